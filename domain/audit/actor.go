@@ -0,0 +1,32 @@
+package audit
+
+import "context"
+
+// Actor 操作人，写入审计日志
+type Actor struct {
+	UserID   string
+	Username string
+	TraceID  string
+	IP       string
+}
+
+type actorKey struct{}
+
+// WithActor 将操作人放入 ctx
+// 使用函数延迟获取，中间件注册在鉴权之前，执行 sql 时根据当时的 ctx 取登录用户
+// fn 不应当引用 *gin.Context，请求结束后它会被复用
+func WithActor(ctx context.Context, fn func(context.Context) Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, fn)
+}
+
+// ActorFrom 获取 ctx 中的操作人，没有时返回零值，例如后台任务
+func ActorFrom(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	fn, ok := ctx.Value(actorKey{}).(func(context.Context) Actor)
+	if !ok {
+		return Actor{}
+	}
+	return fn(ctx)
+}
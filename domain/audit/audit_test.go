@@ -0,0 +1,188 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/domain/audit"
+	"github.com/ixugo/goddd/domain/audit/store/auditdb"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	orm.DeletedModel
+	Name     string `gorm:"notNull;default:''"`
+	Password string `gorm:"notNull;default:''"`
+}
+
+func (*item) TableName() string {
+	return "items"
+}
+
+type other struct {
+	ID   int `gorm:"primaryKey"`
+	Name string
+}
+
+func newTestCore(t *testing.T) (*gorm.DB, audit.Core) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(new(item), new(other)); err != nil {
+		t.Fatal(err)
+	}
+	store := auditdb.NewDB(db).AutoMigrate(true)
+	if err := db.Use(auditdb.NewPlugin(audit.Config{Tables: []string{"items"}})); err != nil {
+		t.Fatal(err)
+	}
+	return db, audit.NewCore(store)
+}
+
+func findLog(t *testing.T, core audit.Core, in audit.FindLogInput) []*audit.Log {
+	t.Helper()
+	in.Size = 100
+	items, total, err := core.FindLog(context.Background(), &in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int(total) != len(items) {
+		t.Fatalf("expect total %d, got %d", len(items), total)
+	}
+	return items
+}
+
+func TestAudit(t *testing.T) {
+	db, core := newTestCore(t)
+	ctx := audit.WithActor(context.Background(), func(context.Context) audit.Actor {
+		return audit.Actor{UserID: "7", Username: "admin", TraceID: "trace", IP: "127.0.0.1"}
+	})
+
+	a := item{Name: "a", Password: "p"}
+	if err := db.WithContext(ctx).Create(&a).Error; err != nil {
+		t.Fatal(err)
+	}
+	logs := findLog(t, core, audit.FindLogInput{Entity: "items", EntityID: "1"})
+	if len(logs) != 1 {
+		t.Fatalf("expect 1 log, got %d", len(logs))
+	}
+	v := logs[0]
+	if v.Action != audit.ActionCreate || v.UserID != "7" || v.Username != "admin" || v.TraceID != "trace" || v.IP != "127.0.0.1" {
+		t.Fatalf("unexpected %+v", v)
+	}
+	if string(v.Changes["name"].After) != `"a"` || v.Changes["name"].Before != nil {
+		t.Fatalf("unexpected name %+v", v.Changes["name"])
+	}
+	if string(v.Changes["password"].After) != `"******"` {
+		t.Fatalf("expect password masked, got %s", v.Changes["password"].After)
+	}
+
+	// 只记录变更的字段，忽略 updated_at
+	if err := db.WithContext(ctx).Model(&a).Update("name", "b").Error; err != nil {
+		t.Fatal(err)
+	}
+	logs = findLog(t, core, audit.FindLogInput{Entity: "items", Action: audit.ActionUpdate})
+	if len(logs) != 1 || len(logs[0].Changes) != 1 {
+		t.Fatalf("expect 1 change, got %+v", logs)
+	}
+	if c := logs[0].Changes["name"]; string(c.Before) != `"a"` || string(c.After) != `"b"` {
+		t.Fatalf("unexpected name %+v", c)
+	}
+
+	// 批量更新按行记录，没有变化的行不记录
+	if err := db.WithContext(ctx).Create(&item{Name: "c"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithContext(ctx).Model(new(item)).Where("id > 0").Update("name", "c").Error; err != nil {
+		t.Fatal(err)
+	}
+	if logs = findLog(t, core, audit.FindLogInput{Entity: "items", Action: audit.ActionUpdate}); len(logs) != 2 || logs[0].EntityID != "1" {
+		t.Fatalf("expect 2 update logs, got %d", len(logs))
+	}
+
+	// 软删除与恢复
+	if err := orm.DeleteWithContext(ctx, db, new(item), orm.Where("id = ?", 2)); err != nil {
+		t.Fatal(err)
+	}
+	logs = findLog(t, core, audit.FindLogInput{Entity: "items", EntityID: "2", Action: audit.ActionDelete})
+	if len(logs) != 1 || string(logs[0].Changes["name"].Before) != `"c"` || logs[0].Changes["name"].After != nil {
+		t.Fatalf("unexpected delete logs %+v", logs)
+	}
+	if _, err := orm.RestoreWithContext[item](ctx, db, orm.Where("id = ?", 2)); err != nil {
+		t.Fatal(err)
+	}
+	logs = findLog(t, core, audit.FindLogInput{Entity: "items", EntityID: "2", Action: audit.ActionUpdate})
+	if len(logs) != 1 || string(logs[0].Changes["deleted_at"].After) != "null" {
+		t.Fatalf("unexpected restore logs %+v", logs)
+	}
+
+	// 事务回滚时日志一并回滚
+	errRollback := errors.New("rollback")
+	err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		if err := orm.Conn(ctx, db).Model(&a).Update("name", "d").Error; err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+
+	// 未配置的表与无登录用户
+	if err := db.Create(&other{Name: "x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&a).Update("name", "e").Error; err != nil {
+		t.Fatal(err)
+	}
+	if logs = findLog(t, core, audit.FindLogInput{Entity: "others"}); len(logs) != 0 {
+		t.Fatalf("expect no log, got %d", len(logs))
+	}
+	if logs = findLog(t, core, audit.FindLogInput{UserID: "7"}); len(logs) != 6 {
+		t.Fatalf("expect 6 logs, got %d", len(logs))
+	}
+	if logs = findLog(t, core, audit.FindLogInput{}); len(logs) != 7 || logs[0].UserID != "" {
+		t.Fatalf("expect 7 logs, got %d", len(logs))
+	}
+
+	now := time.Now()
+	logs = findLog(t, core, audit.FindLogInput{DateFilter: web.DateFilter{StartMs: now.Add(-time.Minute).UnixMilli(), EndMs: now.Add(time.Minute).UnixMilli()}})
+	if len(logs) != 7 {
+		t.Fatalf("expect 7 logs in range, got %d", len(logs))
+	}
+
+	// 游标分页按主键倒序，与 FindLog 结果一致
+	in := audit.FindLogInput{PagerFilter: web.PagerFilter{Size: 3}}
+	var ids []int64
+	for range 4 {
+		out, err := core.ScrollLog(context.Background(), &in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range out.Items {
+			ids = append(ids, v.ID)
+		}
+		if in.Cursor = out.Next; in.Cursor == "" {
+			break
+		}
+	}
+	if len(ids) != 7 || ids[0] != logs[0].ID || ids[6] != logs[6].ID {
+		t.Fatalf("unexpected scroll %v", ids)
+	}
+	in.Cursor = "invalid"
+	if _, err := core.ScrollLog(context.Background(), &in); !errors.Is(err, reason.ErrBadRequest) {
+		t.Fatalf("expect ErrBadRequest, got %v", err)
+	}
+
+	if n, err := core.PurgeLog(context.Background(), now.Add(time.Minute)); err != nil || n != 7 {
+		t.Fatalf("expect 7 purged, got %d %v", n, err)
+	}
+}
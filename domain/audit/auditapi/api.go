@@ -0,0 +1,62 @@
+package auditapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/domain/audit"
+	"github.com/ixugo/goddd/domain/audit/store/auditdb"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+type API struct {
+	AuditCore audit.Core
+}
+
+// New 在 db 上注册审计回调，之后 cfg.Tables 中的表的增删改都会记录
+// 操作人由 Actor 中间件放入请求的 ctx，store 应当使用 orm.Conn(ctx, db) 传递
+func New(db *gorm.DB, cfg audit.Config) (API, error) {
+	store := auditdb.NewDB(db).AutoMigrate(orm.GetEnabledAutoMigrate())
+	if err := db.Use(auditdb.NewPlugin(cfg)); err != nil {
+		return API{}, err
+	}
+	return API{AuditCore: audit.NewCore(store)}, nil
+}
+
+func Register(r gin.IRouter, api API, handler ...gin.HandlerFunc) {
+	group := r.Group("/audit-logs", handler...)
+	web.Handle(group, http.MethodGet, "", api.findLog)
+}
+
+// Actor 将操作人放入请求的 ctx，注册为全局中间件
+// IP 与 trace id 此时即可确定；登录用户由之后的鉴权中间件放入 ctx，执行 sql 时从 ctx 中读取
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base := audit.Actor{IP: c.ClientIP()}
+		base.TraceID, _ = web.TraceID(c)
+		ctx := audit.WithActor(c.Request.Context(), func(ctx context.Context) audit.Actor {
+			actor := base
+			claims := web.ClaimsDataFrom(ctx)
+			actor.Username = web.GetUsername(claims)
+			if uid := web.GetUID(claims); uid > 0 {
+				actor.UserID = strconv.Itoa(uid)
+			}
+			return actor
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// findLog 携带 cursor 参数时游标分页，返回 {items,next}，否则返回 {items,total}
+func (a API) findLog(c *gin.Context, in *audit.FindLogInput) (any, error) {
+	if in.UseCursor() {
+		return a.AuditCore.ScrollLog(c.Request.Context(), in)
+	}
+	items, total, err := a.AuditCore.FindLog(c.Request.Context(), in)
+	return &web.PageOutput[*audit.Log]{Items: items, Total: total}, err
+}
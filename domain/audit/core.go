@@ -0,0 +1,16 @@
+package audit
+
+// Storer data persistence
+type Storer interface {
+	Log() LogStorer
+}
+
+// Core business domain
+type Core struct {
+	store Storer
+}
+
+// NewCore create business domain
+func NewCore(store Storer) Core {
+	return Core{store: store}
+}
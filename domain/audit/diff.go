@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// masked 脱敏字段记录的值
+var masked = json.RawMessage(`"******"`)
+
+// Config 审计范围
+type Config struct {
+	// Tables 记录变更的表名，未列出的表不记录
+	Tables []string
+	// Ignore 不比较的字段，为空时默认 updated_at、version
+	Ignore []string
+	// Mask 只记录是否变更，不记录取值的字段，字段名包含其中任意一项即脱敏
+	// 为空时默认 password、secret、hash
+	Mask []string
+}
+
+// Audited 表是否需要记录，审计日志自身永不记录
+func (c Config) Audited(table string) bool {
+	return table != "audit_logs" && slices.Contains(c.Tables, table)
+}
+
+func (c Config) ignored(field string) bool {
+	ignore := c.Ignore
+	if len(ignore) == 0 {
+		ignore = []string{"updated_at", "version"}
+	}
+	return slices.Contains(ignore, field)
+}
+
+func (c Config) masked(field string) bool {
+	mask := c.Mask
+	if len(mask) == 0 {
+		mask = []string{"password", "secret", "hash"}
+	}
+	for _, v := range mask {
+		if strings.Contains(field, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff 比较变更前后的字段，key 为字段名
+// before 为 nil 表示新增，after 为 nil 表示删除，此时记录全部字段
+func (c Config) Diff(before, after map[string]any) Changes {
+	out := make(Changes)
+	encode := func(m map[string]any, k string) json.RawMessage {
+		if m == nil {
+			return nil
+		}
+		b, err := json.Marshal(m[k])
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	for k := range keys {
+		if c.ignored(k) {
+			continue
+		}
+		v := Change{Before: encode(before, k), After: encode(after, k)}
+		if before != nil && after != nil && bytes.Equal(v.Before, v.After) {
+			continue
+		}
+		if c.masked(k) {
+			if v.Before != nil {
+				v.Before = masked
+			}
+			if v.After != nil {
+				v.After = masked
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
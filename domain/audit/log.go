@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/web"
+)
+
+// LogStorer Instantiation interface
+type LogStorer interface {
+	List(context.Context, *[]*Log, orm.Pager, ...orm.QueryOption) (int64, error)
+	Scroll(context.Context, *[]*Log, orm.ScrollPager, ...orm.QueryOption) (string, error)
+	// Purge 删除 before 之前的日志
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// FindLog Paginated search
+func (c Core) FindLog(ctx context.Context, in *FindLogInput) ([]*Log, int64, error) {
+	items := make([]*Log, 0, in.Limit())
+	total, err := c.store.Log().List(ctx, &items, in, append(findLogOptions(in), orm.OrderBy("id DESC"))...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
+
+// ScrollLog 游标分页，不统计总数，按主键倒序
+func (c Core) ScrollLog(ctx context.Context, in *FindLogInput) (*web.ScrollPageOutput[*Log], error) {
+	items := make([]*Log, 0, in.Limit())
+	next, err := c.store.Log().Scroll(ctx, &items, in, findLogOptions(in)...)
+	if errors.Is(err, orm.ErrInvalidCursor) {
+		return nil, reason.ErrBadRequest.SetMsg("游标已失效，请刷新")
+	}
+	if err != nil {
+		return nil, reason.ErrDB.Withf(`Scroll err[%s]`, err.Error())
+	}
+	return web.NewScrollPageOutput(items, next), nil
+}
+
+// findLogOptions 列表的查询条件，不含排序
+func findLogOptions(in *FindLogInput) []orm.QueryOption {
+	query := orm.NewQuery(6)
+	if in.Entity != "" {
+		query.Where("entity = ?", in.Entity)
+		if in.EntityID != "" {
+			query.Where("entity_id = ?", in.EntityID)
+		}
+	}
+	if in.UserID != "" {
+		query.Where("user_id = ?", in.UserID)
+	}
+	if in.Action != "" {
+		query.Where("action = ?", in.Action)
+	}
+	if in.StartMs > 0 {
+		query.Where("created_at >= ?", in.StartAt())
+	}
+	if in.EndMs > 0 {
+		query.Where("created_at < ?", in.EndAt())
+	}
+	return query.Encode()
+}
+
+// PurgeLog 删除 before 之前的日志，由定时任务按保留时长调用
+func (c Core) PurgeLog(ctx context.Context, before time.Time) (int64, error) {
+	n, err := c.store.Log().Purge(ctx, before)
+	if err != nil {
+		return 0, reason.ErrDB.Withf(`Purge err[%s]`, err.Error())
+	}
+	return n, nil
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+// 变更类型
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Log domain model
+// 审计日志，记录谁在何时修改了哪条数据的哪些字段
+type Log struct {
+	ID        int64    `gorm:"primaryKey" json:"id"`
+	TenantID  string   `gorm:"column:tenant_id;notNull;default:'';index;comment:租户" json:"tenant_id"`                       // 租户，与变更的数据一致
+	Entity    string   `gorm:"column:entity;notNull;default:'';index:idx_audit_logs_entity;comment:表名" json:"entity"`       // 表名
+	EntityID  string   `gorm:"column:entity_id;notNull;default:'';index:idx_audit_logs_entity;comment:主键" json:"entity_id"` // 主键，联合主键以逗号分隔；批量更新无法确定主键时为空
+	Action    string   `gorm:"column:action;notNull;default:'';comment:变更类型" json:"action"`                                 // create/update/delete
+	UserID    string   `gorm:"column:user_id;notNull;default:'';index;comment:操作人" json:"user_id"`                          // 操作人，后台任务等无登录用户时为空
+	Username  string   `gorm:"column:username;notNull;default:'';comment:操作人名称" json:"username"`                            // 操作人名称
+	TraceID   string   `gorm:"column:trace_id;notNull;default:'';comment:请求 id" json:"trace_id"`                            // 请求 id，与访问日志关联
+	IP        string   `gorm:"column:ip;notNull;default:'';comment:客户端 ip" json:"ip"`                                       // 客户端 ip
+	Changes   Changes  `gorm:"column:changes;type:json;comment:变更的字段" json:"changes"`                                       // 变更的字段
+	CreatedAt orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP;index;comment:变更时间" json:"created_at"`    // 变更时间
+}
+
+// TableName database table name
+func (*Log) TableName() string {
+	return "audit_logs"
+}
+
+// Change 字段变更前后的值，新增时没有 before，删除时没有 after
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Changes key 为字段名，以 json 文本存储
+type Changes map[string]Change
+
+var _ orm.JSONValueScanner = (*Changes)(nil)
+
+// Scan implements orm.JSONValueScanner.
+func (c *Changes) Scan(input any) error {
+	return orm.JSONUnmarshal(input, c)
+}
+
+// Value implements orm.JSONValueScanner.
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
@@ -0,0 +1,12 @@
+package audit
+
+import "github.com/ixugo/goddd/pkg/web"
+
+type FindLogInput struct {
+	web.PagerFilter
+	web.DateFilter
+	Entity   string `form:"entity"`    // 表名
+	EntityID string `form:"entity_id"` // 主键，需要同时指定表名
+	UserID   string `form:"user_id"`   // 操作人
+	Action   string `form:"action"`    // create/update/delete
+}
@@ -0,0 +1,36 @@
+package auditdb
+
+import (
+	"github.com/ixugo/goddd/domain/audit"
+	"gorm.io/gorm"
+)
+
+var _ audit.Storer = DB{}
+
+// DB Related business namespaces
+type DB struct {
+	db *gorm.DB
+}
+
+// NewDB instance object
+func NewDB(db *gorm.DB) DB {
+	return DB{db: db}
+}
+
+// Log Get business instance
+func (d DB) Log() audit.LogStorer {
+	return Log(d)
+}
+
+// AutoMigrate sync database
+func (d DB) AutoMigrate(ok bool) DB {
+	if !ok {
+		return d
+	}
+	if err := d.db.AutoMigrate(
+		new(audit.Log),
+	); err != nil {
+		panic(err)
+	}
+	return d
+}
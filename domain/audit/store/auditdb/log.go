@@ -0,0 +1,30 @@
+package auditdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/domain/audit"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ audit.LogStorer = Log{}
+
+// Log Related business namespaces
+type Log DB
+
+// List implements audit.LogStorer.
+func (d Log) List(ctx context.Context, bs *[]*audit.Log, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Scroll implements audit.LogStorer.
+func (d Log) Scroll(ctx context.Context, bs *[]*audit.Log, page orm.ScrollPager, opts ...orm.QueryOption) (string, error) {
+	return orm.ScrollWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Purge implements audit.LogStorer.
+func (d Log) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := orm.Conn(ctx, d.db).Where("created_at < ?", before).Delete(new(audit.Log))
+	return result.RowsAffected, result.Error
+}
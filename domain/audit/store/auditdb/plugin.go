@@ -0,0 +1,313 @@
+package auditdb
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"github.com/ixugo/goddd/domain/audit"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// maxRows 单条语句最多比较的行数，超过时只记录更新的字段，不区分行
+const maxRows = 100
+
+const beforeKey = "audit:before"
+
+var _ gorm.Plugin = (*Plugin)(nil)
+
+// Plugin 通过 gorm 回调记录 Config.Tables 中的表的增删改
+// 更新与删除前按语句的条件查询受影响的行，执行后重新查询并比较字段
+// 审计日志与业务语句使用同一连接，处于 orm.Transaction 中时一起提交或回滚
+type Plugin struct {
+	cfg audit.Config
+}
+
+// NewPlugin 通过 db.Use 注册
+func NewPlugin(cfg audit.Config) *Plugin {
+	return &Plugin{cfg: cfg}
+}
+
+// Name implements gorm.Plugin.
+func (p *Plugin) Name() string {
+	return "audit"
+}
+
+// Initialize implements gorm.Plugin.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("audit:after_create", p.afterCreate); err != nil {
+		return err
+	}
+	// 在租户条件追加之后查询，与业务语句影响的行一致
+	if err := cb.Update().Before("gorm:update").After("orm:tenant_update").Register("audit:before_update", p.before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("audit:after_update", p.afterUpdate); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").After("orm:tenant_delete").Register("audit:before_delete", p.before); err != nil {
+		return err
+	}
+	return cb.Delete().After("gorm:delete").Register("audit:after_delete", p.afterDelete)
+}
+
+func (p *Plugin) audited(db *gorm.DB) bool {
+	return db.Error == nil && !db.DryRun && db.Statement.Schema != nil && p.cfg.Audited(db.Statement.Table)
+}
+
+func (p *Plugin) afterCreate(db *gorm.DB) {
+	if !p.audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	stmt := db.Statement
+	rv := reflect.Indirect(stmt.ReflectValue)
+	logs := make([]*audit.Log, 0, 1)
+	add := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		row := snapshot(db, rv)
+		logs = append(logs, &audit.Log{
+			TenantID: tenantOf(row),
+			EntityID: entityID(stmt.Schema, row),
+			Action:   audit.ActionCreate,
+			Changes:  p.cfg.Diff(nil, row),
+		})
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			add(rv.Index(i))
+		}
+	default:
+		add(rv)
+	}
+	p.write(db, logs)
+}
+
+// before 记录更新与删除前的数据
+func (p *Plugin) before(db *gorm.DB) {
+	if !p.audited(db) || len(db.Statement.Schema.PrimaryFields) == 0 {
+		return
+	}
+	stmt := db.Statement
+	tx := p.query(db)
+	conds := 0
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			tx = tx.Clauses(where)
+			conds++
+		}
+	}
+	if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+		for _, f := range stmt.Schema.PrimaryFields {
+			if v, zero := f.ValueOf(stmt.Context, rv); !zero {
+				tx = tx.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: v})
+				conds++
+			}
+		}
+	}
+	// 没有条件时 gorm 会拒绝执行
+	if conds == 0 {
+		return
+	}
+	rows, err := p.find(db, tx.Limit(maxRows+1))
+	if err != nil {
+		slog.ErrorContext(stmt.Context, "audit snapshot", "table", stmt.Table, "err", err)
+		return
+	}
+	if len(rows) > maxRows {
+		return
+	}
+	stmt.Settings.Store(beforeKey, rows)
+}
+
+func (p *Plugin) afterUpdate(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	stmt := db.Statement
+	v, ok := stmt.Settings.LoadAndDelete(beforeKey)
+	if db.RowsAffected == 0 {
+		return
+	}
+	if !ok {
+		// 无法确定受影响的行，只记录更新的字段
+		set, _ := stmt.Clauses["SET"].Expression.(clause.Set)
+		after := make(map[string]any, len(set))
+		for _, a := range set {
+			after[a.Column.Name] = a.Value
+		}
+		p.write(db, []*audit.Log{{Action: audit.ActionUpdate, Changes: p.cfg.Diff(nil, after)}})
+		return
+	}
+	before := v.([]map[string]any)
+	if len(before) == 0 {
+		return
+	}
+
+	// 按主键重新查询，包含本次更新恢复或删除的数据
+	tx := p.query(db).Unscoped()
+	pks := stmt.Schema.PrimaryFields
+	if len(pks) == 1 {
+		values := make([]any, 0, len(before))
+		for _, row := range before {
+			values = append(values, row[pks[0].DBName])
+		}
+		tx = tx.Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pks[0].DBName}, Values: values})
+	} else {
+		exprs := make([]clause.Expression, 0, len(before))
+		for _, row := range before {
+			eqs := make([]clause.Expression, 0, len(pks))
+			for _, f := range pks {
+				eqs = append(eqs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: row[f.DBName]})
+			}
+			exprs = append(exprs, clause.And(eqs...))
+		}
+		tx = tx.Where(clause.Or(exprs...))
+	}
+	after, err := p.find(db, tx)
+	if err != nil {
+		slog.ErrorContext(stmt.Context, "audit snapshot", "table", stmt.Table, "err", err)
+		return
+	}
+	afterByID := make(map[string]map[string]any, len(after))
+	for _, row := range after {
+		afterByID[entityID(stmt.Schema, row)] = row
+	}
+
+	logs := make([]*audit.Log, 0, len(before))
+	for _, row := range before {
+		id := entityID(stmt.Schema, row)
+		changes := p.cfg.Diff(row, afterByID[id])
+		if len(changes) == 0 {
+			continue
+		}
+		logs = append(logs, &audit.Log{TenantID: tenantOf(row), EntityID: id, Action: audit.ActionUpdate, Changes: changes})
+	}
+	p.write(db, logs)
+}
+
+func (p *Plugin) afterDelete(db *gorm.DB) {
+	if !p.audited(db) {
+		return
+	}
+	stmt := db.Statement
+	v, ok := stmt.Settings.LoadAndDelete(beforeKey)
+	if db.RowsAffected == 0 {
+		return
+	}
+	if !ok {
+		p.write(db, []*audit.Log{{Action: audit.ActionDelete}})
+		return
+	}
+	before := v.([]map[string]any)
+	logs := make([]*audit.Log, 0, len(before))
+	for _, row := range before {
+		logs = append(logs, &audit.Log{
+			TenantID: tenantOf(row),
+			EntityID: entityID(stmt.Schema, row),
+			Action:   audit.ActionDelete,
+			Changes:  p.cfg.Diff(row, nil),
+		})
+	}
+	p.write(db, logs)
+}
+
+// query 与业务语句使用同一连接的新会话，跳过模型的 hook
+// 条件复制自业务语句，已包含租户条件，不再重复过滤；读写分离时读取主库
+func (p *Plugin) query(db *gorm.DB) *gorm.DB {
+	stmt := db.Statement
+	tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(stmt.Table)
+	tx = orm.Primary()(orm.AllTenants()(tx))
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+	return tx
+}
+
+// find 查询并转换为字段名与值的映射
+func (p *Plugin) find(db *gorm.DB, tx *gorm.DB) ([]map[string]any, error) {
+	stmt := db.Statement
+	dest := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+	if err := tx.Model(reflect.New(stmt.Schema.ModelType).Interface()).Find(dest.Interface()).Error; err != nil {
+		return nil, err
+	}
+	rv := dest.Elem()
+	out := make([]map[string]any, 0, rv.Len())
+	for i := range rv.Len() {
+		out = append(out, snapshot(db, rv.Index(i)))
+	}
+	return out, nil
+}
+
+// write 写入审计日志，失败时不影响业务语句
+// 处于事务中时返回错误，由调用方回滚，保证变更与日志一致
+func (p *Plugin) write(db *gorm.DB, logs []*audit.Log) {
+	if len(logs) == 0 {
+		return
+	}
+	stmt := db.Statement
+	actor := audit.ActorFrom(stmt.Context)
+	tenantID, _ := orm.TenantFrom(stmt.Context)
+	now := orm.Now()
+	for _, v := range logs {
+		// 日志归属于变更数据的租户，无法确定时使用 ctx 中的租户
+		if v.TenantID == "" {
+			v.TenantID = tenantID
+		}
+		v.Entity = stmt.Table
+		v.UserID = actor.UserID
+		v.Username = actor.Username
+		v.TraceID = actor.TraceID
+		v.IP = actor.IP
+		v.CreatedAt = now
+	}
+	err := orm.AllTenants()(db.Session(&gorm.Session{NewDB: true, SkipHooks: true})).Create(&logs).Error
+	if err == nil {
+		return
+	}
+	slog.ErrorContext(stmt.Context, "audit write", "table", stmt.Table, "err", err)
+	if orm.InTransaction(stmt.Context) {
+		_ = db.AddError(fmt.Errorf("audit: %w", err))
+	}
+}
+
+// snapshot 读取模型的全部字段
+func snapshot(db *gorm.DB, rv reflect.Value) map[string]any {
+	rv = reflect.Indirect(rv)
+	fields := db.Statement.Schema.Fields
+	out := make(map[string]any, len(fields))
+	for _, f := range fields {
+		if f.DBName == "" || !f.Readable {
+			continue
+		}
+		v, _ := f.ValueOf(db.Statement.Context, rv)
+		out[f.DBName] = v
+	}
+	return out
+}
+
+// entityID 主键的值，联合主键以逗号分隔
+func entityID(s *schema.Schema, row map[string]any) string {
+	ids := make([]string, 0, len(s.PrimaryFields))
+	for _, f := range s.PrimaryFields {
+		ids = append(ids, fmt.Sprint(row[f.DBName]))
+	}
+	return strings.Join(ids, ",")
+}
+
+// tenantOf 数据所属的租户，模型没有租户字段时为空
+func tenantOf(row map[string]any) string {
+	v, ok := row[orm.TenantColumn]
+	if !ok || v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
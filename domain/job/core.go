@@ -0,0 +1,22 @@
+// Code generated by godddx, DO AVOID EDIT.
+package job
+
+import "context"
+
+// Storer data persistence
+type Storer interface {
+	Job() JobStorer
+	Schedule() ScheduleStorer
+	// Transaction fn 内的 store 操作在同一事务中
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Core business domain
+type Core struct {
+	store Storer
+}
+
+// NewCore create business domain
+func NewCore(store Storer) Core {
+	return Core{store: store}
+}
@@ -0,0 +1,86 @@
+// Code generated by godddx, DO AVOID EDIT.
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// JobStorer Instantiation interface
+type JobStorer interface {
+	List(context.Context, *[]*Job, orm.Pager, ...orm.QueryOption) (int64, error)
+	Get(context.Context, *Job, ...orm.QueryOption) error
+	Create(context.Context, *Job) error
+	// Claim 取出到期的任务并标记为执行中，run_at 延后至 leaseUntil
+	// 租约到期且已达到最大执行次数的任务标记为失败
+	// postgres 使用 FOR UPDATE SKIP LOCKED，多副本互不阻塞；sqlite 只有一个写连接，事务内天然串行
+	Claim(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]*Job, error)
+	// Release 记录执行结果，仅当任务仍处于本次抢占的状态时生效，返回是否生效
+	Release(ctx context.Context, id int64, attempts int, values map[string]any) (bool, error)
+	// Transition 将 from 状态的任务更新为 values，返回变更的数量
+	Transition(ctx context.Context, ids []int64, from []string, values map[string]any) (int64, error)
+	// Purge 删除 before 之前结束的任务
+	Purge(ctx context.Context, before time.Time, status ...string) (int64, error)
+}
+
+// FindJob Paginated search
+func (c Core) FindJob(ctx context.Context, in *FindJobInput) ([]*Job, int64, error) {
+	query := orm.NewQuery(3)
+	if in.Name != "" {
+		query.Where("name = ?", in.Name)
+	}
+	if in.Status != "" {
+		query.Where("status = ?", in.Status)
+	}
+	query.OrderBy("id DESC")
+
+	items := make([]*Job, 0, in.Limit())
+	total, err := c.store.Job().List(ctx, &items, in, query.Encode()...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
+
+// GetJob Query a single object
+func (c Core) GetJob(ctx context.Context, id int64) (*Job, error) {
+	var out Job
+	if err := c.store.Job().Get(ctx, &out, orm.Where("id=?", id)); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrNotFound.Withf(`Get err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Get err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// RetryJob 将失败或已取消的任务重新放回队列，重置执行次数
+func (c Core) RetryJob(ctx context.Context, ids ...int64) (int64, error) {
+	now := time.Now()
+	n, err := c.store.Job().Transition(ctx, ids, []string{StatusFailed, StatusCanceled}, map[string]any{
+		"status":     StatusPending,
+		"attempts":   0,
+		"run_at":     now,
+		"updated_at": now,
+	})
+	if err != nil {
+		return 0, reason.ErrDB.Withf(`Retry err[%s]`, err.Error())
+	}
+	return n, nil
+}
+
+// CancelJob 取消等待或执行中的任务
+// 执行中的任务不会被中断，但执行结果不再记录，也不会重试
+func (c Core) CancelJob(ctx context.Context, ids ...int64) (int64, error) {
+	n, err := c.store.Job().Transition(ctx, ids, []string{StatusPending, StatusRunning}, map[string]any{
+		"status":     StatusCanceled,
+		"updated_at": time.Now(),
+	})
+	if err != nil {
+		return 0, reason.ErrDB.Withf(`Cancel err[%s]`, err.Error())
+	}
+	return n, nil
+}
@@ -0,0 +1,50 @@
+// Code generated by godddx, DO AVOID EDIT.
+package job
+
+import "github.com/ixugo/goddd/pkg/orm"
+
+// 任务状态
+const (
+	StatusPending   = "pending"   // 等待执行或重试
+	StatusRunning   = "running"   // 执行中，租约到期后视为中断，重新执行
+	StatusSucceeded = "succeeded" // 执行成功
+	StatusFailed    = "failed"    // 超过最大执行次数
+	StatusCanceled  = "canceled"  // 已取消
+)
+
+// Job domain model
+// 后台任务，Name 对应 Handle 注册的处理函数
+type Job struct {
+	ID          int64    `gorm:"primaryKey" json:"id"`
+	Name        string   `gorm:"column:name;notNull;default:'';index;comment:任务名称" json:"name"`                                        // 任务名称
+	Payload     string   `gorm:"column:payload;notNull;default:'';comment:任务参数" json:"payload"`                                        // 任务参数，json
+	Status      string   `gorm:"column:status;notNull;default:'';index:idx_jobs_status_run;comment:任务状态" json:"status"`                // 任务状态
+	Attempts    int      `gorm:"column:attempts;notNull;default:0;comment:执行次数" json:"attempts"`                                       // 执行次数
+	MaxAttempts int      `gorm:"column:max_attempts;notNull;default:0;comment:最大执行次数" json:"max_attempts"`                             // 最大执行次数
+	RunAt       orm.Time `gorm:"column:run_at;notNull;default:CURRENT_TIMESTAMP;index:idx_jobs_status_run;comment:执行时间" json:"run_at"` // 下次执行时间，执行中为租约到期时间
+	Schedule    string   `gorm:"column:schedule;notNull;default:'';comment:来源的周期任务" json:"schedule"`                                   // 由周期任务创建时的名称
+	LastError   string   `gorm:"column:last_error;notNull;default:'';comment:最后一次失败原因" json:"last_error"`                              // 最后一次失败原因
+	CreatedAt   orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName database table name
+func (*Job) TableName() string {
+	return "jobs"
+}
+
+// Schedule domain model
+// 周期任务，多副本通过 Runs 条件更新保证同一周期只创建一次任务
+type Schedule struct {
+	Name      string   `gorm:"primaryKey;column:name;comment:任务名称" json:"name"`                                        // 任务名称
+	Spec      string   `gorm:"column:spec;notNull;default:'';comment:执行周期" json:"spec"`                                // 执行周期
+	Runs      int64    `gorm:"column:runs;notNull;default:0;comment:已触发次数" json:"runs"`                                // 已触发次数
+	NextRunAt orm.Time `gorm:"column:next_run_at;notNull;default:CURRENT_TIMESTAMP;comment:下次执行时间" json:"next_run_at"` // 下次执行时间
+	CreatedAt orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName database table name
+func (*Schedule) TableName() string {
+	return "job_schedules"
+}
@@ -0,0 +1,18 @@
+// Code generated by godddx, DO AVOID EDIT.
+package job
+
+import "github.com/ixugo/goddd/pkg/web"
+
+type FindJobInput struct {
+	web.PagerFilter
+	Name   string `form:"name"`   // 任务名称
+	Status string `form:"status"` // 任务状态
+}
+
+type JobIDsInput struct {
+	IDs []int64 `json:"ids" binding:"required,min=1,max=1000"` // 任务 id
+}
+
+type JobIDsOutput struct {
+	Affected int64 `json:"affected"` // 实际变更的数量，状态不符的任务被忽略
+}
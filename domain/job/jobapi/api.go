@@ -0,0 +1,72 @@
+package jobapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/domain/job/store/jobdb"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+type API struct {
+	JobCore job.Core
+	Queue   *job.Queue
+}
+
+// New 任务由 Queue.Run 执行，需要在程序启动时运行
+// 处理函数通过 job.Handle 注册到 Queue
+func New(db *gorm.DB, cfg job.Config) API {
+	store := jobdb.NewDB(db).AutoMigrate(orm.GetEnabledAutoMigrate())
+	return API{
+		JobCore: job.NewCore(store),
+		Queue:   job.NewQueue(store, cfg),
+	}
+}
+
+func Register(r gin.IRouter, api API, handler ...gin.HandlerFunc) {
+	{
+		group := r.Group("/jobs", handler...)
+		web.Handle(group, http.MethodGet, "", api.findJob)
+		web.Handle(group, http.MethodGet, "/:id", api.getJob)
+		web.Handle(group, http.MethodGet, "/schedules", api.findSchedule)
+	}
+	web.CustomEndpoints(r.Group("", handler...), "/jobs", map[string]web.Endpoint{
+		"retry":  web.NewEndpoint(api.retryJob),
+		"cancel": web.NewEndpoint(api.cancelJob),
+	})
+}
+
+func (a API) findJob(c *gin.Context, in *job.FindJobInput) (*web.PageOutput[*job.Job], error) {
+	items, total, err := a.JobCore.FindJob(c.Request.Context(), in)
+	return &web.PageOutput[*job.Job]{Items: items, Total: total}, err
+}
+
+func (a API) getJob(c *gin.Context, _ *struct{}) (*job.Job, error) {
+	jobID, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+	return a.JobCore.GetJob(c.Request.Context(), jobID)
+}
+
+func (a API) findSchedule(c *gin.Context, _ *struct{}) (gin.H, error) {
+	items, err := a.JobCore.FindSchedule(c.Request.Context())
+	return gin.H{"items": items}, err
+}
+
+func (a API) retryJob(c *gin.Context, in *job.JobIDsInput) (*job.JobIDsOutput, error) {
+	n, err := a.JobCore.RetryJob(c.Request.Context(), in.IDs...)
+	if err != nil {
+		return nil, err
+	}
+	return &job.JobIDsOutput{Affected: n}, nil
+}
+
+func (a API) cancelJob(c *gin.Context, in *job.JobIDsInput) (*job.JobIDsOutput, error) {
+	n, err := a.JobCore.CancelJob(c.Request.Context(), in.IDs...)
+	if err != nil {
+		return nil, err
+	}
+	return &job.JobIDsOutput{Affected: n}, nil
+}
@@ -0,0 +1,382 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+// Config 执行配置，零值使用默认值
+type Config struct {
+	Workers      int           // 并发执行数量，默认 4
+	PollInterval time.Duration // 轮询间隔，默认 1 秒，事务提交后会立即唤醒
+	MaxAttempts  int           // 默认最大执行次数，默认 5
+	MinBackoff   time.Duration // 首次重试间隔，之后翻倍，默认 5 秒
+	MaxBackoff   time.Duration // 最大重试间隔，默认 1 小时
+	Lease        time.Duration // 单次执行的超时时间，进程崩溃后超过此时间重新执行，默认 5 分钟
+	Retention    time.Duration // 已结束任务的保留时间，默认 7 天
+}
+
+func (c *Config) setDefaults() {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 5 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.Lease <= 0 {
+		c.Lease = 5 * time.Minute
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+}
+
+// ErrHandlerNotFound 任务名称未通过 Handle 注册
+var ErrHandlerNotFound = errors.New("job: handler not found")
+
+type schedule struct {
+	spec    string
+	next    Spec
+	payload string
+}
+
+// Queue 持久化的后台任务队列
+// 任务先写入数据库，再由 Run 取出执行，失败后退避重试，进程重启后未完成的任务会继续
+type Queue struct {
+	store Storer
+	cfg   Config
+	g     *conc.G
+	wake  chan struct{}
+
+	mu        sync.Mutex
+	handlers  map[string]func(context.Context, []byte) error
+	schedules map[string]schedule
+	running   int
+}
+
+// NewQueue 创建任务队列
+func NewQueue(store Storer, cfg Config) *Queue {
+	cfg.setDefaults()
+	return &Queue{
+		store:     store,
+		cfg:       cfg,
+		g:         conc.New(nil),
+		wake:      make(chan struct{}, 1),
+		handlers:  make(map[string]func(context.Context, []byte) error),
+		schedules: make(map[string]schedule),
+	}
+}
+
+// Handle 注册 name 对应的处理函数，payload 为 Enqueue 传入的参数
+// 应当在 Run 之前完成注册，同一任务可能重复执行，fn 需要保证幂等
+func Handle[T any](q *Queue, name string, fn func(ctx context.Context, payload T) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.handlers[name]; ok {
+		panic(fmt.Sprintf("job: handler %s already exists", name))
+	}
+	q.handlers[name] = func(ctx context.Context, b []byte) error {
+		var payload T
+		if len(b) > 0 {
+			if err := json.Unmarshal(b, &payload); err != nil {
+				return err
+			}
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// EnqueueOption 任务选项
+type EnqueueOption func(*Job)
+
+// Delay 延迟执行
+func Delay(d time.Duration) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = orm.Time{Time: j.RunAt.Add(d)}
+	}
+}
+
+// RunAt 指定执行时间
+func RunAt(t time.Time) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = orm.Time{Time: t}
+	}
+}
+
+// MaxAttempts 最大执行次数，默认使用 Config.MaxAttempts
+func MaxAttempts(n int) EnqueueOption {
+	return func(j *Job) {
+		j.MaxAttempts = n
+	}
+}
+
+// Enqueue 创建任务，ctx 处于 orm.Transaction 中时加入该事务，事务回滚时任务一并丢弃
+func (q *Queue) Enqueue(ctx context.Context, name string, payload any, opts ...EnqueueOption) (*Job, error) {
+	q.mu.Lock()
+	_, ok := q.handlers[name]
+	q.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrHandlerNotFound, name)
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return q.create(ctx, name, string(b), "", opts...)
+}
+
+func (q *Queue) create(ctx context.Context, name, payload, from string, opts ...EnqueueOption) (*Job, error) {
+	now := orm.Now()
+	job := Job{
+		Name:        name,
+		Payload:     payload,
+		Status:      StatusPending,
+		MaxAttempts: q.cfg.MaxAttempts,
+		RunAt:       now,
+		Schedule:    from,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+	if err := q.store.Job().Create(ctx, &job); err != nil {
+		return nil, err
+	}
+	orm.AfterCommit(ctx, q.notify)
+	return &job, nil
+}
+
+// Schedule 按 spec 周期性创建 name 任务，spec 格式参考 ParseSpec
+// 多副本同时运行时，同一周期只会创建一次任务
+func (q *Queue) Schedule(name, spec string, payload any) error {
+	next, err := ParseSpec(spec)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.handlers[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrHandlerNotFound, name)
+	}
+	q.schedules[name] = schedule{spec: spec, next: next, payload: string(b)}
+	return nil
+}
+
+// notify 唤醒 Run 立即执行
+func (q *Queue) notify(context.Context) {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 执行到期的任务，直到 ctx 结束，退出前等待正在执行的任务
+func (q *Queue) Run(ctx context.Context) {
+	defer q.g.Wait()
+
+	var synced bool
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+	for {
+		// 写入周期任务失败时下次轮询重试
+		if !synced {
+			err := q.syncSchedules(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "job sync schedules", "err", err)
+			}
+			synced = err == nil
+		}
+		if err := q.trigger(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "job trigger schedules", "err", err)
+		}
+		if err := q.dispatch(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "job dispatch", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		case <-purge.C:
+			before := time.Now().Add(-q.cfg.Retention)
+			if _, err := q.store.Job().Purge(ctx, before, StatusSucceeded, StatusCanceled); err != nil {
+				slog.ErrorContext(ctx, "job purge", "err", err)
+			}
+		}
+	}
+}
+
+// syncSchedules 写入周期任务，执行周期变更时重新计算下次执行时间
+func (q *Queue) syncSchedules(ctx context.Context) error {
+	now := time.Now()
+	for name, s := range q.snapshot() {
+		var row Schedule
+		err := q.store.Schedule().Get(ctx, &row, orm.Where("name = ?", name))
+		switch {
+		case orm.IsErrRecordNotFound(err):
+			err = q.store.Schedule().Create(ctx, &Schedule{
+				Name:      name,
+				Spec:      s.spec,
+				NextRunAt: orm.Time{Time: s.next.Next(now)},
+				CreatedAt: orm.Time{Time: now},
+				UpdatedAt: orm.Time{Time: now},
+			})
+		case err == nil && row.Spec != s.spec:
+			err = q.store.Schedule().Reset(ctx, name, s.spec, s.next.Next(now))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trigger 为到期的周期任务创建任务，错过的周期只补一次
+func (q *Queue) trigger(ctx context.Context) error {
+	schedules := q.snapshot()
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var rows []*Schedule
+	if _, err := q.store.Schedule().List(ctx, &rows, nil, orm.Where("next_run_at <= ?", now)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		s, ok := schedules[row.Name]
+		if !ok || s.spec != row.Spec {
+			continue
+		}
+		if err := q.store.Transaction(ctx, func(ctx context.Context) error {
+			ok, err := q.store.Schedule().Advance(ctx, row.Name, row.Runs, s.next.Next(now))
+			if err != nil || !ok {
+				return err
+			}
+			_, err = q.create(ctx, row.Name, s.payload, row.Name)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *Queue) snapshot() map[string]schedule {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return maps.Clone(q.schedules)
+}
+
+// dispatch 按空闲的并发数取出任务，交给 conc.G 执行
+func (q *Queue) dispatch(ctx context.Context) error {
+	q.mu.Lock()
+	free := q.cfg.Workers - q.running
+	q.mu.Unlock()
+	if free <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	jobs, err := q.store.Job().Claim(ctx, now, free, now.Add(q.cfg.Lease))
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		q.mu.Lock()
+		q.running++
+		q.mu.Unlock()
+		q.g.GoRun(func() {
+			defer func() {
+				q.mu.Lock()
+				q.running--
+				q.mu.Unlock()
+				q.notify(ctx)
+			}()
+			q.execute(ctx, job)
+		})
+	}
+	return nil
+}
+
+func (q *Queue) execute(ctx context.Context, job *Job) {
+	err := q.call(ctx, job)
+	// 服务关闭导致的中断不计入结果，租约到期后重新执行
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	data := map[string]any{"updated_at": now}
+	switch {
+	case err == nil:
+		data["status"] = StatusSucceeded
+		data["last_error"] = ""
+	case job.Attempts >= job.MaxAttempts:
+		data["status"] = StatusFailed
+		data["last_error"] = err.Error()
+		slog.ErrorContext(ctx, "job failed", "id", job.ID, "name", job.Name, "attempts", job.Attempts, "err", err)
+	default:
+		data["status"] = StatusPending
+		data["run_at"] = now.Add(q.backoff(job.Attempts))
+		data["last_error"] = err.Error()
+		slog.WarnContext(ctx, "job retry", "id", job.ID, "name", job.Name, "attempts", job.Attempts, "err", err)
+	}
+	// 执行完成后使用不会被取消的 ctx 记录结果，避免关闭时重复执行
+	if _, err := q.store.Job().Release(context.WithoutCancel(ctx), job.ID, job.Attempts, data); err != nil {
+		slog.ErrorContext(ctx, "job release", "id", job.ID, "err", err)
+	}
+}
+
+func (q *Queue) call(ctx context.Context, job *Job) (err error) {
+	q.mu.Lock()
+	fn, ok := q.handlers[job.Name]
+	q.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrHandlerNotFound, job.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.cfg.Lease)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PANIC[%v] TRACE[%s]", r, debug.Stack())
+		}
+	}()
+	return fn(ctx, []byte(job.Payload))
+}
+
+// backoff 按执行次数翻倍
+func (q *Queue) backoff(attempts int) time.Duration {
+	d := q.cfg.MinBackoff
+	for i := 1; i < attempts && d < q.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.cfg.MaxBackoff)
+}
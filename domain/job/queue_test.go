@@ -0,0 +1,253 @@
+package job_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/domain/job/store/jobdb"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) jobdb.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return jobdb.NewDB(db).AutoMigrate(true)
+}
+
+func waitFor(t *testing.T, msg string, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type sendEmail struct {
+	To string `json:"to"`
+}
+
+func TestQueue(t *testing.T) {
+	store := newTestStore(t)
+	core := job.NewCore(store)
+	ctx := context.Background()
+
+	q := job.NewQueue(store, job.Config{MinBackoff: time.Millisecond, PollInterval: 5 * time.Millisecond})
+	var calls atomic.Int32
+	job.Handle(q, "email", func(_ context.Context, in sendEmail) error {
+		if in.To != "a@b.c" {
+			return errors.New("bad payload")
+		}
+		if calls.Add(1) == 1 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	var panics atomic.Int32
+	job.Handle(q, "panic", func(context.Context, struct{}) error {
+		panics.Add(1)
+		panic("boom")
+	})
+
+	if _, err := q.Enqueue(ctx, "unknown", nil); !errors.Is(err, job.ErrHandlerNotFound) {
+		t.Fatalf("expect ErrHandlerNotFound, got %v", err)
+	}
+	// 事务回滚时任务一并丢弃
+	errRollback := errors.New("rollback")
+	if err := store.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := q.Enqueue(ctx, "email", sendEmail{To: "x"}); err != nil {
+			return err
+		}
+		return errRollback
+	}); !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	email, err := q.Enqueue(ctx, "email", sendEmail{To: "a@b.c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	panicked, err := q.Enqueue(ctx, "panic", nil, job.MaxAttempts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go q.Run(runCtx)
+
+	waitFor(t, "email not succeeded", func() bool {
+		v, err := core.GetJob(ctx, email.ID)
+		return err == nil && v.Status == job.StatusSucceeded && v.Attempts == 2
+	})
+	var v *job.Job
+	waitFor(t, "panic job not failed", func() bool {
+		v, err = core.GetJob(ctx, panicked.ID)
+		return err == nil && v.Status == job.StatusFailed
+	})
+	if v.Attempts != 2 || v.LastError == "" {
+		t.Fatalf("unexpected job %+v", v)
+	}
+	_, total, err := core.FindJob(ctx, &job.FindJobInput{})
+	if err != nil || total != 2 {
+		t.Fatalf("expect 2 jobs, got %d %v", total, err)
+	}
+
+	// 失败的任务重新执行
+	n, err := core.RetryJob(ctx, panicked.ID, email.ID)
+	if err != nil || n != 1 {
+		t.Fatalf("retry %d %v", n, err)
+	}
+	waitFor(t, "panic job not retried", func() bool {
+		v, err = core.GetJob(ctx, panicked.ID)
+		return err == nil && v.Status == job.StatusFailed && panics.Load() == 4
+	})
+}
+
+func TestQueueCancel(t *testing.T) {
+	store := newTestStore(t)
+	core := job.NewCore(store)
+	ctx := context.Background()
+
+	q := job.NewQueue(store, job.Config{PollInterval: 5 * time.Millisecond})
+	var calls atomic.Int32
+	job.Handle(q, "noop", func(context.Context, struct{}) error {
+		calls.Add(1)
+		return nil
+	})
+	delayed, err := q.Enqueue(ctx, "noop", nil, job.Delay(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, "noop", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := core.CancelJob(ctx, delayed.ID); err != nil || n != 1 {
+		t.Fatalf("cancel %d %v", n, err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go q.Run(runCtx)
+
+	waitFor(t, "job not executed", func() bool { return calls.Load() == 1 })
+	time.Sleep(100 * time.Millisecond)
+	if calls.Load() != 1 {
+		t.Fatalf("canceled job executed, calls %d", calls.Load())
+	}
+	v, err := core.GetJob(ctx, delayed.ID)
+	if err != nil || v.Status != job.StatusCanceled {
+		t.Fatalf("unexpected job %+v %v", v, err)
+	}
+}
+
+func TestClaimExpiredLease(t *testing.T) {
+	store := newTestStore(t)
+	core := job.NewCore(store)
+	ctx := context.Background()
+
+	q := job.NewQueue(store, job.Config{})
+	job.Handle(q, "crash", func(context.Context, struct{}) error { return nil })
+	once, err := q.Enqueue(ctx, "crash", nil, job.MaxAttempts(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	twice, err := q.Enqueue(ctx, "crash", nil, job.MaxAttempts(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 取出后进程崩溃，租约到期
+	now := time.Now()
+	if jobs, err := store.Job().Claim(ctx, now, 10, now); err != nil || len(jobs) != 2 {
+		t.Fatalf("expect 2 claimed, got %d %v", len(jobs), err)
+	}
+	now = now.Add(time.Second)
+	jobs, err := store.Job().Claim(ctx, now, 10, now)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != twice.ID || jobs[0].Attempts != 2 {
+		t.Fatalf("expect only the job with attempts left, got %d %v", len(jobs), err)
+	}
+	v, err := core.GetJob(ctx, once.ID)
+	if err != nil || v.Status != job.StatusFailed || v.Attempts != 1 {
+		t.Fatalf("expect exhausted job failed, got %+v %v", v, err)
+	}
+
+	now = now.Add(time.Second)
+	if jobs, err := store.Job().Claim(ctx, now, 10, now); err != nil || len(jobs) != 0 {
+		t.Fatalf("expect nothing claimed, got %d %v", len(jobs), err)
+	}
+	if v, err := core.GetJob(ctx, twice.ID); err != nil || v.Status != job.StatusFailed {
+		t.Fatalf("expect exhausted job failed, got %+v %v", v, err)
+	}
+}
+
+func TestQueueSchedule(t *testing.T) {
+	store := newTestStore(t)
+	core := job.NewCore(store)
+	ctx := context.Background()
+
+	// 两个副本共用同一个数据库，同一周期只创建一次任务
+	var calls atomic.Int32
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for range 2 {
+		q := job.NewQueue(store, job.Config{PollInterval: 5 * time.Millisecond})
+		job.Handle(q, "tick", func(context.Context, struct{}) error {
+			calls.Add(1)
+			return nil
+		})
+		if err := q.Schedule("tick", "@every 30ms", nil); err != nil {
+			t.Fatal(err)
+		}
+		go q.Run(runCtx)
+	}
+
+	waitFor(t, "schedule not triggered", func() bool { return calls.Load() >= 3 })
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	schedules, err := core.FindSchedule(ctx)
+	if err != nil || len(schedules) != 1 {
+		t.Fatalf("unexpected schedules %v %v", schedules, err)
+	}
+	_, total, err := core.FindJob(ctx, &job.FindJobInput{Name: "tick"})
+	if err != nil || total != schedules[0].Runs {
+		t.Fatalf("expect %d jobs, got %d %v", schedules[0].Runs, total, err)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	now := time.Date(2024, 3, 6, 10, 20, 30, 0, time.UTC) // 周三
+	for _, tc := range []struct {
+		spec string
+		next time.Time
+	}{
+		{"@every 90s", now.Add(90 * time.Second)},
+		{"TZ=UTC @hourly", time.Date(2024, 3, 6, 11, 0, 0, 0, time.UTC)},
+		{"TZ=UTC @daily", time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC 0 3 * * MON", time.Date(2024, 3, 11, 3, 0, 0, 0, time.UTC)},
+	} {
+		spec, err := job.ParseSpec(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next := spec.Next(now); !next.Equal(tc.next) {
+			t.Errorf("%s: expect %s, got %s", tc.spec, tc.next, next)
+		}
+	}
+	for _, spec := range []string{"", "@every", "@every -1s", "* * * *"} {
+		if _, err := job.ParseSpec(spec); err == nil {
+			t.Errorf("%q: expect error", spec)
+		}
+	}
+}
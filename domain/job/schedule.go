@@ -0,0 +1,40 @@
+// Code generated by godddx, DO AVOID EDIT.
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/hook"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// ScheduleStorer Instantiation interface
+type ScheduleStorer interface {
+	List(context.Context, *[]*Schedule, orm.Pager, ...orm.QueryOption) (int64, error)
+	Get(context.Context, *Schedule, ...orm.QueryOption) error
+	// Create 已存在时忽略，多副本同时启动不会报错
+	Create(context.Context, *Schedule) error
+	// Reset 执行周期变更后重新计算下次执行时间
+	Reset(ctx context.Context, name, spec string, next time.Time) error
+	// Advance 条件更新 runs 与下次执行时间，返回是否抢占成功
+	Advance(ctx context.Context, name string, runs int64, next time.Time) (bool, error)
+}
+
+// FindSchedule 周期任务与下次执行时间
+func (c Core) FindSchedule(ctx context.Context) ([]*Schedule, error) {
+	items := make([]*Schedule, 0, 8)
+	if _, err := c.store.Schedule().List(ctx, &items, nil, orm.OrderBy("name ASC")); err != nil {
+		return nil, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, nil
+}
+
+// Spec 执行周期
+type Spec = hook.Schedule
+
+// ParseSpec 解析执行周期，支持 cron 表达式与 @daily、@every 5m 等描述符，格式参考 hook.ParseCron
+func ParseSpec(spec string) (Spec, error) {
+	return hook.ParseCron(spec)
+}
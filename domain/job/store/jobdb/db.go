@@ -0,0 +1,51 @@
+// Code generated by godddx, DO AVOID EDIT.
+package jobdb
+
+import (
+	"context"
+
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+var _ job.Storer = DB{}
+
+// DB Related business namespaces
+type DB struct {
+	db *gorm.DB
+}
+
+// NewDB instance object
+func NewDB(db *gorm.DB) DB {
+	return DB{db: db}
+}
+
+// Job Get business instance
+func (d DB) Job() job.JobStorer {
+	return Job(d)
+}
+
+// Schedule Get business instance
+func (d DB) Schedule() job.ScheduleStorer {
+	return Schedule(d)
+}
+
+// Transaction implements job.Storer.
+func (d DB) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return orm.Transaction(ctx, d.db, fn)
+}
+
+// AutoMigrate sync database
+func (d DB) AutoMigrate(ok bool) DB {
+	if !ok {
+		return d
+	}
+	if err := d.db.AutoMigrate(
+		new(job.Job),
+		new(job.Schedule),
+	); err != nil {
+		panic(err)
+	}
+	return d
+}
@@ -0,0 +1,95 @@
+// Code generated by godddx, DO AVOID EDIT.
+package jobdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ job.JobStorer = Job{}
+
+// Job Related business namespaces
+type Job DB
+
+// List implements job.JobStorer.
+func (d Job) List(ctx context.Context, bs *[]*job.Job, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Get implements job.JobStorer.
+func (d Job) Get(ctx context.Context, model *job.Job, opts ...orm.QueryOption) error {
+	return orm.FirstWithContext(ctx, d.db, model, opts...)
+}
+
+// Create implements job.JobStorer.
+func (d Job) Create(ctx context.Context, model *job.Job) error {
+	return orm.Conn(ctx, d.db).Create(model).Error
+}
+
+// Claim implements job.JobStorer.
+func (d Job) Claim(ctx context.Context, now time.Time, limit int, leaseUntil time.Time) ([]*job.Job, error) {
+	var out []*job.Job
+	err := orm.Transaction(ctx, d.db, func(ctx context.Context) error {
+		// 租约到期且已达到最大执行次数，例如任务导致进程崩溃，不再重试
+		if err := orm.Conn(ctx, d.db).Model(new(job.Job)).
+			Where("status = ? AND run_at <= ? AND attempts >= max_attempts", job.StatusRunning, now).Updates(map[string]any{
+			"status":     job.StatusFailed,
+			"last_error": "lease expired after max attempts",
+			"updated_at": now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// 执行中且租约到期的任务视为进程崩溃，重新执行
+		query := orm.Conn(ctx, d.db).Where("status IN ? AND run_at <= ? AND attempts < max_attempts", []string{job.StatusPending, job.StatusRunning}, now).
+			Order("run_at ASC, id ASC").Limit(limit)
+		if d.db.Dialector.Name() == "postgres" {
+			query = query.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked})
+		}
+		if err := query.Find(&out).Error; err != nil || len(out) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(out))
+		for i, v := range out {
+			ids[i] = v.ID
+			v.Status = job.StatusRunning
+			v.Attempts++
+			v.RunAt = orm.Time{Time: leaseUntil}
+		}
+		return orm.Conn(ctx, d.db).Model(new(job.Job)).Where("id IN ?", ids).Updates(map[string]any{
+			"status":     job.StatusRunning,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"run_at":     leaseUntil,
+			"updated_at": now,
+		}).Error
+	})
+	return out, err
+}
+
+// Release implements job.JobStorer.
+func (d Job) Release(ctx context.Context, id int64, attempts int, values map[string]any) (bool, error) {
+	result := orm.Conn(ctx, d.db).Model(new(job.Job)).
+		Where("id = ? AND status = ? AND attempts = ?", id, job.StatusRunning, attempts).Updates(values)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Transition implements job.JobStorer.
+func (d Job) Transition(ctx context.Context, ids []int64, from []string, values map[string]any) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := orm.Conn(ctx, d.db).Model(new(job.Job)).Where("id IN ? AND status IN ?", ids, from).Updates(values)
+	return result.RowsAffected, result.Error
+}
+
+// Purge implements job.JobStorer.
+func (d Job) Purge(ctx context.Context, before time.Time, status ...string) (int64, error) {
+	result := orm.Conn(ctx, d.db).Where("status IN ? AND updated_at < ?", status, before).Delete(new(job.Job))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,45 @@
+// Code generated by godddx, DO AVOID EDIT.
+package jobdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ job.ScheduleStorer = Schedule{}
+
+// Schedule Related business namespaces
+type Schedule DB
+
+// List implements job.ScheduleStorer.
+func (d Schedule) List(ctx context.Context, bs *[]*job.Schedule, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Get implements job.ScheduleStorer.
+func (d Schedule) Get(ctx context.Context, model *job.Schedule, opts ...orm.QueryOption) error {
+	return orm.FirstWithContext(ctx, d.db, model, opts...)
+}
+
+// Create implements job.ScheduleStorer.
+func (d Schedule) Create(ctx context.Context, model *job.Schedule) error {
+	return orm.Conn(ctx, d.db).Clauses(clause.OnConflict{DoNothing: true}).Create(model).Error
+}
+
+// Reset implements job.ScheduleStorer.
+func (d Schedule) Reset(ctx context.Context, name, spec string, next time.Time) error {
+	return orm.Conn(ctx, d.db).Model(new(job.Schedule)).Where("name = ?", name).
+		Updates(map[string]any{"spec": spec, "next_run_at": next, "updated_at": time.Now()}).Error
+}
+
+// Advance implements job.ScheduleStorer.
+func (d Schedule) Advance(ctx context.Context, name string, runs int64, next time.Time) (bool, error) {
+	result := orm.Conn(ctx, d.db).Model(new(job.Schedule)).Where("name = ? AND runs = ?", name, runs).
+		Updates(map[string]any{"runs": gorm.Expr("runs + 1"), "next_run_at": next, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,36 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+)
+
+// Wildcard 通配符，"*" 表示全部权限，"user:*" 表示 user 资源的全部操作
+const Wildcard = "*"
+
+// HasPermission 角色是否拥有 permission，实现 web.PermissionChecker
+// permission 格式为 resource:action
+func (c Core) HasPermission(ctx context.Context, roleID int, permission string) (bool, error) {
+	if roleID <= 0 {
+		return false, nil
+	}
+	codes, err := c.store.RolePermission().ListCodes(ctx, roleID)
+	if err != nil {
+		return false, err
+	}
+	for _, code := range codes {
+		if MatchPermission(code, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MatchPermission 已授予的 granted 是否覆盖所需的 required
+func MatchPermission(granted, required string) bool {
+	if granted == Wildcard || granted == required {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(granted, ":"+Wildcard)
+	return ok && strings.HasPrefix(required, prefix+":")
+}
@@ -0,0 +1,89 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/jinzhu/copier"
+)
+
+// PermissionStorer Instantiation interface
+type PermissionStorer interface {
+	List(context.Context, *[]*Permission, orm.Pager, ...orm.QueryOption) (int64, error)
+	Get(context.Context, *Permission, ...orm.QueryOption) error
+	Create(context.Context, *Permission) error
+	Update(context.Context, *Permission, func(*Permission), ...orm.QueryOption) error
+	Delete(context.Context, *Permission, ...orm.QueryOption) error
+}
+
+// GetPermission Query a single object
+func (c Core) GetPermission(ctx context.Context, id int) (*Permission, error) {
+	var out Permission
+	if err := c.store.Permission().Get(ctx, &out, orm.Where("id=?", id)); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrNotFound.Withf(`Get err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Get err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// AddPermission Insert into database
+func (c Core) AddPermission(ctx context.Context, in *AddPermissionInput) (*Permission, error) {
+	var out Permission
+	if err := copier.Copy(&out, in); err != nil {
+		slog.ErrorContext(ctx, "Copy", "err", err)
+	}
+	if err := c.store.Permission().Create(ctx, &out); err != nil {
+		return nil, reason.ErrDB.Withf(`Add err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// EditPermission Update object information
+func (c Core) EditPermission(ctx context.Context, in *EditPermissionInput, id int) (*Permission, error) {
+	var out Permission
+	if err := c.store.Permission().Update(ctx, &out, func(b *Permission) {
+		if err := copier.Copy(b, in); err != nil {
+			slog.ErrorContext(ctx, "Copy", "err", err)
+		}
+	}, orm.Where("id=?", id)); err != nil {
+		return nil, reason.ErrDB.Withf(`Edit err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// ListPermissions Paginated search
+func (c Core) ListPermissions(ctx context.Context, in *FindPermissionInput) ([]*Permission, int64, error) {
+	query := orm.NewQuery(2)
+	if in.Code != "" {
+		query.Where("code like ?", in.Code+"%")
+	}
+	query.OrderBy("code ASC")
+
+	items := make([]*Permission, 0, in.Limit())
+	total, err := c.store.Permission().List(ctx, &items, in, query.Encode()...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
+
+// DeletePermission Delete object
+// 同时解除所有角色与该权限的绑定，两者在同一事务中
+func (c Core) DeletePermission(ctx context.Context, id int) (*Permission, error) {
+	var out Permission
+	err := c.store.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := c.store.RolePermission().DeleteByPermission(ctx, id); err != nil {
+			return err
+		}
+		return c.store.Permission().Delete(ctx, &out, orm.Where("id=?", id))
+	})
+	if err != nil {
+		return nil, reason.ErrDB.Withf(`Del err[%s]`, err.Error())
+	}
+	return &out, nil
+}
@@ -0,0 +1,18 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import "github.com/ixugo/goddd/pkg/orm"
+
+// Permission domain model
+type Permission struct {
+	ID        int      `gorm:"primaryKey" json:"id"`
+	Code      string   `gorm:"column:code;notNull;default:'';uniqueIndex;comment:权限标识，格式为 resource:action" json:"code"` // 权限标识，格式为 resource:action
+	Name      string   `gorm:"column:name;notNull;default:'';comment:权限名称" json:"name"`                                 // 权限名称
+	CreatedAt orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName database table name
+func (*Permission) TableName() string {
+	return "permissions"
+}
@@ -0,0 +1,19 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import "github.com/ixugo/goddd/pkg/web"
+
+type FindPermissionInput struct {
+	web.PagerFilter
+	Code string `form:"code"` // 权限标识前缀，例如 user 可查询 user:* 相关权限
+}
+
+// EditPermissionInput 权限标识被业务代码引用，创建后不可修改
+type EditPermissionInput struct {
+	Name string `json:"name"` // 权限名称
+}
+
+type AddPermissionInput struct {
+	Code string `json:"code" binding:"required"` // 权限标识，格式为 resource:action
+	Name string `json:"name"`                    // 权限名称
+}
@@ -0,0 +1,158 @@
+package rbac_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/domain/rbac/store/rbaccache"
+	"github.com/ixugo/goddd/domain/rbac/store/rbacdb"
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/reason"
+	"gorm.io/gorm"
+)
+
+func TestMatchPermission(t *testing.T) {
+	tests := []struct {
+		granted, required string
+		expect            bool
+	}{
+		{"*", "user:read", true},
+		{"user:read", "user:read", true},
+		{"user:*", "user:read", true},
+		{"user:*", "user:write", true},
+		{"user:read", "user:write", false},
+		{"user:*", "role:read", false},
+		{"user:*", "username:read", false},
+		{"user", "user:read", false},
+		{"", "user:read", false},
+	}
+	for _, tt := range tests {
+		if got := rbac.MatchPermission(tt.granted, tt.required); got != tt.expect {
+			t.Fatalf("MatchPermission(%q, %q) expect %v, got %v", tt.granted, tt.required, tt.expect, got)
+		}
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	store := rbaccache.NewCache(rbacdb.NewDB(db).AutoMigrate(true), conc.NewTTLCache(time.Minute))
+	core := rbac.NewCore(store)
+	ctx := context.Background()
+
+	read, err := core.AddPermission(ctx, &rbac.AddPermissionInput{Code: "user:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write, err := core.AddPermission(ctx, &rbac.AddPermissionInput{Code: "user:write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := core.AddRole(ctx, &rbac.AddRoleInput{Name: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := func(msg string, codes ...string) {
+		t.Helper()
+		for _, code := range []string{"user:read", "user:write"} {
+			ok, err := core.HasPermission(ctx, role.ID, code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := false
+			for _, c := range codes {
+				want = want || c == code
+			}
+			if ok != want {
+				t.Fatalf("%s: %s expect %v, got %v", msg, code, want, ok)
+			}
+		}
+	}
+	set := func(ids ...int) {
+		t.Helper()
+		if _, err := core.SetRolePermissions(ctx, role.ID, &rbac.SetRolePermissionsInput{PermissionIDs: ids}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	set(read.ID)
+	expect("set", "user:read")
+
+	// 绕过缓存修改数据库，读到的仍是缓存
+	if err := rbacdb.NewDB(db).RolePermission().Set(ctx, role.ID, []int{read.ID, write.ID}); err != nil {
+		t.Fatal(err)
+	}
+	expect("cached", "user:read")
+
+	set(write.ID)
+	expect("set invalidates", "user:write")
+
+	if err := store.RolePermission().DeleteByRole(ctx, role.ID); err != nil {
+		t.Fatal(err)
+	}
+	expect("DeleteByRole invalidates")
+
+	set(read.ID, write.ID)
+	expect("set again", "user:read", "user:write")
+	if _, err := core.DeletePermission(ctx, read.ID); err != nil {
+		t.Fatal(err)
+	}
+	expect("DeleteByPermission invalidates", "user:write")
+	if _, err := core.GetPermission(ctx, read.ID); err == nil {
+		t.Fatal("expect permission deleted")
+	}
+}
+
+func TestRoleTrash(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true, TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	core := rbac.NewCore(rbacdb.NewDB(db).AutoMigrate(true))
+	ctx := context.Background()
+
+	first, err := core.AddRole(ctx, &rbac.AddRoleInput{Name: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.AddRole(ctx, &rbac.AddRoleInput{Name: "admin"}); err == nil {
+		t.Fatal("expect duplicated name rejected")
+	}
+	if _, err := core.DeleteRole(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 删除后名称可以被新角色使用，此时无法恢复
+	second, err := core.AddRole(ctx, &rbac.AddRoleInput{Name: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.RestoreRole(ctx, first.ID); !errors.Is(err, reason.ErrBadRequest) {
+		t.Fatalf("expect ErrBadRequest, got %v", err)
+	}
+
+	if _, err := core.DeleteRole(ctx, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.RestoreRole(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	n, err := core.PurgeRoles(ctx, time.Now().Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expect 1 purged, got %d", n)
+	}
+}
@@ -0,0 +1,127 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbacapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/domain/rbac/store/rbaccache"
+	"github.com/ixugo/goddd/domain/rbac/store/rbacdb"
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+type RBACAPI struct {
+	RBACCore rbac.Core
+}
+
+// NewRBACAPI 多副本部署时，cache 应当使用 conc.RedisCache，以保证权限变更在各副本间可见
+func NewRBACAPI(db *gorm.DB, cache conc.Cacher) RBACAPI {
+	var store rbac.Storer
+	store = rbacdb.NewDB(db).AutoMigrate(orm.GetEnabledAutoMigrate())
+	store = rbaccache.NewCache(store, cache)
+	core := rbac.NewCore(store)
+	return RBACAPI{RBACCore: core}
+}
+
+func Register(g gin.IRouter, api RBACAPI, handler ...gin.HandlerFunc) {
+	{
+		group := g.Group("/roles", handler...)
+		web.Handle(group, http.MethodGet, "", api.findRole)
+		web.Handle(group, http.MethodGet, "/:id", api.getRole)
+		web.Handle(group, http.MethodPut, "/:id", api.editRole)
+		web.Handle(group, http.MethodPost, "", api.addRole)
+		web.Handle(group, http.MethodDelete, "/:id", api.delRole)
+		web.Handle(group, http.MethodGet, "/trash", api.findTrashedRole)
+		web.Handle(group, http.MethodPost, "/:id/restore", api.restoreRole)
+
+		web.Handle(group, http.MethodGet, "/:id/permissions", api.findRolePermissions)
+		web.Handle(group, http.MethodPut, "/:id/permissions", api.setRolePermissions)
+	}
+	{
+		group := g.Group("/permissions", handler...)
+		web.Handle(group, http.MethodGet, "", api.findPermission)
+		web.Handle(group, http.MethodGet, "/:id", api.getPermission)
+		web.Handle(group, http.MethodPut, "/:id", api.editPermission)
+		web.Handle(group, http.MethodPost, "", api.addPermission)
+		web.Handle(group, http.MethodDelete, "/:id", api.delPermission)
+	}
+}
+
+// >>> role >>>>>>>>>>>>>>>>>>>>
+
+func (a RBACAPI) findRole(c *gin.Context, in *rbac.FindRoleInput) (any, error) {
+	items, total, err := a.RBACCore.ListRoles(c.Request.Context(), in)
+	return gin.H{"items": items, "total": total}, err
+}
+
+func (a RBACAPI) getRole(c *gin.Context, _ *struct{}) (any, error) {
+	roleID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.GetRole(c.Request.Context(), roleID)
+}
+
+func (a RBACAPI) editRole(c *gin.Context, in *rbac.EditRoleInput) (any, error) {
+	roleID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.EditRole(c.Request.Context(), in, roleID)
+}
+
+func (a RBACAPI) addRole(c *gin.Context, in *rbac.AddRoleInput) (any, error) {
+	return a.RBACCore.AddRole(c.Request.Context(), in)
+}
+
+func (a RBACAPI) delRole(c *gin.Context, _ *struct{}) (any, error) {
+	roleID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.DeleteRole(c.Request.Context(), roleID)
+}
+
+func (a RBACAPI) findTrashedRole(c *gin.Context, in *rbac.FindRoleInput) (any, error) {
+	items, total, err := a.RBACCore.ListTrashedRoles(c.Request.Context(), in)
+	return gin.H{"items": items, "total": total}, err
+}
+
+func (a RBACAPI) restoreRole(c *gin.Context, _ *struct{}) (any, error) {
+	roleID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.RestoreRole(c.Request.Context(), roleID)
+}
+
+func (a RBACAPI) findRolePermissions(c *gin.Context, _ *struct{}) (any, error) {
+	roleID, _ := strconv.Atoi(c.Param("id"))
+	items, err := a.RBACCore.ListRolePermissions(c.Request.Context(), roleID)
+	return gin.H{"items": items}, err
+}
+
+func (a RBACAPI) setRolePermissions(c *gin.Context, in *rbac.SetRolePermissionsInput) (any, error) {
+	roleID, _ := strconv.Atoi(c.Param("id"))
+	items, err := a.RBACCore.SetRolePermissions(c.Request.Context(), roleID, in)
+	return gin.H{"items": items}, err
+}
+
+// >>> permission >>>>>>>>>>>>>>>>>>>>
+
+func (a RBACAPI) findPermission(c *gin.Context, in *rbac.FindPermissionInput) (any, error) {
+	items, total, err := a.RBACCore.ListPermissions(c.Request.Context(), in)
+	return gin.H{"items": items, "total": total}, err
+}
+
+func (a RBACAPI) getPermission(c *gin.Context, _ *struct{}) (any, error) {
+	permissionID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.GetPermission(c.Request.Context(), permissionID)
+}
+
+func (a RBACAPI) editPermission(c *gin.Context, in *rbac.EditPermissionInput) (any, error) {
+	permissionID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.EditPermission(c.Request.Context(), in, permissionID)
+}
+
+func (a RBACAPI) addPermission(c *gin.Context, in *rbac.AddPermissionInput) (any, error) {
+	return a.RBACCore.AddPermission(c.Request.Context(), in)
+}
+
+func (a RBACAPI) delPermission(c *gin.Context, _ *struct{}) (any, error) {
+	permissionID, _ := strconv.Atoi(c.Param("id"))
+	return a.RBACCore.DeletePermission(c.Request.Context(), permissionID)
+}
@@ -0,0 +1,139 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/jinzhu/copier"
+)
+
+// RoleStorer Instantiation interface
+type RoleStorer interface {
+	List(context.Context, *[]*Role, orm.Pager, ...orm.QueryOption) (int64, error)
+	Get(context.Context, *Role, ...orm.QueryOption) error
+	Create(context.Context, *Role) error
+	Update(context.Context, *Role, func(*Role), ...orm.QueryOption) error
+	UpdateOptimistic(context.Context, *Role, int, func(*Role) error, ...orm.QueryOption) error
+	Delete(context.Context, *Role, ...orm.QueryOption) error
+	orm.SoftDeleter[Role]
+}
+
+// GetRole Query a single object
+func (c Core) GetRole(ctx context.Context, id int) (*Role, error) {
+	var out Role
+	if err := c.store.Role().Get(ctx, &out, orm.Where("id=?", id)); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrNotFound.Withf(`Get err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Get err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// AddRole Insert into database
+func (c Core) AddRole(ctx context.Context, in *AddRoleInput) (*Role, error) {
+	var out Role
+	if err := copier.Copy(&out, in); err != nil {
+		slog.ErrorContext(ctx, "Copy", "err", err)
+	}
+	if err := c.store.Role().Create(ctx, &out); err != nil {
+		return nil, reason.ErrDB.Withf(`Add err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// EditRole Update object information
+// 乐观锁更新，If-Match 的版本号与数据库不一致时返回 reason.ErrVersionConflict
+// If-Match 可能携带多个 ETag，在 changeFn 中校验读取到的版本号
+func (c Core) EditRole(ctx context.Context, in *EditRoleInput, id int) (*Role, error) {
+	var out Role
+	if err := c.store.Role().UpdateOptimistic(ctx, &out, 0, func(b *Role) error {
+		if !in.MatchVersion(b.Version) {
+			return orm.ErrVersionConflict
+		}
+		if err := copier.Copy(b, in); err != nil {
+			slog.ErrorContext(ctx, "Copy", "err", err)
+		}
+		return nil
+	}, orm.Where("id=?", id)); err != nil {
+		if errors.Is(err, orm.ErrVersionConflict) {
+			return nil, reason.ErrVersionConflict.Withf(`Edit err[%s]`, err.Error())
+		}
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrNotFound.Withf(`Edit err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Edit err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// ListRoles Paginated search
+func (c Core) ListRoles(ctx context.Context, in *FindRoleInput) ([]*Role, int64, error) {
+	query := orm.NewQuery(2)
+	if in.Name != "" {
+		query.Where("name like ?", "%"+in.Name+"%")
+	}
+	query.OrderBy("id DESC")
+
+	items := make([]*Role, 0, in.Limit())
+	total, err := c.store.Role().List(ctx, &items, in, query.Encode()...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
+
+// DeleteRole Delete object
+// 软删除，保留角色与权限的绑定，恢复后权限不变；删除期间角色不再拥有任何权限
+func (c Core) DeleteRole(ctx context.Context, id int) (*Role, error) {
+	var out Role
+	if err := c.store.Role().Delete(ctx, &out, orm.Where("id=?", id)); err != nil {
+		return nil, reason.ErrDB.Withf(`Del err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// ListTrashedRoles 分页查询已删除的角色
+func (c Core) ListTrashedRoles(ctx context.Context, in *FindRoleInput) ([]*Role, int64, error) {
+	query := orm.NewQuery(2)
+	if in.Name != "" {
+		query.Where("name like ?", "%"+in.Name+"%")
+	}
+	query.OrderBy("deleted_at DESC")
+
+	items := make([]*Role, 0, in.Limit())
+	total, err := c.store.Role().ListTrashed(ctx, &items, in, query.Encode()...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`ListTrashed err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
+
+// RestoreRole 恢复已删除的角色，已存在同名角色时无法恢复
+func (c Core) RestoreRole(ctx context.Context, id int) (*Role, error) {
+	n, err := c.store.Role().Restore(ctx, orm.Where("id=?", id))
+	if err != nil {
+		if orm.IsDuplicatedKey(err) {
+			return nil, reason.ErrBadRequest.SetMsg("已存在同名角色，无法恢复").Withf(`Restore err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Restore err[%s]`, err.Error())
+	}
+	if n == 0 {
+		return nil, reason.ErrNotFound.SetMsg("回收站中不存在该角色")
+	}
+	return c.GetRole(ctx, id)
+}
+
+// PurgeRoles 彻底删除 before 之前删除的角色，同时解除与权限的绑定
+func (c Core) PurgeRoles(ctx context.Context, before time.Time) (int64, error) {
+	n, err := c.store.Role().Purge(ctx, before)
+	if err != nil {
+		return 0, reason.ErrDB.Withf(`Purge err[%s]`, err.Error())
+	}
+	return n, nil
+}
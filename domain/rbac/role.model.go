@@ -0,0 +1,27 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import "github.com/ixugo/goddd/pkg/orm"
+
+// Role domain model
+type Role struct {
+	ID        int      `gorm:"primaryKey" json:"id"`
+	TenantID  string   `gorm:"column:tenant_id;notNull;default:'';uniqueIndex:idx_roles_tenant_name,priority:1,where:deleted_at IS NULL;comment:租户" json:"tenant_id"` // 租户，由 ctx 填充
+	Name      string   `gorm:"column:name;notNull;default:'';uniqueIndex:idx_roles_tenant_name,priority:2;comment:角色名称" json:"name"`                                  // 角色名称，同一租户内未删除的角色唯一
+	Remark    string   `gorm:"column:remark;notNull;default:'';comment:备注" json:"remark"`                                                                             // 备注
+	CreatedAt orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	Version   int      `gorm:"column:version;notNull;default:1;comment:版本号" json:"version"` // 乐观锁版本号
+	// 软删除，删除后名称可以被新角色使用，彻底删除前可以恢复
+	DeletedAt orm.DeletedAt `gorm:"column:deleted_at;index;comment:删除时间" json:"deleted_at"`
+}
+
+// TableName database table name
+func (*Role) TableName() string {
+	return "roles"
+}
+
+// GetVersion 实现 web.Versioned，响应时输出 ETag
+func (r *Role) GetVersion() int {
+	return r.Version
+}
@@ -0,0 +1,20 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import "github.com/ixugo/goddd/pkg/web"
+
+type FindRoleInput struct {
+	web.PagerFilter
+	Name string `form:"name"` // 角色名称
+}
+
+type EditRoleInput struct {
+	web.IfMatch        // 请求头 If-Match 为 GET 响应的 ETag
+	Name        string `json:"name"`   // 角色名称
+	Remark      string `json:"remark"` // 备注
+}
+
+type AddRoleInput struct {
+	Name   string `json:"name" binding:"required"` // 角色名称
+	Remark string `json:"remark"`                  // 备注
+}
@@ -0,0 +1,48 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// RolePermissionStorer Instantiation interface
+type RolePermissionStorer interface {
+	// ListCodes 角色拥有的权限标识，鉴权时高频调用，缓存层应当缓存此结果
+	ListCodes(ctx context.Context, roleID int) ([]string, error)
+	// ListByRole 角色拥有的权限
+	ListByRole(ctx context.Context, roleID int) ([]*Permission, error)
+	// Set 覆盖角色拥有的全部权限，permissionIDs 中存在未知权限时返回 orm.ErrRecordNotFound
+	Set(ctx context.Context, roleID int, permissionIDs []int) error
+	// DeleteByRole 解除角色的全部绑定
+	DeleteByRole(ctx context.Context, roleID int) error
+	// DeleteByPermission 解除权限的全部绑定，返回受影响的角色
+	DeleteByPermission(ctx context.Context, permissionID int) ([]int, error)
+}
+
+// ListRolePermissions 查询角色拥有的权限
+func (c Core) ListRolePermissions(ctx context.Context, roleID int) ([]*Permission, error) {
+	if _, err := c.GetRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	items, err := c.store.RolePermission().ListByRole(ctx, roleID)
+	if err != nil {
+		return nil, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, nil
+}
+
+// SetRolePermissions 覆盖角色拥有的全部权限
+func (c Core) SetRolePermissions(ctx context.Context, roleID int, in *SetRolePermissionsInput) ([]*Permission, error) {
+	if _, err := c.GetRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	if err := c.store.RolePermission().Set(ctx, roleID, in.PermissionIDs); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrBadRequest.SetMsg("权限不存在")
+		}
+		return nil, reason.ErrDB.Withf(`Set err[%s]`, err.Error())
+	}
+	return c.ListRolePermissions(ctx, roleID)
+}
@@ -0,0 +1,17 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbac
+
+import "github.com/ixugo/goddd/pkg/orm"
+
+// RolePermission domain model
+type RolePermission struct {
+	ID           int      `gorm:"primaryKey" json:"id"`
+	RoleID       int      `gorm:"column:role_id;notNull;default:0;uniqueIndex:idx_role_permission;comment:角色 id" json:"role_id"`             // 角色 id
+	PermissionID int      `gorm:"column:permission_id;notNull;default:0;uniqueIndex:idx_role_permission;comment:权限 id" json:"permission_id"` // 权限 id
+	CreatedAt    orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName database table name
+func (*RolePermission) TableName() string {
+	return "role_permissions"
+}
@@ -0,0 +1,5 @@
+package rbac
+
+type SetRolePermissionsInput struct {
+	PermissionIDs []int `json:"permission_ids"` // 覆盖角色拥有的全部权限，空数组表示清空
+}
@@ -0,0 +1,45 @@
+package rbaccache
+
+import (
+	"context"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/conc"
+)
+
+var _ rbac.Storer = (*Cache)(nil)
+
+// NewCache 缓存角色的权限集合，鉴权中间件每个请求都会查询
+// 多副本部署时，cache 应当使用 conc.RedisCache，以保证权限变更在各副本间可见
+func NewCache(store rbac.Storer, cache conc.Cacher) *Cache {
+	return &Cache{
+		store: store,
+		perms: cache,
+	}
+}
+
+type Cache struct {
+	store rbac.Storer
+	perms conc.Cacher
+}
+
+// Role implements rbac.Storer
+func (c *Cache) Role() rbac.RoleStorer {
+	return (*Role)(c)
+}
+
+// Permission implements rbac.Storer
+func (c *Cache) Permission() rbac.PermissionStorer {
+	return c.store.Permission()
+}
+
+// RolePermission implements rbac.Storer
+func (c *Cache) RolePermission() rbac.RolePermissionStorer {
+	return (*RolePermission)(c)
+}
+
+// Transaction implements rbac.Storer
+// 缓存在事务提交后删除，回滚时保留
+func (c *Cache) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return c.store.Transaction(ctx, fn)
+}
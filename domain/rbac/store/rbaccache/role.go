@@ -0,0 +1,90 @@
+package rbaccache
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ rbac.RoleStorer = (*Role)(nil)
+
+// Role 软删除与恢复会改变角色是否拥有权限，需要清除权限缓存
+type Role Cache
+
+func (c *Role) rolePermission() *RolePermission {
+	return (*RolePermission)(c)
+}
+
+// ids 受影响的角色 id
+func (c *Role) ids(roles []*rbac.Role) []int {
+	out := make([]int, len(roles))
+	for i, v := range roles {
+		out[i] = v.ID
+	}
+	return out
+}
+
+// List implements rbac.RoleStorer.
+func (c *Role) List(ctx context.Context, bs *[]*rbac.Role, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return c.store.Role().List(ctx, bs, page, opts...)
+}
+
+// Get implements rbac.RoleStorer.
+func (c *Role) Get(ctx context.Context, model *rbac.Role, opts ...orm.QueryOption) error {
+	return c.store.Role().Get(ctx, model, opts...)
+}
+
+// Create implements rbac.RoleStorer.
+func (c *Role) Create(ctx context.Context, model *rbac.Role) error {
+	return c.store.Role().Create(ctx, model)
+}
+
+// Update implements rbac.RoleStorer.
+func (c *Role) Update(ctx context.Context, model *rbac.Role, changeFn func(*rbac.Role), opts ...orm.QueryOption) error {
+	return c.store.Role().Update(ctx, model, changeFn, opts...)
+}
+
+// UpdateOptimistic implements rbac.RoleStorer.
+func (c *Role) UpdateOptimistic(ctx context.Context, model *rbac.Role, version int, changeFn func(*rbac.Role) error, opts ...orm.QueryOption) error {
+	return c.store.Role().UpdateOptimistic(ctx, model, version, changeFn, opts...)
+}
+
+// Delete implements rbac.RoleStorer.
+func (c *Role) Delete(ctx context.Context, model *rbac.Role, opts ...orm.QueryOption) error {
+	var roles []*rbac.Role
+	if _, err := c.store.Role().List(ctx, &roles, nil, opts...); err != nil {
+		return err
+	}
+	if err := c.store.Role().Delete(ctx, model, opts...); err != nil {
+		return err
+	}
+	c.rolePermission().del(ctx, c.ids(roles)...)
+	return nil
+}
+
+// Restore implements rbac.RoleStorer.
+func (c *Role) Restore(ctx context.Context, opts ...orm.QueryOption) (int64, error) {
+	var roles []*rbac.Role
+	if _, err := c.store.Role().ListTrashed(ctx, &roles, nil, opts...); err != nil {
+		return 0, err
+	}
+	n, err := c.store.Role().Restore(ctx, opts...)
+	if err != nil {
+		return n, err
+	}
+	c.rolePermission().del(ctx, c.ids(roles)...)
+	return n, nil
+}
+
+// ListTrashed implements rbac.RoleStorer.
+func (c *Role) ListTrashed(ctx context.Context, bs *[]*rbac.Role, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return c.store.Role().ListTrashed(ctx, bs, page, opts...)
+}
+
+// Purge implements rbac.RoleStorer.
+// 软删除时已清除缓存，此后缓存的均为空权限，无需再次清除
+func (c *Role) Purge(ctx context.Context, before time.Time, opts ...orm.QueryOption) (int64, error) {
+	return c.store.Role().Purge(ctx, before, opts...)
+}
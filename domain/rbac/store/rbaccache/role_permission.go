@@ -0,0 +1,94 @@
+package rbaccache
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ rbac.RolePermissionStorer = (*RolePermission)(nil)
+
+type RolePermission Cache
+
+func (c *RolePermission) cacheKey(tenantID string, roleID int) string {
+	return orm.TenantKeyOf(tenantID, fmt.Sprintf("ROLE_PERMISSIONS:%d", roleID))
+}
+
+// del 事务提交后删除缓存，回滚时保留
+// 缓存 key 带有读取时 ctx 中的租户，按角色所属的租户与无租户两种 key 删除
+// 例如平台管理员修改权限时，ctx 中没有租户，也能清除租户用户读取的缓存
+func (c *RolePermission) del(ctx context.Context, roleIDs ...int) {
+	if len(roleIDs) == 0 {
+		return
+	}
+	var roles []*rbac.Role
+	if _, err := c.store.Role().List(ctx, &roles, nil, orm.AllTenants(), orm.Unscoped(), orm.Where("id IN ?", roleIDs)); err != nil {
+		slog.ErrorContext(ctx, "list roles for cache", "err", err)
+	}
+	tenants := make(map[int]string, len(roles))
+	for _, v := range roles {
+		tenants[v.ID] = v.TenantID
+	}
+	orm.AfterCommit(ctx, func(ctx context.Context) {
+		for _, id := range roleIDs {
+			c.perms.Del(ctx, c.cacheKey("", id))
+			if tenantID := tenants[id]; tenantID != "" {
+				c.perms.Del(ctx, c.cacheKey(tenantID, id))
+			}
+		}
+	})
+}
+
+// ListCodes implements rbac.RolePermissionStorer.
+func (c *RolePermission) ListCodes(ctx context.Context, roleID int) ([]string, error) {
+	tenantID, _ := orm.TenantFrom(ctx)
+	var codes []string
+	if err := c.perms.Get(ctx, c.cacheKey(tenantID, roleID), &codes); err == nil {
+		return codes, nil
+	}
+	codes, err := c.store.RolePermission().ListCodes(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	// 事务中读到的数据可能回滚，提交后再缓存
+	orm.AfterCommit(ctx, func(ctx context.Context) {
+		c.perms.Set(ctx, c.cacheKey(tenantID, roleID), codes)
+	})
+	return codes, nil
+}
+
+// ListByRole implements rbac.RolePermissionStorer.
+func (c *RolePermission) ListByRole(ctx context.Context, roleID int) ([]*rbac.Permission, error) {
+	return c.store.RolePermission().ListByRole(ctx, roleID)
+}
+
+// Set implements rbac.RolePermissionStorer.
+func (c *RolePermission) Set(ctx context.Context, roleID int, permissionIDs []int) error {
+	if err := c.store.RolePermission().Set(ctx, roleID, permissionIDs); err != nil {
+		return err
+	}
+	c.del(ctx, roleID)
+	return nil
+}
+
+// DeleteByRole implements rbac.RolePermissionStorer.
+func (c *RolePermission) DeleteByRole(ctx context.Context, roleID int) error {
+	if err := c.store.RolePermission().DeleteByRole(ctx, roleID); err != nil {
+		return err
+	}
+	c.del(ctx, roleID)
+	return nil
+}
+
+// DeleteByPermission implements rbac.RolePermissionStorer.
+func (c *RolePermission) DeleteByPermission(ctx context.Context, permissionID int) ([]int, error) {
+	roleIDs, err := c.store.RolePermission().DeleteByPermission(ctx, permissionID)
+	if err != nil {
+		return roleIDs, err
+	}
+	c.del(ctx, roleIDs...)
+	return roleIDs, nil
+}
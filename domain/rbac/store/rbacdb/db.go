@@ -0,0 +1,57 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbacdb
+
+import (
+	"context"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+var _ rbac.Storer = DB{}
+
+// DB Related business namespaces
+type DB struct {
+	db *gorm.DB
+}
+
+// NewDB instance object
+func NewDB(db *gorm.DB) DB {
+	return DB{db: db}
+}
+
+// Role Get business instance
+func (d DB) Role() rbac.RoleStorer {
+	return Role(d)
+}
+
+// Permission Get business instance
+func (d DB) Permission() rbac.PermissionStorer {
+	return Permission(d)
+}
+
+// RolePermission Get business instance
+func (d DB) RolePermission() rbac.RolePermissionStorer {
+	return RolePermission(d)
+}
+
+// Transaction implements rbac.Storer.
+func (d DB) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return orm.Transaction(ctx, d.db, fn)
+}
+
+// AutoMigrate sync database
+func (d DB) AutoMigrate(ok bool) DB {
+	if !ok {
+		return d
+	}
+	if err := d.db.AutoMigrate(
+		new(rbac.Role),
+		new(rbac.Permission),
+		new(rbac.RolePermission),
+	); err != nil {
+		panic(err)
+	}
+	return d
+}
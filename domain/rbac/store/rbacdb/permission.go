@@ -0,0 +1,39 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbacdb
+
+import (
+	"context"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ rbac.PermissionStorer = Permission{}
+
+// Permission Related business namespaces
+type Permission DB
+
+// List implements rbac.PermissionStorer.
+func (d Permission) List(ctx context.Context, bs *[]*rbac.Permission, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Get implements rbac.PermissionStorer.
+func (d Permission) Get(ctx context.Context, model *rbac.Permission, opts ...orm.QueryOption) error {
+	return orm.FirstWithContext(ctx, d.db, model, opts...)
+}
+
+// Create implements rbac.PermissionStorer.
+func (d Permission) Create(ctx context.Context, model *rbac.Permission) error {
+	return orm.Conn(ctx, d.db).Create(model).Error
+}
+
+// Update implements rbac.PermissionStorer.
+func (d Permission) Update(ctx context.Context, model *rbac.Permission, changeFn func(*rbac.Permission), opts ...orm.QueryOption) error {
+	return orm.UpdateWithContext(ctx, d.db, model, changeFn, opts...)
+}
+
+// Delete implements rbac.PermissionStorer.
+func (d Permission) Delete(ctx context.Context, model *rbac.Permission, opts ...orm.QueryOption) error {
+	return orm.DeleteWithContext(ctx, d.db, model, opts...)
+}
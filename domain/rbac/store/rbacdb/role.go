@@ -0,0 +1,78 @@
+// Code generated by godddx, DO AVOID EDIT.
+package rbacdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ rbac.RoleStorer = Role{}
+
+// Role Related business namespaces
+type Role DB
+
+// List implements rbac.RoleStorer.
+func (d Role) List(ctx context.Context, bs *[]*rbac.Role, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Get implements rbac.RoleStorer.
+func (d Role) Get(ctx context.Context, model *rbac.Role, opts ...orm.QueryOption) error {
+	return orm.FirstWithContext(ctx, d.db, model, opts...)
+}
+
+// Create implements rbac.RoleStorer.
+func (d Role) Create(ctx context.Context, model *rbac.Role) error {
+	return orm.Conn(ctx, d.db).Create(model).Error
+}
+
+// Update implements rbac.RoleStorer.
+func (d Role) Update(ctx context.Context, model *rbac.Role, changeFn func(*rbac.Role), opts ...orm.QueryOption) error {
+	return orm.UpdateWithContext(ctx, d.db, model, changeFn, opts...)
+}
+
+// UpdateOptimistic implements rbac.RoleStorer.
+func (d Role) UpdateOptimistic(ctx context.Context, model *rbac.Role, version int, changeFn func(*rbac.Role) error, opts ...orm.QueryOption) error {
+	return orm.UpdateOptimistic(ctx, d.db, model, version, changeFn, opts...)
+}
+
+// Delete implements rbac.RoleStorer.
+func (d Role) Delete(ctx context.Context, model *rbac.Role, opts ...orm.QueryOption) error {
+	return orm.DeleteWithContext(ctx, d.db, model, opts...)
+}
+
+// Restore implements rbac.RoleStorer.
+func (d Role) Restore(ctx context.Context, opts ...orm.QueryOption) (int64, error) {
+	return orm.RestoreWithContext[rbac.Role](ctx, d.db, opts...)
+}
+
+// ListTrashed implements rbac.RoleStorer.
+func (d Role) ListTrashed(ctx context.Context, bs *[]*rbac.Role, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.ListTrashedWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Purge implements rbac.RoleStorer.
+// 角色与权限的绑定在软删除时保留，彻底删除时一并删除
+func (d Role) Purge(ctx context.Context, before time.Time, opts ...orm.QueryOption) (int64, error) {
+	var n int64
+	err := orm.Transaction(ctx, d.db, func(ctx context.Context) error {
+		var ids []int
+		tx := orm.Conn(ctx, d.db).Unscoped().Model(new(rbac.Role)).Where("deleted_at < ?", before)
+		for _, opt := range opts {
+			tx = opt(tx)
+		}
+		if err := tx.Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+		if err := orm.Conn(ctx, d.db).Where("role_id IN ?", ids).Delete(new(rbac.RolePermission)).Error; err != nil {
+			return err
+		}
+		var err error
+		n, err = orm.PurgeWithContext[rbac.Role](ctx, d.db, before, orm.Where("id IN ?", ids))
+		return err
+	})
+	return n, err
+}
@@ -0,0 +1,89 @@
+package rbacdb
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ixugo/goddd/domain/rbac"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var _ rbac.RolePermissionStorer = RolePermission{}
+
+// RolePermission Related business namespaces
+type RolePermission DB
+
+// ListCodes implements rbac.RolePermissionStorer.
+// 已删除的角色不再拥有任何权限
+func (d RolePermission) ListCodes(ctx context.Context, roleID int) ([]string, error) {
+	codes := make([]string, 0, 8)
+	err := d.byRole(ctx, roleID).Pluck("permissions.code", &codes).Error
+	return codes, err
+}
+
+// ListByRole implements rbac.RolePermissionStorer.
+func (d RolePermission) ListByRole(ctx context.Context, roleID int) ([]*rbac.Permission, error) {
+	out := make([]*rbac.Permission, 0, 8)
+	err := d.byRole(ctx, roleID).Select("permissions.*").Order("permissions.code ASC").Find(&out).Error
+	return out, err
+}
+
+func (d RolePermission) byRole(ctx context.Context, roleID int) *gorm.DB {
+	tx := orm.Conn(ctx, d.db).Model(new(rbac.Permission)).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Where("role_permissions.role_id = ?", roleID)
+	// 关联的 roles 不会自动按租户过滤，需要显式限定
+	if tenantID, ok := orm.TenantFrom(ctx); ok {
+		tx = tx.Where("roles.tenant_id = ?", tenantID)
+	}
+	return tx
+}
+
+// Set implements rbac.RolePermissionStorer.
+func (d RolePermission) Set(ctx context.Context, roleID int, permissionIDs []int) error {
+	ids := slices.Compact(slices.Sorted(slices.Values(permissionIDs)))
+	return orm.Conn(ctx, d.db).Transaction(func(tx *gorm.DB) error {
+		if len(ids) > 0 {
+			var total int64
+			if err := tx.Model(new(rbac.Permission)).Where("id IN ?", ids).Count(&total).Error; err != nil {
+				return err
+			}
+			if int(total) != len(ids) {
+				return orm.ErrRecordNotFound
+			}
+		}
+		if err := tx.Where("role_id = ?", roleID).Delete(new(rbac.RolePermission)).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		items := make([]rbac.RolePermission, len(ids))
+		for i, id := range ids {
+			items[i] = rbac.RolePermission{RoleID: roleID, PermissionID: id, CreatedAt: orm.Now()}
+		}
+		return tx.Create(&items).Error
+	})
+}
+
+// DeleteByRole implements rbac.RolePermissionStorer.
+func (d RolePermission) DeleteByRole(ctx context.Context, roleID int) error {
+	return orm.Conn(ctx, d.db).Where("role_id = ?", roleID).Delete(new(rbac.RolePermission)).Error
+}
+
+// DeleteByPermission implements rbac.RolePermissionStorer.
+func (d RolePermission) DeleteByPermission(ctx context.Context, permissionID int) ([]int, error) {
+	var deleted []rbac.RolePermission
+	if err := orm.Conn(ctx, d.db).Clauses(clause.Returning{Columns: []clause.Column{{Name: "role_id"}}}).
+		Where("permission_id = ?", permissionID).Delete(&deleted).Error; err != nil {
+		return nil, err
+	}
+	roleIDs := make([]int, len(deleted))
+	for i, v := range deleted {
+		roleIDs[i] = v.RoleID
+	}
+	return roleIDs, nil
+}
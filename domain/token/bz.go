@@ -10,12 +10,22 @@ import (
 )
 
 // DelayToken 延迟 token，短期内只会延迟一次，expire 过期时间应该大于 10 分钟
+// 内存去重仅对当前进程有效，多副本时通过条件更新保证 10 分钟内只写一次数据库
 func (c Core) DelayToken(ctx context.Context, token string, expire time.Time) error {
 	_, exist := c.data.LoadOrStore(token, struct{}{}, 10*time.Minute)
 	if exist {
 		return nil
 	}
-	return c.DelayTokenNow(ctx, token, expire)
+	hash := sha256.Sum256([]byte(token))
+	var to Token
+	err := c.store.Token().Update(ctx, &to, func(t *Token) {
+		t.ExpiredAt.Time = expire
+	}, orm.Where("hash = ?", hash[:]), orm.Where("expired_at < ?", expire.Add(-10*time.Minute)))
+	// 其它副本已经延迟过
+	if orm.IsErrRecordNotFound(err) {
+		return nil
+	}
+	return err
 }
 
 // DelayTokenNow 立即延迟 token 过期时间
@@ -32,17 +42,30 @@ func (c Core) DeleteExpired(ctx context.Context, before time.Time) ([]string, er
 	return c.store.Token().DeleteExpired(ctx, before)
 }
 
+// PurgeTokens 彻底删除 before 之前删除的 token
+func (c Core) PurgeTokens(ctx context.Context, before time.Time) (int64, error) {
+	n, err := c.store.Token().Purge(ctx, before)
+	if err != nil {
+		return 0, reason.ErrDB.Withf(`Purge err[%s]`, err.Error())
+	}
+	return n, nil
+}
+
 // Valid 验证 token 是否过期
 func (c Core) Valid(ctx context.Context, token string) error {
 	hash := sha256.Sum256([]byte(token))
 	var to Token
 	to.Hash = hash[:]
-	if err := c.store.Token().Get(ctx, &to, orm.Where("hash = ?", hash[:])); err != nil {
+	if err := c.store.Token().Get(ctx, &to, orm.Where("hash = ?", hash[:]), orm.Primary()); err != nil {
 		if orm.IsErrRecordNotFound(err) {
 			return reason.ErrUnauthorizedToken.SetMsg("请重新登录")
 		}
 		return reason.ErrDB.Withf("token get err[%s]", err.Error())
 	}
+	// 已轮换的 refresh token 不再有效
+	if to.Rotated {
+		return reason.ErrUnauthorizedToken.SetMsg("请重新登录")
+	}
 	if to.ExpiredAt.Before(time.Now()) {
 		if to.Reason != "" {
 			return reason.ErrUnauthorizedToken.SetMsg(to.Reason)
@@ -52,12 +75,34 @@ func (c Core) Valid(ctx context.Context, token string) error {
 	return nil
 }
 
-// DeleteAllForUser 删除用户的所有 token
+// DeleteAllForUser 删除用户的所有 token，并发布 LoggedOutEvent
 func (c Core) DeleteAllForUser(ctx context.Context, scope, userID string) ([]string, error) {
-	return c.store.Token().DeleteAllForUser(ctx, scope, userID)
+	var hashes []string
+	err := c.atomic(ctx, func(ctx context.Context) error {
+		var err error
+		if hashes, err = c.store.Token().DeleteAllForUser(ctx, scope, userID); err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		return c.publish(ctx, LoggedOutEvent{Scope: scope, UserID: userID, Hashes: hashes})
+	})
+	return hashes, err
 }
 
-// Expire 主动过期
+// Expire 主动过期，并发布 LoggedOutEvent
 func (c Core) Expire(ctx context.Context, scope string, userID string, reason string) ([]string, error) {
-	return c.store.Token().Expire(ctx, scope, userID, reason)
+	var hashes []string
+	err := c.atomic(ctx, func(ctx context.Context) error {
+		var err error
+		if hashes, err = c.store.Token().Expire(ctx, scope, userID, reason); err != nil {
+			return err
+		}
+		if len(hashes) == 0 {
+			return nil
+		}
+		return c.publish(ctx, LoggedOutEvent{Scope: scope, UserID: userID, Hashes: hashes, Reason: reason})
+	})
+	return hashes, err
 }
@@ -2,7 +2,12 @@
 package token
 
 import (
+	"context"
+	"time"
+
 	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/event"
+	"github.com/ixugo/goddd/pkg/web"
 )
 
 // Storer data persistence
@@ -10,13 +15,43 @@ type Storer interface {
 	Token() TokenStorer
 }
 
+// Config 令牌签发配置
+type Config struct {
+	Keys       *web.KeySet     // jwt 签名密钥
+	AccessTTL  time.Duration   // access token 有效期，默认 2 小时
+	RefreshTTL time.Duration   // refresh token 有效期，默认 7 天
+	Events     event.Publisher // 领域事件，为空时不发布
+}
+
 // Core business domain
 type Core struct {
 	store Storer
 	data  *conc.TTLMap[string, struct{}]
+	cfg   Config
 }
 
 // NewCore create business domain
-func NewCore(store Storer) Core {
-	return Core{store: store, data: conc.NewTTLMap[string, struct{}]()}
+func NewCore(store Storer, cfg Config) Core {
+	if cfg.AccessTTL <= 0 {
+		cfg.AccessTTL = 2 * time.Hour
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = 7 * 24 * time.Hour
+	}
+	return Core{store: store, data: conc.NewTTLMap[string, struct{}](), cfg: cfg}
+}
+
+// atomic 配置 Events 时，fn 内的操作与发布的事件在同一事务中
+func (c Core) atomic(ctx context.Context, fn func(ctx context.Context) error) error {
+	if c.cfg.Events == nil {
+		return fn(ctx)
+	}
+	return c.cfg.Events.Transaction(ctx, fn)
+}
+
+func (c Core) publish(ctx context.Context, events ...event.Event) error {
+	if c.cfg.Events == nil {
+		return nil
+	}
+	return c.cfg.Events.Publish(ctx, events...)
 }
@@ -0,0 +1,14 @@
+package token
+
+// LoggedOutEvent 用户在某个场景下的全部令牌被注销或吊销
+type LoggedOutEvent struct {
+	Scope  string   `json:"scope"`   // 应用场景
+	UserID string   `json:"user_id"` // 用户标识
+	Hashes []string `json:"hashes"`  // 受影响的令牌
+	Reason string   `json:"reason"`  // 吊销原因，用户主动注销时为空
+}
+
+// Topic implements event.Event.
+func (LoggedOutEvent) Topic() string {
+	return "token.logged_out"
+}
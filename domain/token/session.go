@@ -0,0 +1,145 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/web"
+)
+
+// TokenType 令牌类型
+const TokenType = "Bearer"
+
+// IssueToken 签发令牌，通常在用户登录成功后调用
+// 数据库仅保存 refresh token 的 SHA-256，原文只在此处返回一次
+func (c Core) IssueToken(ctx context.Context, in *IssueTokenInput) (*TokenPair, error) {
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, reason.ErrServer.Withf("rand err[%s]", err.Error())
+	}
+	now := time.Now()
+	to := Token{
+		TenantID:  in.Data.GetString(web.KeyTenantID),
+		UserID:    in.UserID,
+		Scope:     in.Scope,
+		Hash:      hash,
+		CreatedAt: orm.Time{Time: now},
+		UpdatedAt: orm.Time{Time: now},
+		ExpiredAt: orm.Time{Time: now.Add(c.cfg.RefreshTTL)},
+		Data:      Claims(in.Data),
+	}
+	if err := c.store.Token().Create(ctx, &to); err != nil {
+		return nil, reason.ErrDB.Withf(`Create err[%s]`, err.Error())
+	}
+	return c.newTokenPair(&to, refresh)
+}
+
+// RefreshToken 使用 refresh token 换取新的令牌
+// refresh token 每次使用后都会轮换，已轮换的令牌再次使用，视为令牌泄露，该用户在此场景下的全部令牌都将失效
+func (c Core) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	hash := sha256.Sum256([]byte(refreshToken))
+	old := Token{Hash: hash[:]}
+	if err := c.store.Token().Get(ctx, &old, orm.Where("hash = ?", hash[:]), orm.Primary()); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrUnauthorizedToken.SetMsg("请重新登录")
+		}
+		return nil, reason.ErrDB.Withf("token get err[%s]", err.Error())
+	}
+	if old.Rotated {
+		return nil, c.revokeFamily(ctx, &old)
+	}
+	if old.ExpiredAt.Before(time.Now()) {
+		if old.Reason != "" {
+			return nil, reason.ErrUnauthorizedToken.SetMsg(old.Reason)
+		}
+		return nil, reason.ErrUnauthorizedToken.SetMsg("请重新登录")
+	}
+
+	refresh, nextHash, err := newRefreshToken()
+	if err != nil {
+		return nil, reason.ErrServer.Withf("rand err[%s]", err.Error())
+	}
+	now := time.Now()
+	next := Token{
+		TenantID:  old.TenantID,
+		UserID:    old.UserID,
+		Scope:     old.Scope,
+		Hash:      nextHash,
+		CreatedAt: orm.Time{Time: now},
+		UpdatedAt: orm.Time{Time: now},
+		ExpiredAt: orm.Time{Time: now.Add(c.cfg.RefreshTTL)},
+		Data:      old.Data,
+	}
+	if err := c.store.Token().Rotate(ctx, old.Hash, &next); err != nil {
+		// 并发刷新时，其它请求已抢先完成轮换
+		if orm.IsErrRecordNotFound(err) {
+			return nil, c.revokeFamily(ctx, &old)
+		}
+		return nil, reason.ErrDB.Withf("Rotate err[%s]", err.Error())
+	}
+	return c.newTokenPair(&next, refresh)
+}
+
+// Logout 注销 refresh token，all 为 true 时注销该用户在此场景下的全部令牌
+// 令牌不存在时视为已注销
+func (c Core) Logout(ctx context.Context, refreshToken string, all bool) error {
+	hash := sha256.Sum256([]byte(refreshToken))
+	to := Token{Hash: hash[:]}
+	if err := c.store.Token().Get(ctx, &to, orm.Where("hash = ?", hash[:]), orm.Primary()); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil
+		}
+		return reason.ErrDB.Withf("token get err[%s]", err.Error())
+	}
+	if all {
+		// 刷新与注销时 ctx 中没有租户，按令牌所属的租户限定，用户 id 可以在租户间重复
+		if _, err := c.DeleteAllForUser(orm.WithTenant(ctx, to.TenantID), to.Scope, to.UserID); err != nil {
+			return reason.ErrDB.Withf("DeleteAllForUser err[%s]", err.Error())
+		}
+		return nil
+	}
+	if err := c.store.Token().Delete(ctx, &to, orm.Where("hash = ?", hash[:])); err != nil {
+		return reason.ErrDB.Withf("Delete err[%s]", err.Error())
+	}
+	return nil
+}
+
+// revokeFamily 检测到 refresh token 重放，吊销该用户在此场景下的全部令牌
+func (c Core) revokeFamily(ctx context.Context, to *Token) error {
+	const msg = "登录状态异常，请重新登录"
+	slog.WarnContext(ctx, "refresh token reused", "tenant_id", to.TenantID, "user_id", to.UserID, "scope", to.Scope)
+	if _, err := c.Expire(orm.WithTenant(ctx, to.TenantID), to.Scope, to.UserID, msg); err != nil {
+		return reason.ErrDB.Withf("Expire err[%s]", err.Error())
+	}
+	return reason.ErrUnauthorizedToken.SetMsg(msg)
+}
+
+func (c Core) newTokenPair(to *Token, refresh string) (*TokenPair, error) {
+	access, err := c.cfg.Keys.NewToken(to.Data, web.WithExpires(c.cfg.AccessTTL))
+	if err != nil {
+		return nil, reason.ErrServer.Withf("NewToken err[%s]", err.Error())
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    TokenType,
+		ExpiresIn:    int64(c.cfg.AccessTTL.Seconds()),
+	}, nil
+}
+
+// newRefreshToken 生成不透明的 refresh token 及其 SHA-256
+func newRefreshToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	token := hex.EncodeToString(b)
+	hash := sha256.Sum256([]byte(token))
+	return token, hash[:], nil
+}
@@ -0,0 +1,269 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/domain/token"
+	"github.com/ixugo/goddd/domain/token/store/tokencache"
+	"github.com/ixugo/goddd/domain/token/store/tokendb"
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+func newTestCore(t *testing.T) token.Core {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.Use(orm.TenantPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := web.NewHMACKeySet("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := tokencache.NewCache(tokendb.NewDB(db).AutoMigrate(true), conc.NewTTLCache(time.Minute))
+	return token.NewCore(store, token.Config{Keys: keys})
+}
+
+func issue(t *testing.T, core token.Core, userID string) *token.TokenPair {
+	t.Helper()
+	return issueTenant(t, core, "", userID)
+}
+
+func issueTenant(t *testing.T, core token.Core, tenantID, userID string) *token.TokenPair {
+	t.Helper()
+	pair, err := core.IssueToken(context.Background(), &token.IssueTokenInput{
+		UserID: userID,
+		Scope:  "web",
+		Data:   web.NewClaimsData().SetUsername(userID).SetTenantID(tenantID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair
+}
+
+func TestRefreshToken(t *testing.T) {
+	core := newTestCore(t)
+	ctx := context.Background()
+
+	first := issue(t, core, "u1")
+	next, err := core.RefreshToken(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.RefreshToken == first.RefreshToken || next.AccessToken == "" {
+		t.Fatalf("expect new pair, got %+v", next)
+	}
+	if err := core.Valid(ctx, first.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect rotated token invalid, got %v", err)
+	}
+	if err := core.Valid(ctx, next.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已轮换的令牌再次使用，吊销整个家族，其它用户不受影响
+	other := issue(t, core, "u2")
+	if _, err := core.RefreshToken(ctx, first.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect reuse rejected, got %v", err)
+	}
+	if err := core.Valid(ctx, next.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect family revoked, got %v", err)
+	}
+	if _, err := core.RefreshToken(ctx, next.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect revoked token rejected, got %v", err)
+	}
+	if err := core.Valid(ctx, other.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshToken_Tenant(t *testing.T) {
+	core := newTestCore(t)
+	ctx := context.Background()
+
+	// 用户 id 在租户间重复
+	first := issueTenant(t, core, "t1", "u1")
+	other := issueTenant(t, core, "t2", "u1")
+	if _, err := core.RefreshToken(ctx, first.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := core.RefreshToken(ctx, first.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect reuse rejected, got %v", err)
+	}
+	if err := core.Valid(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("expect other tenant unaffected, got %v", err)
+	}
+
+	// 缓存中其它租户的令牌不可见
+	if err := core.Valid(orm.WithTenant(ctx, "t1"), other.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect other tenant token invisible, got %v", err)
+	}
+	if err := core.Valid(orm.WithTenant(ctx, "t2"), other.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+
+	items, total, err := core.ListTokens(orm.WithTenant(ctx, "t2"), &token.FindTokenInput{PagerFilter: web.PagerFilter{Size: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || items[0].TenantID != "t2" {
+		t.Fatalf("expect only t2 tokens, got %d", total)
+	}
+
+	if err := core.Logout(ctx, other.RefreshToken, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.Valid(ctx, other.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect logged out, got %v", err)
+	}
+}
+
+func TestScrollTokens(t *testing.T) {
+	core := newTestCore(t)
+	ctx := context.Background()
+	for _, uid := range []string{"u1", "u1", "u1", "u2"} {
+		issue(t, core, uid)
+	}
+
+	in := token.FindTokenInput{PagerFilter: web.PagerFilter{Size: 2, Sort: "created_at"}, UserID: "u1"}
+	var ids []int
+	for range 3 {
+		out, err := core.ScrollTokens(ctx, &in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range out.Items {
+			ids = append(ids, v.ID)
+		}
+		if in.Cursor = out.Next; in.Cursor == "" {
+			break
+		}
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[2] != 3 {
+		t.Fatalf("unexpected scroll %v", ids)
+	}
+
+	// 被篡改的游标
+	in.Cursor = "invalid"
+	if _, err := core.ScrollTokens(ctx, &in); !errors.Is(err, reason.ErrBadRequest) {
+		t.Fatalf("expect ErrBadRequest, got %v", err)
+	}
+}
+
+func TestRefreshToken_Concurrent(t *testing.T) {
+	core := newTestCore(t)
+	pair := issue(t, core, "u1")
+
+	const n = 8
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = core.RefreshToken(context.Background(), pair.RefreshToken)
+		}()
+	}
+	wg.Wait()
+
+	var success int
+	for _, err := range errs {
+		if err == nil {
+			success++
+		} else if !errors.Is(err, reason.ErrUnauthorizedToken) {
+			t.Fatalf("unexpected %v", err)
+		}
+	}
+	if success != 1 {
+		t.Fatalf("expect exactly one winner, got %d", success)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	core := newTestCore(t)
+	ctx := context.Background()
+
+	a := issue(t, core, "u1")
+	b := issue(t, core, "u1")
+	c := issue(t, core, "u1")
+	other := issue(t, core, "u2")
+
+	if err := core.Logout(ctx, a.RefreshToken, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := core.Valid(ctx, a.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect logged out, got %v", err)
+	}
+	if err := core.Valid(ctx, b.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	// 令牌不存在时视为已注销
+	if err := core.Logout(ctx, a.RefreshToken, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := core.Logout(ctx, b.RefreshToken, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []*token.TokenPair{b, c} {
+		if err := core.Valid(ctx, v.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+			t.Fatalf("expect all tokens logged out, got %v", err)
+		}
+	}
+	if err := core.Valid(ctx, other.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTokenTrash(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	keys, err := web.NewHMACKeySet("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := tokendb.NewDB(db).AutoMigrate(true)
+	core := token.NewCore(store, token.Config{Keys: keys})
+	ctx := context.Background()
+
+	a := issue(t, core, "u1")
+	issue(t, core, "u1")
+	hashes, err := core.DeleteAllForUser(ctx, "web", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 缓存依赖返回的 hash 清除
+	if len(hashes) != 2 {
+		t.Fatalf("expect 2 hashes, got %v", hashes)
+	}
+	if err := core.Valid(ctx, a.RefreshToken); !errors.Is(err, reason.ErrUnauthorizedToken) {
+		t.Fatalf("expect deleted token invalid, got %v", err)
+	}
+
+	var items []*token.Token
+	if total, err := store.Token().ListTrashed(ctx, &items, nil); err != nil || total != 2 {
+		t.Fatalf("expect 2 trashed tokens, got %d %v", total, err)
+	}
+	if n, err := core.PurgeTokens(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("expect retention respected, got %d %v", n, err)
+	}
+	if n, err := core.PurgeTokens(ctx, time.Now().Add(time.Second)); err != nil || n != 2 {
+		t.Fatalf("expect 2 purged, got %d %v", n, err)
+	}
+}
@@ -3,6 +3,8 @@ package tokencache
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,9 +24,7 @@ func (c *Token) Expire(ctx context.Context, scope string, userID string, reason
 	if err != nil {
 		return keys, err
 	}
-	for _, key := range keys {
-		c.token.Del(ctx, c.cacheKey(key))
-	}
+	c.del(ctx, keys...)
 	return keys, nil
 }
 
@@ -34,39 +34,93 @@ func (c *Token) DeleteAllForUser(ctx context.Context, scope string, userID strin
 	if err != nil {
 		return keys, err
 	}
-	for _, key := range keys {
-		c.token.Del(ctx, c.cacheKey(key))
-	}
+	c.del(ctx, keys...)
 	return keys, nil
 }
 
+// Rotate implements token.TokenStorer.
+func (c *Token) Rotate(ctx context.Context, hash []byte, next *token.Token) error {
+	if err := c.store.Token().Rotate(ctx, hash, next); err != nil {
+		return err
+	}
+	c.del(ctx, hex.EncodeToString(hash))
+	c.set(ctx, next)
+	return nil
+}
+
+// visible 缓存的令牌是否属于 ctx 中的租户，ctx 中没有租户时不限定
+func (c *Token) visible(ctx context.Context, v *token.Token) bool {
+	tenantID, ok := orm.TenantFrom(ctx)
+	return !ok || v.TenantID == tenantID
+}
+
 func (c *Token) cacheKey(key any) string {
 	return fmt.Sprintf("TOKEN:%v", key)
 }
 
+// del 事务提交后删除缓存，回滚时保留
+func (c *Token) del(ctx context.Context, keys ...string) {
+	orm.AfterCommit(ctx, func(ctx context.Context) {
+		for _, key := range keys {
+			c.token.Del(ctx, c.cacheKey(key))
+		}
+	})
+}
+
+// set 事务提交后写入缓存，复制一份避免提交前 model 被修改
+func (c *Token) set(ctx context.Context, model *token.Token) {
+	v := *model
+	orm.AfterCommit(ctx, func(ctx context.Context) {
+		c.token.Set(ctx, c.cacheKey(v.CacheKey()), &v)
+	})
+}
+
 // DeleteExpired implements token.TokenStorer.
 func (c *Token) DeleteExpired(ctx context.Context, before time.Time) ([]string, error) {
 	ids, err := c.store.Token().DeleteExpired(ctx, before)
 	if err != nil {
 		return ids, err
 	}
-	for _, id := range ids {
-		c.token.Del(ctx, c.cacheKey(id))
-	}
+	c.del(ctx, ids...)
 	return ids, nil
 }
 
+// Restore implements token.TokenStorer.
+// 已删除的令牌不会被缓存，恢复后首次查询时写入缓存
+func (c *Token) Restore(ctx context.Context, opts ...orm.QueryOption) (int64, error) {
+	return c.store.Token().Restore(ctx, opts...)
+}
+
+// ListTrashed implements token.TokenStorer.
+func (c *Token) ListTrashed(ctx context.Context, bs *[]*token.Token, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return c.store.Token().ListTrashed(ctx, bs, page, opts...)
+}
+
+// Purge implements token.TokenStorer.
+// 软删除时已清除缓存，无需再次清除
+func (c *Token) Purge(ctx context.Context, before time.Time, opts ...orm.QueryOption) (int64, error) {
+	return c.store.Token().Purge(ctx, before, opts...)
+}
+
 // Find implements token.TokenStorer.
 func (c *Token) List(ctx context.Context, bs *[]*token.Token, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
 	return c.store.Token().List(ctx, bs, page, opts...)
 }
 
+// Scroll implements token.TokenStorer.
+func (c *Token) Scroll(ctx context.Context, bs *[]*token.Token, page orm.ScrollPager, opts ...orm.QueryOption) (string, error) {
+	return c.store.Token().Scroll(ctx, bs, page, opts...)
+}
+
 // Get implements token.TokenStorer.
-// 注意: 若想走缓存，则 model 的 id 必传
-// 条件查询无法缓存，此缓存仅为 ID 查询生效。
+// 注意: 若想走缓存，则 model 的 hash 必传
+// 条件查询无法缓存，此缓存仅为 hash 查询生效。
+// key 不加租户前缀，签发、刷新与注销时 ctx 中没有租户；命中其它租户的令牌时视为未命中，交给数据库按租户过滤
 func (c *Token) Get(ctx context.Context, model *token.Token, opts ...orm.QueryOption) error {
 	if key := model.CacheKey(); key != "" {
-		if err := c.token.Get(ctx, c.cacheKey(model.CacheKey()), model); err == nil {
+		var v token.Token
+		if err := c.token.Get(ctx, c.cacheKey(key), &v); err == nil && c.visible(ctx, &v) {
+			*model = v
 			return nil
 		}
 	}
@@ -74,7 +128,11 @@ func (c *Token) Get(ctx context.Context, model *token.Token, opts ...orm.QueryOp
 	if err := c.store.Token().Get(ctx, model, opts...); err != nil {
 		return err
 	}
-	c.token.SetNX(ctx, c.cacheKey(model.CacheKey()), model)
+	// 事务中读到的数据可能回滚，提交后再缓存
+	v := *model
+	orm.AfterCommit(ctx, func(ctx context.Context) {
+		c.token.SetNX(ctx, c.cacheKey(v.CacheKey()), &v)
+	})
 	return nil
 }
 
@@ -83,7 +141,7 @@ func (c *Token) Create(ctx context.Context, model *token.Token) error {
 	if err := c.store.Token().Create(ctx, model); err != nil {
 		return err
 	}
-	c.token.Set(ctx, c.cacheKey(model.CacheKey()), model)
+	c.set(ctx, model)
 	return nil
 }
 
@@ -92,7 +150,7 @@ func (c *Token) Update(ctx context.Context, model *token.Token, changeFn func(*t
 	if err := c.store.Token().Update(ctx, model, changeFn, opts...); err != nil {
 		return err
 	}
-	c.token.Set(ctx, c.cacheKey(model.CacheKey()), model)
+	c.set(ctx, model)
 	return nil
 }
 
@@ -101,21 +159,29 @@ func (c *Token) Delete(ctx context.Context, model *token.Token, opts ...orm.Quer
 	if err := c.store.Token().Delete(ctx, model, opts...); err != nil {
 		return err
 	}
-	c.token.Del(ctx, c.cacheKey(model.CacheKey()))
+	c.del(ctx, model.CacheKey())
 	return nil
 }
 
 // Session 事务组合
 func (c *Token) Session(ctx context.Context, changeFns ...func(*gorm.DB) error) error {
-	// return c.store.Token().Session(ctx, changeFns...)
-	return nil
+	s, ok := c.store.Token().(orm.UniversalSession[token.Token])
+	if !ok {
+		return errors.New("token store does not support session")
+	}
+	return s.Session(ctx, changeFns...)
 }
 
-// UpdateWithSession 修改事务
+// EditWithSession 修改事务
+// tx 应当来自 Session，缓存在事务提交后删除
 func (c *Token) EditWithSession(tx *gorm.DB, model *token.Token, changeFn func(b *token.Token) error, opts ...orm.QueryOption) error {
-	//	if err := c.store.Token().EditWithSession(ctx,model, changeFn,opts...);err!=nil{
-	// return err
-	//	}
-	//	c.token.Set(ctx, c.cacheKey(model.ID ),  model)
+	s, ok := c.store.Token().(orm.UniversalSession[token.Token])
+	if !ok {
+		return errors.New("token store does not support session")
+	}
+	if err := s.EditWithSession(tx, model, changeFn, opts...); err != nil {
+		return err
+	}
+	c.del(tx.Statement.Context, model.CacheKey())
 	return nil
 }
@@ -20,9 +20,9 @@ type Token DB
 // Expire implements token.TokenStorer.
 func (d Token) Expire(ctx context.Context, scope string, userID string, reason string) ([]string, error) {
 	var expiredTokens []token.Token
-	if err := d.db.WithContext(ctx).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "hash"}}}).
+	if err := orm.Conn(ctx, d.db).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "hash"}}}).
 		Where("scope = ? AND user_id = ? AND expired_at > ?", scope, userID, time.Now()).
-		Model(&expiredTokens).Update("reason", reason).Error; err != nil {
+		Model(&expiredTokens).Updates(map[string]any{"reason": reason, "expired_at": time.Now()}).Error; err != nil {
 		return nil, err
 	}
 
@@ -33,6 +33,21 @@ func (d Token) Expire(ctx context.Context, scope string, userID string, reason s
 	return hashes, nil
 }
 
+// Rotate implements token.TokenStorer.
+func (d Token) Rotate(ctx context.Context, hash []byte, next *token.Token) error {
+	return orm.Conn(ctx, d.db).Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发刷新时只有一个请求能轮换成功
+		result := tx.Model(new(token.Token)).Where("hash = ? AND rotated = ?", hash, false).Update("rotated", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return orm.ErrRecordNotFound
+		}
+		return tx.Create(next).Error
+	})
+}
+
 // NewToken instance object
 func NewToken(db *gorm.DB) Token {
 	return Token{db: db}
@@ -43,6 +58,11 @@ func (d Token) List(ctx context.Context, bs *[]*token.Token, page orm.Pager, opt
 	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
 }
 
+// Scroll implements token.TokenStorer.
+func (d Token) Scroll(ctx context.Context, bs *[]*token.Token, page orm.ScrollPager, opts ...orm.QueryOption) (string, error) {
+	return orm.ScrollWithContext(ctx, d.db, bs, page, opts...)
+}
+
 // Get implements token.TokenStorer.
 func (d Token) Get(ctx context.Context, model *token.Token, opts ...orm.QueryOption) error {
 	return orm.FirstWithContext(ctx, d.db, model, opts...)
@@ -50,7 +70,7 @@ func (d Token) Get(ctx context.Context, model *token.Token, opts ...orm.QueryOpt
 
 // Add implements token.TokenStorer.
 func (d Token) Create(ctx context.Context, model *token.Token) error {
-	return d.db.WithContext(ctx).Create(model).Error
+	return orm.Conn(ctx, d.db).Create(model).Error
 }
 
 // Edit implements token.TokenStorer.
@@ -65,7 +85,8 @@ func (d Token) Delete(ctx context.Context, model *token.Token, opts ...orm.Query
 
 // Session 事务组合
 func (d Token) Session(ctx context.Context, changeFns ...func(*gorm.DB) error) error {
-	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return orm.Transaction(ctx, d.db, func(ctx context.Context) error {
+		tx := orm.Conn(ctx, d.db)
 		for _, fn := range changeFns {
 			if err := fn(tx); err != nil {
 				return err
@@ -82,27 +103,21 @@ func (d Token) EditWithSession(tx *gorm.DB, model *token.Token, changeFn func(b
 
 // DeleteAllForUser 删除用户的 token
 func (d Token) DeleteAllForUser(ctx context.Context, scope, userID string) ([]string, error) {
-	var deletedTokens []token.Token
-	result := d.db.WithContext(ctx).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "hash"}}}).
-		Where("scope = ? AND user_id = ?", scope, userID).Delete(&deletedTokens)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	hashes := make([]string, len(deletedTokens))
-	for i, t := range deletedTokens {
-		hashes[i] = hex.EncodeToString(t.Hash)
-	}
-	return hashes, nil
+	return d.softDelete(ctx, "scope = ? AND user_id = ?", scope, userID)
 }
 
-// DeleteExpired 删除过期的 token
+// DeleteExpired 删除过期的 token，彻底删除由 Purge 按回收站的保留时长执行
 func (d Token) DeleteExpired(ctx context.Context, before time.Time) ([]string, error) {
+	return d.softDelete(ctx, "expired_at < ?", before)
+}
+
+// softDelete 标记删除符合条件的 token，返回其缓存主键
+// 软删除是 UPDATE 语句，gorm 不会为 Delete 追加 RETURNING，因此直接更新 deleted_at
+func (d Token) softDelete(ctx context.Context, query string, args ...any) ([]string, error) {
 	var deletedTokens []token.Token
-	result := d.db.WithContext(ctx).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "hash"}}}).
-		Where("expired_at < ?", before).Delete(&deletedTokens)
-	if result.Error != nil {
-		return nil, result.Error
+	if err := orm.Conn(ctx, d.db).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "hash"}}}).
+		Where(query, args...).Model(&deletedTokens).Update("deleted_at", time.Now()).Error; err != nil {
+		return nil, err
 	}
 
 	hashes := make([]string, len(deletedTokens))
@@ -111,3 +126,18 @@ func (d Token) DeleteExpired(ctx context.Context, before time.Time) ([]string, e
 	}
 	return hashes, nil
 }
+
+// Restore implements token.TokenStorer.
+func (d Token) Restore(ctx context.Context, opts ...orm.QueryOption) (int64, error) {
+	return orm.RestoreWithContext[token.Token](ctx, d.db, opts...)
+}
+
+// ListTrashed implements token.TokenStorer.
+func (d Token) ListTrashed(ctx context.Context, bs *[]*token.Token, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.ListTrashedWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Purge implements token.TokenStorer.
+func (d Token) Purge(ctx context.Context, before time.Time, opts ...orm.QueryOption) (int64, error) {
+	return orm.PurgeWithContext[token.Token](ctx, d.db, before, opts...)
+}
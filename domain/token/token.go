@@ -3,17 +3,20 @@ package token
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"time"
 
 	"github.com/ixugo/goddd/pkg/orm"
 	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/web"
 	"github.com/jinzhu/copier"
 )
 
 // TokenStorer Instantiation interface
 type TokenStorer interface {
 	List(context.Context, *[]*Token, orm.Pager, ...orm.QueryOption) (int64, error)
+	Scroll(context.Context, *[]*Token, orm.ScrollPager, ...orm.QueryOption) (string, error)
 	Get(context.Context, *Token, ...orm.QueryOption) error
 	Create(context.Context, *Token) error
 	Update(context.Context, *Token, func(*Token), ...orm.QueryOption) error
@@ -22,20 +25,72 @@ type TokenStorer interface {
 	DeleteAllForUser(ctx context.Context, scope, userID string) ([]string, error)
 	// 主动过期的函数，记录过期的原因，对用户友好
 	Expire(ctx context.Context, scope, userID, reason string) ([]string, error)
+	// Rotate 将 hash 对应的令牌标记为已轮换并写入新令牌，若其已被轮换则返回 orm.ErrRecordNotFound
+	Rotate(ctx context.Context, hash []byte, next *Token) error
+	orm.SoftDeleter[Token]
 }
 
 // FindToken Paginated search
 func (c Core) ListTokens(ctx context.Context, in *FindTokenInput) ([]*Token, int64, error) {
-	query := orm.NewQuery(1).OrderBy("created_at DESC")
+	opts, err := c.findTokenOptions(in)
+	if err != nil {
+		return nil, 0, err
+	}
+	if sort := in.MustSortColumn(); sort != "" {
+		opts = append(opts, orm.OrderBy(sort))
+	} else {
+		opts = append(opts, orm.OrderBy("created_at DESC"))
+	}
 
 	items := make([]*Token, 0, in.Limit())
-	total, err := c.store.Token().List(ctx, &items, in, query.Encode()...)
+	total, err := c.store.Token().List(ctx, &items, in, opts...)
 	if err != nil {
 		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
 	}
 	return items, total, nil
 }
 
+// ScrollTokens 游标分页，不统计总数，条件与 ListTokens 相同
+func (c Core) ScrollTokens(ctx context.Context, in *FindTokenInput) (*web.ScrollPageOutput[*Token], error) {
+	opts, err := c.findTokenOptions(in)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*Token, 0, in.Limit())
+	next, err := c.store.Token().Scroll(ctx, &items, in, opts...)
+	if errors.Is(err, orm.ErrInvalidCursor) {
+		return nil, reason.ErrBadRequest.SetMsg("游标已失效，请刷新")
+	}
+	if err != nil {
+		return nil, reason.ErrDB.Withf(`Scroll err[%s]`, err.Error())
+	}
+	return web.NewScrollPageOutput(items, next), nil
+}
+
+// findTokenOptions 列表的查询条件，不含排序
+func (c Core) findTokenOptions(in *FindTokenInput) ([]orm.QueryOption, error) {
+	in.SortSafelist = []string{"created_at", "-created_at", "expired_at", "-expired_at"}
+	in.FilterSafelist = orm.FilterSafelist{
+		"user_id":    {},
+		"scope":      {},
+		"expired_at": {Parse: orm.ParseFilterTime},
+		"created_at": {Parse: orm.ParseFilterTime},
+	}
+	filters, err := in.FilterOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	query := orm.NewQuery(3)
+	if in.UserID != "" {
+		query.Where("user_id=?", in.UserID)
+	}
+	if in.Scope != "" {
+		query.Where("scope=?", in.Scope)
+	}
+	return append(query.Encode(), filters...), nil
+}
+
 // GetToken Query a single object
 func (c Core) GetToken(ctx context.Context, id int) (*Token, error) {
 	out := Token{ID: id}
@@ -75,6 +130,7 @@ func (c Core) UpdateToken(ctx context.Context, in *EditTokenInput, id int) (*Tok
 }
 
 // DeleteToken Delete object
+// 软删除，令牌立即失效，记录保留到回收站清理
 func (c Core) DeleteToken(ctx context.Context, id int) (*Token, error) {
 	var out Token
 	if err := c.store.Token().Delete(ctx, &out, orm.Where("id=?", id)); err != nil {
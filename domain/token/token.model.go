@@ -2,7 +2,9 @@
 package token
 
 import (
+	"database/sql/driver"
 	"encoding/hex"
+	"encoding/json"
 
 	"github.com/ixugo/goddd/pkg/orm"
 )
@@ -10,13 +12,18 @@ import (
 // Token domain model
 type Token struct {
 	ID        int      `gorm:"primaryKey" json:"id"`
-	UserID    string   `gorm:"column:user_id;notNull;default:'';comment:用户标识" json:"user_id"` // 用户标识
-	Scope     string   `gorm:"column:scope;notNull;default:'';comment:应用场景" json:"scope"`     // 应用场景
-	Hash      []byte   `gorm:"column:hash;notNull;comment:发给客户端的令牌 SHA-256 加密" json:"hash"`   // 发给客户端的令牌 SHA-256 加密
+	TenantID  string   `gorm:"column:tenant_id;notNull;default:'';index;comment:租户" json:"tenant_id"` // 租户，签发时取自 Claims
+	UserID    string   `gorm:"column:user_id;notNull;default:'';comment:用户标识" json:"user_id"`         // 用户标识
+	Scope     string   `gorm:"column:scope;notNull;default:'';comment:应用场景" json:"scope"`             // 应用场景
+	Hash      []byte   `gorm:"column:hash;notNull;comment:发给客户端的令牌 SHA-256 加密" json:"hash"`           // 发给客户端的令牌 SHA-256 加密
 	CreatedAt orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	ExpiredAt orm.Time `gorm:"column:expired_at;notNull;default:CURRENT_TIMESTAMP;comment:过期时间" json:"expired_at"` // 过期时间
 	Reason    string   `gorm:"column:reason;notNull;default:''" json:"reason"`                                     // 过期原因
+	Rotated   bool     `gorm:"column:rotated;notNull;default:false;comment:是否已轮换" json:"rotated"`                  // 是否已轮换，轮换后再次使用视为令牌泄露
+	Data      Claims   `gorm:"column:data;type:json;comment:签发 access token 的载荷" json:"data"`                      // 签发 access token 的载荷
+	// 软删除，注销、吊销与过期的令牌保留到回收站清理，便于追溯
+	DeletedAt orm.DeletedAt `gorm:"column:deleted_at;index;comment:删除时间" json:"deleted_at"`
 }
 
 // TableName database table name
@@ -29,3 +36,22 @@ func (*Token) TableName() string {
 func (t *Token) CacheKey() string {
 	return hex.EncodeToString(t.Hash)
 }
+
+// Claims 刷新令牌时，用于重新签发 access token 的载荷
+type Claims map[string]any
+
+var _ orm.JSONValueScanner = (*Claims)(nil)
+
+// Scan implements orm.JSONValueScanner.
+func (c *Claims) Scan(input any) error {
+	return orm.JSONUnmarshal(input, c)
+}
+
+// Value implements orm.JSONValueScanner.
+func (c Claims) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
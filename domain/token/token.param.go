@@ -27,3 +27,27 @@ type AddTokenInput struct {
 	Hash      []byte   `json:"hash"`       // 发给客户端的令牌 SHA-256 加密
 	ExpiredAt orm.Time `json:"expired_at"` // 过期时间
 }
+
+// IssueTokenInput 登录成功后签发令牌
+type IssueTokenInput struct {
+	UserID string         // 用户标识
+	Scope  string         // 应用场景
+	Data   web.ClaimsData // access token 载荷
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"` // 刷新令牌
+}
+
+type LogoutTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"` // 刷新令牌
+	All          bool   `json:"all"`                              // 是否注销该用户在此场景下的全部会话
+}
+
+// TokenPair 签发给客户端的令牌
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`  // 访问令牌，jwt
+	RefreshToken string `json:"refresh_token"` // 刷新令牌，仅可使用一次
+	TokenType    string `json:"token_type"`    // 固定为 Bearer
+	ExpiresIn    int64  `json:"expires_in"`    // access token 有效期(秒)
+}
@@ -2,14 +2,15 @@
 package tokenapi
 
 import (
+	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ixugo/goddd/domain/token"
 	"github.com/ixugo/goddd/domain/token/store/tokencache"
 	"github.com/ixugo/goddd/domain/token/store/tokendb"
 	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/event"
 	"github.com/ixugo/goddd/pkg/orm"
 	"github.com/ixugo/goddd/pkg/web"
 	"gorm.io/gorm"
@@ -19,30 +20,42 @@ type TokenAPI struct {
 	TokenCore token.Core
 }
 
-func NewTokenAPI(db *gorm.DB) TokenAPI {
+// NewTokenAPI keys 用于签发 access token
+// 多副本部署时，cache 应当使用 conc.RedisCache，以保证令牌吊销在各副本间可见
+// events 用于发布 token.LoggedOutEvent，可以为 nil
+func NewTokenAPI(db *gorm.DB, keys *web.KeySet, cache conc.Cacher, events event.Publisher) TokenAPI {
 	var store token.Storer
 	store = tokendb.NewDB(db).AutoMigrate(orm.GetEnabledAutoMigrate())
 	// 如果需要缓存，可以取消注释
 	// 目前缓存是通过 id 缓存，而此领域没有获取 id 的条件
-	store = tokencache.NewCache(store, conc.NewTTLCache(time.Hour))
-	core := token.NewCore(store)
+	store = tokencache.NewCache(store, cache)
+	core := token.NewCore(store, token.Config{Keys: keys, Events: events})
 	return TokenAPI{TokenCore: core}
 }
 
 func Register(g gin.IRouter, api TokenAPI, handler ...gin.HandlerFunc) {
 	{
 		group := g.Group("/tokens", handler...)
-		group.GET("", web.WrapH(api.listTokens))
-		group.DELETE("/:id", web.WrapH(api.deleteToken))
-		// group.GET("/:id", web.WrapH(api.getToken))
-		// group.PUT("/:id", web.WrapH(api.editToken))
-		// group.POST("", web.WrapH(api.addToken))
+		web.Handle(group, http.MethodGet, "", api.listTokens)
+		web.Handle(group, http.MethodDelete, "/:id", api.deleteToken)
+		// web.Handle(group, http.MethodGet, "/:id", api.getToken)
+		// web.Handle(group, http.MethodPut, "/:id", api.editToken)
+		// web.Handle(group, http.MethodPost, "", api.addToken)
 	}
+	// 刷新与注销凭 refresh token 鉴权，此时 access token 可能已过期，不经过 handler 中间件
+	web.CustomEndpoints(g, "/tokens", map[string]web.Endpoint{
+		"refresh": web.NewEndpoint(api.refreshToken),
+		"logout":  web.NewEndpoint(api.logoutToken),
+	})
 }
 
 // >>> token >>>>>>>>>>>>>>>>>>>>
 
+// listTokens 携带 cursor 参数时游标分页，返回 {items,next}，否则返回 {items,total}
 func (a TokenAPI) listTokens(c *gin.Context, in *token.FindTokenInput) (any, error) {
+	if in.UseCursor() {
+		return a.TokenCore.ScrollTokens(c.Request.Context(), in)
+	}
 	items, total, err := a.TokenCore.ListTokens(c.Request.Context(), in)
 	return gin.H{"items": items, "total": total}, err
 }
@@ -61,6 +74,15 @@ func (a TokenAPI) listTokens(c *gin.Context, in *token.FindTokenInput) (any, err
 // 	return a.TokenCore.AddToken(c.Request.Context(), in)
 // }
 
+func (a TokenAPI) refreshToken(c *gin.Context, in *token.RefreshTokenInput) (*token.TokenPair, error) {
+	return a.TokenCore.RefreshToken(c.Request.Context(), in.RefreshToken)
+}
+
+func (a TokenAPI) logoutToken(c *gin.Context, in *token.LogoutTokenInput) (gin.H, error) {
+	err := a.TokenCore.Logout(c.Request.Context(), in.RefreshToken, in.All)
+	return gin.H{}, err
+}
+
 func (a TokenAPI) deleteToken(c *gin.Context, _ *struct{}) (any, error) {
 	tokenID, _ := strconv.Atoi(c.Param("id"))
 	return a.TokenCore.DeleteToken(c.Request.Context(), tokenID)
@@ -46,6 +46,11 @@ func (m *IDManager) SetLetterBytes(letterBytes string) {
 
 // UniqueID 获取唯一 id
 func (m *IDManager) UniqueID(prefix string, length int) string {
+	return m.UniqueIDWithContext(context.Background(), prefix, length)
+}
+
+// UniqueIDWithContext 获取唯一 id，ctx 处于 orm.Transaction 中时随事务回滚
+func (m *IDManager) UniqueIDWithContext(ctx context.Context, prefix string, length int) string {
 	cost := hook.UseTiming(time.Second)
 	defer cost()
 
@@ -54,7 +59,7 @@ func (m *IDManager) UniqueID(prefix string, length int) string {
 		// 生成自定义长度随机数，通过数据库主键来防止碰撞，碰撞后再次尝试
 		for range 36 {
 			id := prefix + GenerateRandomString(m.letterBytes, length+i)
-			if err := m.store.Create(context.Background(), &UniqueID{ID: id}); err != nil {
+			if err := m.store.Create(ctx, &UniqueID{ID: id}); err != nil {
 				slog.Error("UniqueID", "err", err)
 				continue
 			}
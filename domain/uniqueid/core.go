@@ -1,6 +1,8 @@
 // Code generated by godddx, DO AVOID EDIT.
 package uniqueid
 
+import "context"
+
 // Storer data persistence
 type Storer interface {
 	UniqueID() UniqueIDStorer
@@ -28,6 +30,11 @@ func (c Core) UniqueID(prefix string) string {
 	return c.m.UniqueID(prefix, c.length)
 }
 
+// UniqueIDWithContext 获取全局唯一 ID，ctx 处于 orm.Transaction 中时随事务回滚
+func (c Core) UniqueIDWithContext(ctx context.Context, prefix string) string {
+	return c.m.UniqueIDWithContext(ctx, prefix, c.length)
+}
+
 // UniqueIDByCustomLen 获取自定义长度的全局 id
 func (c Core) UniqueIDWithCustomLen(prefix string, length int) string {
 	return c.m.UniqueID(prefix, length)
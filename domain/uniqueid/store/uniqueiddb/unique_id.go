@@ -31,7 +31,13 @@ func (d UniqueID) Get(ctx context.Context, model *uniqueid.UniqueID, opts ...orm
 
 // Add implements uniqueid.UniqueIDStorer.
 func (d UniqueID) Create(ctx context.Context, model *uniqueid.UniqueID) error {
-	return d.db.WithContext(ctx).Create(model).Error
+	// 主键冲突是预期内的错误，postgres 事务中语句失败后整个事务不可用，使用保存点隔离
+	if orm.InTransaction(ctx) {
+		return orm.Conn(ctx, d.db).Transaction(func(tx *gorm.DB) error {
+			return tx.Create(model).Error
+		})
+	}
+	return orm.Conn(ctx, d.db).Create(model).Error
 }
 
 // Edit implements uniqueid.UniqueIDStorer.
@@ -45,7 +51,8 @@ func (d UniqueID) Delete(ctx context.Context, model *uniqueid.UniqueID, opts ...
 }
 
 func (d UniqueID) Session(ctx context.Context, changeFns ...func(*gorm.DB) error) error {
-	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return orm.Transaction(ctx, d.db, func(ctx context.Context) error {
+		tx := orm.Conn(ctx, d.db)
 		for _, fn := range changeFns {
 			if err := fn(tx); err != nil {
 				return err
@@ -1,14 +1,17 @@
 package version
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
+	"strconv"
 )
 
 // Storer ...
 type Storer interface {
 	First(*Version) error
 	Add(*Version) error
+	MigrationStorer
 }
 
 // Core 控制程序启动时是否执行表迁移
@@ -16,30 +19,53 @@ type Storer interface {
 type Core struct {
 	store     Storer
 	IsMigrate *bool
+	migrator  *Migrator
 }
 
-// NewCore ...
-func NewCore(store Storer) Core {
+// NewCore migrations 为版本化迁移，在 AutoMigrate 之后执行
+func NewCore(store Storer, migrations ...Migration) Core {
 	var isMigrate bool
 	return Core{
 		store:     store,
 		IsMigrate: &isMigrate,
+		migrator:  NewMigrator(store, migrations...),
 	}
 }
 
+// Migrator 版本化迁移，提供 status/up/down 操作
+func (c Core) Migrator() *Migrator {
+	return c.migrator
+}
+
+// ErrDowngrade 程序版本低于数据库记录的版本
+// 旧版本程序不认识新版本的表结构，继续运行可能损坏数据
+var ErrDowngrade = errors.New("程序版本低于数据库版本")
+
 // IsAutoMigrate 是否需要进行表迁移?
-func (c Core) IsAutoMigrate(currentVer string) bool {
+// 程序版本低于数据库记录的版本时返回 ErrDowngrade，应当拒绝启动
+func (c Core) IsAutoMigrate(currentVer string) (bool, error) {
 	var ver Version
 	if err := c.store.First(&ver); err != nil {
-		isMigrate := true
-		*c.IsMigrate = isMigrate
-		return isMigrate
+		*c.IsMigrate = true
+		return true, nil
+	}
+	cur, err := ParseSemVer(currentVer)
+	if err != nil {
+		return false, err
+	}
+	last, err := ParseSemVer(ver.Version)
+	if err != nil {
+		// 历史记录无法解析时，以当前版本重新迁移
+		slog.Warn("IsAutoMigrate", "version", ver.Version, "err", err)
+		*c.IsMigrate = true
+		return true, nil
 	}
-	isMigrate := CompareVersionFunc(currentVer, ver.Version, func(a, b string) bool {
-		return a > b
-	})
-	*c.IsMigrate = isMigrate
-	return isMigrate
+	result := cur.Compare(last)
+	if result < 0 {
+		return false, fmt.Errorf("%w current[%s] database[%s]", ErrDowngrade, currentVer, ver.Version)
+	}
+	*c.IsMigrate = result > 0
+	return *c.IsMigrate, nil
 }
 
 // RecordVersion 记录当前版本号
@@ -50,24 +76,13 @@ func (c Core) RecordVersion(currentVer, remark string) error {
 	return c.store.Add(&ver)
 }
 
-// CompareVersionFunc 比较版本号
+// CompareVersionFunc 比较版本号，f 按字典序比较两个字符串，例如 func(a, b string) bool { return a > b }
+// 内部使用 CompareVersion，比较结果映射为等长的字符串传给 f；版本号无法解析时返回 true
+// Deprecated: 请使用 CompareVersion
 func CompareVersionFunc(a, b string, f func(a, b string) bool) bool {
-	s1 := versionToStr(a)
-	s2 := versionToStr(b)
-	if len(s1) != len(s2) {
+	c, err := CompareVersion(a, b)
+	if err != nil {
 		return true
 	}
-	return f(s1, s2)
-}
-
-func versionToStr(str string) string {
-	var result strings.Builder
-	arr := strings.SplitSeq(strings.TrimPrefix(str, "v"), ".")
-	for item := range arr {
-		if idx := strings.Index(item, "-"); idx != -1 {
-			item = item[0:idx]
-		}
-		result.WriteString(fmt.Sprintf("%03s", item))
-	}
-	return result.String()
+	return f(strconv.Itoa(c+1), "1")
 }
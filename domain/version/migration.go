@@ -0,0 +1,271 @@
+package version
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库方言，与 gorm.Dialector.Name() 一致
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// MigrationStorer 迁移记录持久化
+type MigrationStorer interface {
+	// Dialect 数据库方言
+	Dialect() string
+	// WithLock 持有迁移锁执行 fn，防止多副本同时迁移
+	WithLock(ctx context.Context, fn func(context.Context) error) error
+	// ListApplied 已执行的迁移，按版本号升序
+	ListApplied(ctx context.Context) ([]SchemaMigration, error)
+	// Apply 在同一事务中执行迁移并写入(up)或删除(down)记录
+	Apply(ctx context.Context, m *Migration, up bool) error
+}
+
+// MigrateFunc 使用 Go 代码实现的迁移步骤，tx 为当前事务
+type MigrateFunc func(ctx context.Context, tx *gorm.DB) error
+
+// Migration 一个迁移步骤
+// SQL 与 Go 函数二选一，同时存在时 Go 函数优先
+type Migration struct {
+	Version int64  // 版本号，升序执行，例如 1 或 20250811120000
+	Name    string // 迁移说明
+	UpSQL   string
+	DownSQL string
+	Up      MigrateFunc
+	Down    MigrateFunc
+}
+
+// Checksum 迁移内容摘要，已执行的迁移被修改时用于发现差异
+func (m *Migration) Checksum() string {
+	content := m.UpSQL
+	if m.Up != nil {
+		content = "go:" + m.Name
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version    int64    `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name       string   `gorm:"notNull;default:''" json:"name"`
+	Checksum   string   `gorm:"notNull;default:''" json:"checksum"`
+	DurationMs int64    `gorm:"notNull;default:0" json:"duration_ms"`
+	AppliedAt  orm.Time `gorm:"notNull;default:CURRENT_TIMESTAMP" json:"applied_at"`
+}
+
+// TableName ...
+func (*SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   int64    `json:"version"`
+	Name      string   `json:"name"`
+	Applied   bool     `json:"applied"`    // 是否已执行
+	AppliedAt orm.Time `json:"applied_at"` // 执行时间
+	Modified  bool     `json:"modified"`   // 执行后内容被修改
+	Missing   bool     `json:"missing"`    // 数据库有记录，但代码中已不存在
+}
+
+// LoadMigrations 从 fsys 的 dialect 目录加载 SQL 迁移
+// 文件命名规则为 {version}_{name}.up.sql 与 {version}_{name}.down.sql，down 文件可省略
+func LoadMigrations(fsys fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dialect)
+	if err != nil {
+		return nil, err
+	}
+	cache := make(map[int64]*Migration, len(entries))
+	for _, entry := range entries {
+		filename := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(filename, ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(filename, ".sql")
+		var up bool
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			up, base = true, strings.TrimSuffix(base, ".up")
+		case strings.HasSuffix(base, ".down"):
+			base = strings.TrimSuffix(base, ".down")
+		default:
+			return nil, fmt.Errorf("migration %s: 文件名应以 .up.sql 或 .down.sql 结尾", filename)
+		}
+		ver, name, _ := strings.Cut(base, "_")
+		v, err := strconv.ParseInt(ver, 10, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("migration %s: 版本号必须是正整数", filename)
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dialect, filename))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := cache[v]
+		if !ok {
+			m = &Migration{Version: v, Name: name}
+			cache[v] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %s: 版本号 %d 重复", filename, v)
+		}
+		if up {
+			m.UpSQL = string(b)
+		} else {
+			m.DownSQL = string(b)
+		}
+	}
+	out := make([]Migration, 0, len(cache))
+	for _, m := range cache {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s: 缺少 up 文件", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	slices.SortFunc(out, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return out, nil
+}
+
+// Migrator 按版本号顺序执行迁移
+type Migrator struct {
+	store      MigrationStorer
+	migrations []Migration
+}
+
+// NewMigrator migrations 可来自 LoadMigrations 或手写的 Go 函数
+func NewMigrator(store MigrationStorer, migrations ...Migration) *Migrator {
+	ms := slices.Clone(migrations)
+	slices.SortStableFunc(ms, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return &Migrator{store: store, migrations: ms}
+}
+
+func (m *Migrator) valid() error {
+	for i := range m.migrations {
+		cur := &m.migrations[i]
+		if cur.Version <= 0 {
+			return fmt.Errorf("migration %s: 版本号必须是正整数", cur.Name)
+		}
+		if i > 0 && m.migrations[i-1].Version == cur.Version {
+			return fmt.Errorf("migration %d: 版本号重复", cur.Version)
+		}
+		if cur.Up == nil && cur.UpSQL == "" {
+			return fmt.Errorf("migration %d: 缺少 up", cur.Version)
+		}
+	}
+	return nil
+}
+
+// Status 查询所有迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.valid(); err != nil {
+		return nil, err
+	}
+	applied, err := m.store.ListApplied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[int64]SchemaMigration, len(applied))
+	for _, v := range applied {
+		records[v.Version] = v
+	}
+
+	out := make([]MigrationStatus, 0, len(m.migrations)+len(applied))
+	for i := range m.migrations {
+		mi := &m.migrations[i]
+		s := MigrationStatus{Version: mi.Version, Name: mi.Name}
+		if r, ok := records[mi.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = r.AppliedAt
+			s.Modified = r.Checksum != mi.Checksum()
+			delete(records, mi.Version)
+		}
+		out = append(out, s)
+	}
+	for _, r := range records {
+		out = append(out, MigrationStatus{Version: r.Version, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt, Missing: true})
+	}
+	slices.SortFunc(out, func(a, b MigrationStatus) int { return cmp.Compare(a.Version, b.Version) })
+	return out, nil
+}
+
+// Up 执行未执行的迁移，直到 target 版本(含)，target<=0 表示全部
+// 已执行的迁移被修改时拒绝执行
+func (m *Migrator) Up(ctx context.Context, target int64) ([]int64, error) {
+	var done []int64
+	err := m.store.WithLock(ctx, func(ctx context.Context) error {
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		applied := make(map[int64]bool, len(status))
+		for _, s := range status {
+			if s.Modified {
+				return fmt.Errorf("migration %d_%s: 执行后内容被修改", s.Version, s.Name)
+			}
+			applied[s.Version] = s.Applied
+		}
+		for i := range m.migrations {
+			mi := &m.migrations[i]
+			if target > 0 && mi.Version > target {
+				break
+			}
+			if applied[mi.Version] {
+				continue
+			}
+			now := time.Now()
+			if err := m.store.Apply(ctx, mi, true); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mi.Version, mi.Name, err)
+			}
+			slog.InfoContext(ctx, "migration up", "version", mi.Version, "name", mi.Name, "cost", time.Since(now))
+			done = append(done, mi.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本号倒序回滚 steps 个已执行的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int64, error) {
+	var done []int64
+	err := m.store.WithLock(ctx, func(ctx context.Context) error {
+		if err := m.valid(); err != nil {
+			return err
+		}
+		applied, err := m.store.ListApplied(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(applied) - 1; i >= 0 && len(done) < steps; i-- {
+			idx := slices.IndexFunc(m.migrations, func(mi Migration) bool { return mi.Version == applied[i].Version })
+			if idx == -1 {
+				return fmt.Errorf("migration %d_%s: 代码中不存在，无法回滚", applied[i].Version, applied[i].Name)
+			}
+			mi := &m.migrations[idx]
+			if mi.Down == nil && mi.DownSQL == "" {
+				return fmt.Errorf("migration %d_%s: 缺少 down，无法回滚", mi.Version, mi.Name)
+			}
+			if err := m.store.Apply(ctx, mi, false); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mi.Version, mi.Name, err)
+			}
+			slog.InfoContext(ctx, "migration down", "version", mi.Version, "name", mi.Name)
+			done = append(done, mi.Version)
+		}
+		return nil
+	})
+	return done, err
+}
@@ -0,0 +1,154 @@
+package version_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/domain/version"
+	"github.com/ixugo/goddd/domain/version/store/versiondb"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) (*gorm.DB, versiondb.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	locker, err := orm.NewLocker(db, orm.LockConfig{RetryInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, versiondb.NewDB(db, locker)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sqlite/0002_add_age.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN age INTEGER")},
+		"sqlite/0001_users.up.sql":        {Data: []byte("CREATE TABLE users (id INTEGER)")},
+		"sqlite/0001_users.down.sql":      {Data: []byte("DROP TABLE users")},
+		"sqlite/README.md":                {Data: []byte("ignored")},
+		"postgres/0001_users.up.sql":      {Data: []byte("CREATE TABLE users (id BIGINT)")},
+		"postgres/0003_other.up.sql":      {Data: []byte("SELECT 1")},
+		"postgres/0003_other.down.sql":    {Data: []byte("SELECT 1")},
+		"postgres/0003_other.extra.sql.x": {Data: []byte("ignored")},
+	}
+	ms, err := version.LoadMigrations(fsys, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 2 || ms[0].Version != 1 || ms[1].Version != 2 {
+		t.Fatalf("expect sorted [1 2], got %+v", ms)
+	}
+	if ms[0].Name != "users" || ms[0].DownSQL != "DROP TABLE users" || ms[1].Name != "add_age" || ms[1].DownSQL != "" {
+		t.Fatalf("unexpected %+v", ms)
+	}
+
+	for name, files := range map[string]fstest.MapFS{
+		"missing up":   {"d/0001_a.down.sql": {}},
+		"bad version":  {"d/v1_a.up.sql": {}},
+		"zero version": {"d/0_a.up.sql": {}},
+		"bad suffix":   {"d/0001_a.sql": {}},
+		"duplicate":    {"d/0001_a.up.sql": {}, "d/0001_b.up.sql": {}},
+	} {
+		if _, err := version.LoadMigrations(files, "d"); err == nil {
+			t.Fatalf("%s: expect error", name)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, store := newTestStore(t)
+	ctx := context.Background()
+	migrations := []version.Migration{
+		// 乱序传入，按版本号执行，2 依赖 1 创建的表
+		{Version: 2, Name: "add_age", UpSQL: "ALTER TABLE users ADD COLUMN age INTEGER", DownSQL: "ALTER TABLE users DROP COLUMN age"},
+		{Version: 1, Name: "users", UpSQL: "CREATE TABLE users (id INTEGER)", DownSQL: "DROP TABLE users"},
+		{Version: 3, Name: "seed", Up: func(_ context.Context, tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO users (id, age) VALUES (1, 18)").Error
+		}, Down: func(_ context.Context, tx *gorm.DB) error {
+			return tx.Exec("DELETE FROM users").Error
+		}},
+	}
+	m := version.NewMigrator(store, migrations...)
+
+	done, err := m.Up(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(done, []int64{1, 2}) {
+		t.Fatalf("expect [1 2], got %v", done)
+	}
+	if done, err = m.Up(ctx, 0); err != nil || !slices.Equal(done, []int64{3}) {
+		t.Fatalf("expect [3], got %v %v", done, err)
+	}
+	var count int64
+	db.Table("users").Where("age = 18").Count(&count)
+	if count != 1 {
+		t.Fatalf("expect seeded row, got %d", count)
+	}
+
+	// 倒序回滚
+	if done, err = m.Down(ctx, 2); err != nil || !slices.Equal(done, []int64{3, 2}) {
+		t.Fatalf("expect [3 2], got %v %v", done, err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Applied || status[1].Applied || status[2].Applied {
+		t.Fatalf("unexpected %+v", status)
+	}
+
+	// 已执行的迁移被修改时拒绝执行
+	migrations[1].UpSQL = "CREATE TABLE users (id BIGINT)"
+	m = version.NewMigrator(store, migrations...)
+	if status, _ := m.Status(ctx); !status[0].Modified {
+		t.Fatalf("expect modified, got %+v", status[0])
+	}
+	if _, err := m.Up(ctx, 0); err == nil || !strings.Contains(err.Error(), "被修改") {
+		t.Fatalf("expect refused, got %v", err)
+	}
+	if status, _ := m.Status(ctx); status[1].Applied {
+		t.Fatal("expect nothing applied")
+	}
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	_, store := newTestStore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var running, overlap atomic.Int32
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.WithLock(ctx, func(context.Context) error {
+				if running.Add(1) > 1 {
+					overlap.Add(1)
+				}
+				time.Sleep(20 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if overlap.Load() != 0 {
+		t.Fatal("expect exclusive")
+	}
+}
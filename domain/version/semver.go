@@ -0,0 +1,186 @@
+package version
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SemVer 语义化版本 2.0
+// https://semver.org/lang/zh-CN/
+type SemVer struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	PreRelease []string // 先行版本号，例如 1.0.0-rc.1 为 [rc 1]
+	Build      string   // 编译信息，不参与比较
+}
+
+// ParseSemVer 解析版本号
+// 兼容 v 前缀，以及省略次版本号与修订号的写法，例如 v1.2 等同 1.2.0
+func ParseSemVer(s string) (SemVer, error) {
+	var v SemVer
+	raw := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return v, fmt.Errorf("semver %q: 版本号为空", raw)
+	}
+
+	s, v.Build, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("semver %q: 最多包含主版本号、次版本号、修订号", raw)
+	}
+	nums := [3]uint64{}
+	for i, p := range parts {
+		n, err := parseNumeric(p)
+		if err != nil {
+			return v, fmt.Errorf("semver %q: %w", raw, err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+
+	if hasPre {
+		if pre == "" {
+			return v, fmt.Errorf("semver %q: 先行版本号为空", raw)
+		}
+		v.PreRelease = strings.Split(pre, ".")
+		for _, id := range v.PreRelease {
+			if err := validIdentifier(id); err != nil {
+				return v, fmt.Errorf("semver %q: %w", raw, err)
+			}
+			if isNumeric(id) && len(id) > 1 && id[0] == '0' {
+				return v, fmt.Errorf("semver %q: 先行版本号 %q 不能有前导零", raw, id)
+			}
+		}
+	}
+	if v.Build != "" {
+		for id := range strings.SplitSeq(v.Build, ".") {
+			if err := validIdentifier(id); err != nil {
+				return v, fmt.Errorf("semver %q: %w", raw, err)
+			}
+		}
+	}
+	return v, nil
+}
+
+// MustParseSemVer 解析失败时 panic，适用于硬编码的版本号
+func MustParseSemVer(s string) SemVer {
+	v, err := ParseSemVer(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String 规范化输出，不带 v 前缀
+func (v SemVer) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.PreRelease) > 0 {
+		b.WriteString("-" + strings.Join(v.PreRelease, "."))
+	}
+	if v.Build != "" {
+		b.WriteString("+" + v.Build)
+	}
+	return b.String()
+}
+
+// Compare 比较优先级，v<o 返回 -1，v==o 返回 0，v>o 返回 1
+// 编译信息不参与比较
+func (v SemVer) Compare(o SemVer) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	// 正式版本优先级高于先行版本
+	switch {
+	case len(v.PreRelease) == 0 && len(o.PreRelease) == 0:
+		return 0
+	case len(v.PreRelease) == 0:
+		return 1
+	case len(o.PreRelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.PreRelease) && i < len(o.PreRelease); i++ {
+		if c := compareIdentifier(v.PreRelease[i], o.PreRelease[i]); c != 0 {
+			return c
+		}
+	}
+	// 前面的标识符都相同时，字段多的优先级高
+	return cmp.Compare(len(v.PreRelease), len(o.PreRelease))
+}
+
+// CompareVersion 比较两个版本号字符串
+func CompareVersion(a, b string) (int, error) {
+	va, err := ParseSemVer(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseSemVer(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// compareIdentifier 纯数字按数值比较，否则按 ASCII 排序，数字的优先级低于非数字
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		// 长度不同时，长的数值大，避免溢出
+		if c := cmp.Compare(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func parseNumeric(s string) (uint64, error) {
+	if !isNumeric(s) {
+		return 0, fmt.Errorf("%q 不是数字", s)
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("%q 不能有前导零", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func validIdentifier(s string) error {
+	if s == "" {
+		return fmt.Errorf("标识符不能为空")
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-') {
+			return fmt.Errorf("标识符 %q 只能包含 [0-9A-Za-z-]", s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package version
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseSemVer(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{name: "完整版本", input: "1.2.3", expect: "1.2.3"},
+		{name: "v 前缀", input: "v1.2.3", expect: "1.2.3"},
+		{name: "省略修订号", input: "1.2", expect: "1.2.0"},
+		{name: "省略次版本号", input: "v1", expect: "1.0.0"},
+		{name: "先行版本", input: "1.0.0-rc.1", expect: "1.0.0-rc.1"},
+		{name: "先行版本包含连字符", input: "1.0.0-x-y.1", expect: "1.0.0-x-y.1"},
+		{name: "编译信息", input: "1.0.0-beta+exp.sha.5114f85", expect: "1.0.0-beta+exp.sha.5114f85"},
+		{name: "大数字", input: "1000.20000.300000", expect: "1000.20000.300000"},
+		{name: "空串", input: "", wantErr: true},
+		{name: "非数字", input: "1.a.0", wantErr: true},
+		{name: "前导零", input: "01.0.0", wantErr: true},
+		{name: "先行版本前导零", input: "1.0.0-01", wantErr: true},
+		{name: "段数过多", input: "1.2.3.4", wantErr: true},
+		{name: "先行版本为空", input: "1.0.0-", wantErr: true},
+		{name: "非法字符", input: "1.0.0-rc_1", wantErr: true},
+		{name: "编译信息为空段", input: "1.0.0+a..b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := ParseSemVer(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expect error, got %s", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.String() != tt.expect {
+				t.Fatalf("expect %s, got %s", tt.expect, v)
+			}
+		})
+	}
+}
+
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		expect int
+	}{
+		{"1.2", "1.2.0", 0},
+		{"v1.2.0", "1.2.0", 0},
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"1.0.1", "1.0.0", 1},
+		{"1.1.0", "1.0.9", 1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.1000", "1.0.999", 1},
+		{"1000.0.0", "999.0.0", 1},
+		{"1.0.0", "1.0.0-rc1", 1},
+		{"1.0.0-rc1", "1.0.0", -1},
+		// semver.org 规范中给出的先行版本优先级示例
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta.11", "1.0.0-rc.1", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			got, err := CompareVersion(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect {
+				t.Fatalf("expect %d, got %d", tt.expect, got)
+			}
+			// 交换位置，结果取反
+			got, _ = CompareVersion(tt.b, tt.a)
+			if got != -tt.expect {
+				t.Fatalf("reverse expect %d, got %d", -tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestCompareVersionFunc(t *testing.T) {
+	gt := func(a, b string) bool { return a > b }
+	eq := func(a, b string) bool { return a == b }
+	// 段数不同时按版本号比较，不再总是返回 true
+	if CompareVersionFunc("1.2", "1.2.1", gt) || !CompareVersionFunc("1.2", "1.2.0", eq) {
+		t.Fatal("expect compare by semver")
+	}
+	if !CompareVersionFunc("1.0.1000", "1.0.999", gt) || CompareVersionFunc("1.0.0-rc1", "1.0.0", gt) {
+		t.Fatal("expect compare by semver")
+	}
+}
+
+type memStore struct {
+	versions []Version
+}
+
+func (m *memStore) First(v *Version) error {
+	if len(m.versions) == 0 {
+		return errors.New("record not found")
+	}
+	*v = m.versions[len(m.versions)-1]
+	return nil
+}
+
+func (m *memStore) Add(v *Version) error {
+	m.versions = append(m.versions, *v)
+	return nil
+}
+
+func (*memStore) Dialect() string { return DialectSQLite }
+
+func (*memStore) WithLock(ctx context.Context, fn func(context.Context) error) error { return fn(ctx) }
+
+func (*memStore) ListApplied(context.Context) ([]SchemaMigration, error) { return nil, nil }
+
+func (*memStore) Apply(context.Context, *Migration, bool) error { return nil }
+
+func TestIsAutoMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  string // 数据库中最后一次记录的版本，空串表示没有记录
+		current string
+		expect  bool
+		wantErr error
+	}{
+		{name: "首次启动", record: "", current: "0.0.1", expect: true},
+		{name: "版本相同", record: "1.2.0", current: "1.2.0", expect: false},
+		{name: "省略修订号视为相同", record: "1.2.0", current: "v1.2", expect: false},
+		{name: "编译信息不同视为相同", record: "1.2.0+a", current: "1.2.0+b", expect: false},
+		{name: "升级", record: "1.2.0", current: "1.2.1", expect: true},
+		{name: "大版本号升级", record: "v1.0.999", current: "v1.0.1000", expect: true},
+		{name: "先行版本升级为正式版", record: "1.0.0-rc1", current: "1.0.0", expect: true},
+		{name: "降级", record: "1.3.0", current: "1.2.9", wantErr: ErrDowngrade},
+		{name: "正式版降级为先行版", record: "1.0.0", current: "1.0.0-rc1", wantErr: ErrDowngrade},
+		{name: "大版本号降级", record: "1.0.1000", current: "1.0.999", wantErr: ErrDowngrade},
+		{name: "历史记录无法解析", record: "debug", current: "1.0.0", expect: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var store memStore
+			if tt.record != "" {
+				_ = store.Add(&Version{Version: tt.record})
+			}
+			core := NewCore(&store)
+			got, err := core.IsAutoMigrate(tt.current)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expect %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expect || *core.IsMigrate != tt.expect {
+				t.Fatalf("expect %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
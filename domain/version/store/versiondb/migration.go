@@ -0,0 +1,92 @@
+package versiondb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ixugo/goddd/domain/version"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+var _ version.MigrationStorer = DB{}
+
+// migrationLockKey 与 orm.Locker 的其它锁共用同一套租约，持有期间自动续期
+const migrationLockKey = "version.migrate"
+
+// Dialect implements version.MigrationStorer.
+func (d DB) Dialect() string {
+	return d.db.Dialector.Name()
+}
+
+// WithLock implements version.MigrationStorer.
+// 租约丢失时取消 fn 的 ctx，未提交的迁移随事务回滚
+func (d DB) WithLock(ctx context.Context, fn func(context.Context) error) error {
+	if err := d.db.WithContext(ctx).AutoMigrate(new(version.SchemaMigration)); err != nil {
+		return err
+	}
+	lock, err := d.locker.Lock(ctx, migrationLockKey)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lock.Unlock(context.WithoutCancel(ctx)); err != nil {
+			slog.ErrorContext(ctx, "migration unlock", "err", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-lock.Lost():
+			cancel(orm.ErrLockLost)
+		case <-ctx.Done():
+		}
+	}()
+	return fn(ctx)
+}
+
+// ListApplied implements version.MigrationStorer.
+func (d DB) ListApplied(ctx context.Context) ([]version.SchemaMigration, error) {
+	var out []version.SchemaMigration
+	// 副本可能延迟，读到旧记录会重复执行迁移
+	err := orm.Primary()(d.db.WithContext(ctx)).Order("version ASC").Find(&out).Error
+	return out, err
+}
+
+// Apply implements version.MigrationStorer.
+func (d DB) Apply(ctx context.Context, m *version.Migration, up bool) error {
+	now := time.Now()
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		fn, sql := m.Down, m.DownSQL
+		if up {
+			fn, sql = m.Up, m.UpSQL
+		}
+		switch {
+		case fn != nil:
+			if err := fn(ctx, tx); err != nil {
+				return err
+			}
+		case sql != "":
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		default:
+			return errors.New("empty migration")
+		}
+
+		if !up {
+			return tx.Delete(new(version.SchemaMigration), "version = ?", m.Version).Error
+		}
+		return tx.Create(&version.SchemaMigration{
+			Version:    m.Version,
+			Name:       m.Name,
+			Checksum:   m.Checksum(),
+			DurationMs: time.Since(now).Milliseconds(),
+			AppliedAt:  orm.Now(),
+		}).Error
+	})
+}
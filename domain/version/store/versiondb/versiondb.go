@@ -2,17 +2,19 @@ package versiondb
 
 import (
 	"github.com/ixugo/goddd/domain/version"
+	"github.com/ixugo/goddd/pkg/orm"
 	"gorm.io/gorm"
 )
 
 // DB ...
 type DB struct {
-	db *gorm.DB
+	db     *gorm.DB
+	locker *orm.Locker
 }
 
-// NewDB ...
-func NewDB(db *gorm.DB) DB {
-	return DB{db: db}
+// NewDB locker 用于迁移期间互斥，与其它分布式锁共用
+func NewDB(db *gorm.DB, locker *orm.Locker) DB {
+	return DB{db: db, locker: locker}
 }
 
 // AutoMigrate ...
@@ -30,7 +32,7 @@ func (d DB) AutoMigrate(ok bool) DB {
 
 // First ...
 func (d DB) First(v *version.Version) error {
-	return d.db.Order("id DESC").First(v).Error
+	return orm.Primary()(d.db).Order("id DESC").First(v).Error
 }
 
 // Add ...
@@ -1,7 +1,10 @@
 package versionapi
 
 import (
+	"context"
+	"embed"
 	"log/slog"
+	"time"
 
 	"github.com/ixugo/goddd/domain/version"
 	"github.com/ixugo/goddd/domain/version/store/versiondb"
@@ -15,15 +18,52 @@ var (
 	DBRemark  = "debug"
 )
 
-// NewVersionCore ...
-func NewVersionCore(db *gorm.DB) version.Core {
-	vdb := versiondb.NewDB(db)
-	core := version.NewCore(vdb)
-	isOK := core.IsAutoMigrate(DBVersion)
+// migrationFS 按方言存放的 SQL 迁移文件
+// 目录结构为 migrations/{sqlite,postgres}/{version}_{name}.{up,down}.sql
+//
+//go:embed migrations
+var migrationFS embed.FS
+
+// goMigrations 使用 Go 函数实现的迁移
+var goMigrations []version.Migration
+
+// RegisterMigration 注册 Go 函数实现的迁移，应当在 NewVersionCore 之前调用
+// 版本号与 SQL 迁移共用，不可重复
+func RegisterMigration(m ...version.Migration) {
+	goMigrations = append(goMigrations, m...)
+}
+
+// autoMigrateLock 表迁移期间持有，RecordVersion 后释放
+var autoMigrateLock *orm.Lock
+
+// NewVersionCore 多副本同时启动时，只有一个副本执行表迁移
+// 其它副本等待迁移完成后再检查版本号，此时版本号已更新，不再重复迁移
+func NewVersionCore(db *gorm.DB, locker *orm.Locker) version.Core {
+	vdb := versiondb.NewDB(db, locker)
+	migrations, err := version.LoadMigrations(migrationFS, "migrations/"+vdb.Dialect())
+	if err != nil {
+		panic(err)
+	}
+	core := version.NewCore(vdb, append(migrations, goMigrations...)...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	lock, err := locker.Lock(ctx, "version.auto_migrate")
+	if err != nil {
+		panic(err)
+	}
+	isOK, err := core.IsAutoMigrate(DBVersion)
+	if err != nil {
+		lock.Unlock(context.Background())
+		panic(err)
+	}
 	vdb.AutoMigrate(isOK)
-	if isOK {
-		slog.Info("更新数据库表结构")
-		orm.SetEnabledAutoMigrate(true)
+	if !isOK {
+		lock.Unlock(context.Background())
+		return core
 	}
+	slog.Info("更新数据库表结构")
+	orm.SetEnabledAutoMigrate(true)
+	autoMigrateLock = lock
 	return core
 }
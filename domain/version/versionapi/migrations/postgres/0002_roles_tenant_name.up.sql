@@ -0,0 +1,2 @@
+-- 角色名称改为同一租户内唯一，新索引 idx_roles_tenant_name 由表迁移创建
+DROP INDEX IF EXISTS idx_roles_name;
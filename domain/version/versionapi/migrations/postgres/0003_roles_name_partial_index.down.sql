@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_roles_tenant_name;
+CREATE UNIQUE INDEX idx_roles_tenant_name ON roles (tenant_id, name);
@@ -0,0 +1,3 @@
+-- 角色名称只在未删除的角色中唯一，表迁移不会替换已存在的同名索引，需要重建
+DROP INDEX IF EXISTS idx_roles_tenant_name;
+CREATE UNIQUE INDEX idx_roles_tenant_name ON roles (tenant_id, name) WHERE deleted_at IS NULL;
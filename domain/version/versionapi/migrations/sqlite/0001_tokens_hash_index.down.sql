@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_tokens_hash;
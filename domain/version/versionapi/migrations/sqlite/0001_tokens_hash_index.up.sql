@@ -0,0 +1,2 @@
+-- refresh token 通过 hash 查询，且 hash 必须唯一
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tokens_hash ON tokens (hash);
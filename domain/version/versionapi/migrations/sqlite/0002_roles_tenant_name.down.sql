@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_roles_tenant_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
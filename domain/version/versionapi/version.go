@@ -1,11 +1,14 @@
 package versionapi
 
 import (
+	"context"
 	"log/slog"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/ixugo/goddd/domain/version"
 	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
 	"github.com/ixugo/goddd/pkg/web"
 )
 
@@ -20,7 +23,12 @@ func New(ver version.Core) API {
 func Register(r gin.IRouter, verAPI API, handler ...gin.HandlerFunc) {
 	{
 		group := r.Group("/version", handler...)
-		group.GET("", web.WrapH(verAPI.getVersion))
+		web.Handle(group, http.MethodGet, "", verAPI.getVersion)
+		web.Handle(group, http.MethodGet, "/migrations", verAPI.listMigrations)
+		web.CustomEndpoints(group, "/migrations", map[string]web.Endpoint{
+			"up":   web.NewEndpoint(verAPI.upMigrations),
+			"down": web.NewEndpoint(verAPI.downMigrations),
+		})
 	}
 }
 
@@ -28,6 +36,45 @@ func (v API) getVersion(_ *gin.Context, _ *struct{}) (any, error) {
 	return gin.H{"version": DBVersion, "remark": DBRemark}, nil
 }
 
+func (v API) listMigrations(c *gin.Context, _ *struct{}) (gin.H, error) {
+	items, err := v.versionCore.Migrator().Status(c.Request.Context())
+	if err != nil {
+		return nil, reason.ErrDB.Withf("Status err[%s]", err.Error())
+	}
+	return gin.H{"items": items}, nil
+}
+
+type upMigrationsInput struct {
+	Target int64 `json:"target"` // 迁移到指定版本(含)，0 表示全部
+}
+
+func (v API) upMigrations(c *gin.Context, in *upMigrationsInput) (gin.H, error) {
+	versions, err := v.versionCore.Migrator().Up(c.Request.Context(), in.Target)
+	if err != nil {
+		return nil, reason.ErrDB.Withf("Up err[%s]", err.Error())
+	}
+	return gin.H{"versions": versions}, nil
+}
+
+type downMigrationsInput struct {
+	Steps int `json:"steps" binding:"required,min=1"` // 回滚的步数
+}
+
+func (v API) downMigrations(c *gin.Context, in *downMigrationsInput) (gin.H, error) {
+	versions, err := v.versionCore.Migrator().Down(c.Request.Context(), in.Steps)
+	if err != nil {
+		return nil, reason.ErrDB.Withf("Down err[%s]", err.Error())
+	}
+	return gin.H{"versions": versions}, nil
+}
+
+// Migrate 执行版本化迁移，应当在所有 AutoMigrate 完成后调用
+// 迁移失败时无法保证程序正常运行，由上层决定是否退出
+func (v API) Migrate() error {
+	_, err := v.versionCore.Migrator().Up(context.Background(), 0)
+	return err
+}
+
 // RecordVersion 更新版本号，错误仅记录日志，不建议上层处理
 func (v API) RecordVersion() {
 	// 如果没有执行表迁移，则不需要更新版本号
@@ -38,4 +85,11 @@ func (v API) RecordVersion() {
 	if err := v.versionCore.RecordVersion(DBVersion, DBRemark); err != nil {
 		slog.Error("RecordVersion", "err", err)
 	}
+	// 版本号更新后，等待中的副本不再重复迁移
+	if autoMigrateLock != nil {
+		if err := autoMigrateLock.Unlock(context.Background()); err != nil {
+			slog.Error("RecordVersion unlock", "err", err)
+		}
+		autoMigrateLock = nil
+	}
 }
@@ -0,0 +1,18 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+// Storer data persistence
+type Storer interface {
+	Webhook() WebhookStorer
+	Delivery() DeliveryStorer
+}
+
+// Core business domain
+type Core struct {
+	store Storer
+}
+
+// NewCore create business domain
+func NewCore(store Storer) Core {
+	return Core{store: store}
+}
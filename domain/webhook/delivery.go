@@ -0,0 +1,40 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// DeliveryStorer Instantiation interface
+type DeliveryStorer interface {
+	List(context.Context, *[]*Delivery, orm.Pager, ...orm.QueryOption) (int64, error)
+	Create(context.Context, *Delivery) error
+	Update(context.Context, *Delivery, func(*Delivery), ...orm.QueryOption) error
+	// ListDue 到期待投递的记录，按 id 正序
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	// Claim 条件更新投递次数并延后下次投递时间，返回是否抢占成功
+	Claim(ctx context.Context, id int64, attempts int, leaseUntil time.Time) (bool, error)
+}
+
+// FindDelivery 投递日志，按时间倒序
+func (c Core) FindDelivery(ctx context.Context, webhookID int, in *FindDeliveryInput) ([]*Delivery, int64, error) {
+	query := orm.NewQuery(4).Where("webhook_id = ?", webhookID)
+	if in.Event != "" {
+		query.Where("event = ?", in.Event)
+	}
+	if in.Status != "" {
+		query.Where("status = ?", in.Status)
+	}
+	query.OrderBy("id DESC")
+
+	items := make([]*Delivery, 0, in.Limit())
+	total, err := c.store.Delivery().List(ctx, &items, in, query.Encode()...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
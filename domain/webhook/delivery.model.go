@@ -0,0 +1,34 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+import "github.com/ixugo/goddd/pkg/orm"
+
+// 投递状态
+const (
+	DeliveryPending   = "pending"   // 等待投递或重试
+	DeliverySucceeded = "succeeded" // 接收方响应 2xx
+	DeliveryFailed    = "failed"    // 超过最大投递次数或 webhook 已停用
+)
+
+// Delivery domain model
+// 投递日志，记录最后一次请求的结果
+type Delivery struct {
+	ID            int64    `gorm:"primaryKey" json:"id"`
+	WebhookID     int      `gorm:"column:webhook_id;notNull;default:0;index;comment:webhook id" json:"webhook_id"`                                           // webhook id
+	Event         string   `gorm:"column:event;notNull;default:'';comment:事件" json:"event"`                                                                  // 事件
+	Payload       string   `gorm:"column:payload;notNull;default:'';comment:请求体" json:"payload"`                                                             // 请求体
+	Status        string   `gorm:"column:status;notNull;default:'';index:idx_webhook_deliveries_status_next;comment:投递状态" json:"status"`                     // 投递状态
+	Attempts      int      `gorm:"column:attempts;notNull;default:0;comment:投递次数" json:"attempts"`                                                           // 投递次数
+	NextAttemptAt orm.Time `gorm:"column:next_attempt_at;notNull;default:CURRENT_TIMESTAMP;index:idx_webhook_deliveries_status_next" json:"next_attempt_at"` // 下次投递时间
+	ResponseCode  int      `gorm:"column:response_code;notNull;default:0;comment:响应状态码" json:"response_code"`                                                // 响应状态码
+	ResponseBody  string   `gorm:"column:response_body;notNull;default:'';comment:响应内容" json:"response_body"`                                                // 响应内容，最多保留 1KB
+	LastError     string   `gorm:"column:last_error;notNull;default:'';comment:最后一次失败原因" json:"last_error"`                                                  // 最后一次失败原因
+	DurationMs    int64    `gorm:"column:duration_ms;notNull;default:0;comment:请求耗时" json:"duration_ms"`                                                     // 请求耗时(毫秒)
+	CreatedAt     orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName database table name
+func (*Delivery) TableName() string {
+	return "webhook_deliveries"
+}
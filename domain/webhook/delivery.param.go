@@ -0,0 +1,10 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+import "github.com/ixugo/goddd/pkg/web"
+
+type FindDeliveryInput struct {
+	web.PagerFilter
+	Event  string `form:"event"`  // 事件
+	Status string `form:"status"` // 投递状态
+}
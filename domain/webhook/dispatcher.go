@@ -0,0 +1,330 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/trace"
+)
+
+// 请求头，接收方通过 Verify 校验签名
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxResponseBody 投递日志保留的响应内容长度
+const maxResponseBody = 1024
+
+// Config 投递配置，零值使用默认值
+type Config struct {
+	Workers      int           // 并发投递数量，默认 4
+	Timeout      time.Duration // 单次请求超时时间，默认 10 秒
+	MaxAttempts  int           // 最大投递次数，默认 8
+	MinBackoff   time.Duration // 首次重试间隔，之后翻倍，默认 10 秒
+	MaxBackoff   time.Duration // 最大重试间隔，默认 1 小时
+	PollInterval time.Duration // 轮询间隔，默认 1 秒
+}
+
+func (c *Config) setDefaults() {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 8
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 10 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = time.Hour
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+}
+
+// Dispatcher 投递 webhook
+// 投递记录先写入数据库，再由 Run 取出并发送，进程重启后未完成的投递会继续
+type Dispatcher struct {
+	store  Storer
+	client *http.Client
+	g      *conc.G
+	wake   chan struct{}
+
+	mu      sync.Mutex
+	cfg     Config
+	running int
+}
+
+// NewDispatcher 创建投递器
+func NewDispatcher(store Storer, cfg Config) *Dispatcher {
+	cfg.setDefaults()
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			// 投递请求记录 span 并传递 traceparent
+			Transport: trace.Transport(nil),
+			// 不跟随重定向，避免被引导到内网地址
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		g:    conc.New(nil),
+		wake: make(chan struct{}, 1),
+		cfg:  cfg,
+	}
+}
+
+// SetConfig 热更新配置，并发数减少时，正在进行的投递不受影响
+func (d *Dispatcher) SetConfig(cfg Config) {
+	cfg.setDefaults()
+	d.mu.Lock()
+	d.cfg = cfg
+	d.mu.Unlock()
+	d.notify(context.Background())
+}
+
+// Config 当前生效的配置
+func (d *Dispatcher) Config() Config {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cfg
+}
+
+// Enqueue 为订阅了 event 的 webhook 创建投递记录，ctx 处于 orm.Transaction 中时加入该事务
+// data 作为请求体的 data 字段，同一事件发给各个 webhook 的 id 相同，接收方可以据此去重
+func (d *Dispatcher) Enqueue(ctx context.Context, event string, data any) (int, error) {
+	var hooks []*Webhook
+	if _, err := d.store.Webhook().List(ctx, &hooks, nil, orm.Where("enabled = ?", true)); err != nil {
+		return 0, err
+	}
+	body, err := json.Marshal(map[string]any{
+		"id":         orm.GenerateRandomString(16),
+		"event":      event,
+		"created_at": time.Now().UnixMilli(),
+		"data":       data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	now := orm.Now()
+	for _, hook := range hooks {
+		if !hook.Events.Match(event) {
+			continue
+		}
+		if err := d.store.Delivery().Create(ctx, &Delivery{
+			WebhookID:     hook.ID,
+			Event:         event,
+			Payload:       string(body),
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if n > 0 {
+		orm.AfterCommit(ctx, d.notify)
+	}
+	return n, nil
+}
+
+func (d *Dispatcher) notify(context.Context) {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 投递到期的记录，直到 ctx 结束，退出前等待正在进行的投递
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer d.g.Wait()
+	for {
+		if err := d.dispatch(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "webhook dispatch", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-time.After(d.Config().PollInterval):
+		}
+	}
+}
+
+// dispatch 按空闲的并发数取出记录，交给 conc.G 执行
+func (d *Dispatcher) dispatch(ctx context.Context) error {
+	d.mu.Lock()
+	cfg, free := d.cfg, d.cfg.Workers-d.running
+	d.mu.Unlock()
+	if free <= 0 {
+		return nil
+	}
+
+	rows, err := d.store.Delivery().ListDue(ctx, time.Now(), free)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		// 租约覆盖请求超时，进程崩溃后到期重新投递
+		ok, err := d.store.Delivery().Claim(ctx, row.ID, row.Attempts, time.Now().Add(cfg.Timeout+30*time.Second))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		row.Attempts++
+
+		d.mu.Lock()
+		d.running++
+		d.mu.Unlock()
+		d.g.GoRun(func() {
+			defer func() {
+				d.mu.Lock()
+				d.running--
+				d.mu.Unlock()
+				d.notify(ctx)
+			}()
+			d.deliver(ctx, row)
+		})
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, row *Delivery) {
+	cfg := d.Config()
+	start := time.Now()
+
+	var hook Webhook
+	var code int
+	var body string
+	err := d.store.Webhook().Get(ctx, &hook, orm.Where("id=?", row.WebhookID))
+	final := false
+	switch {
+	case orm.IsErrRecordNotFound(err):
+		err, final = errors.New("webhook 已删除"), true
+	case err != nil:
+	case !hook.Enabled:
+		err, final = errors.New("webhook 已停用"), true
+	default:
+		code, body, err = d.send(ctx, &hook, row, cfg.Timeout)
+	}
+	// 服务关闭导致的中断不计入结果，租约到期后重新投递
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	if uerr := d.store.Delivery().Update(ctx, new(Delivery), func(b *Delivery) {
+		b.ResponseCode = code
+		b.ResponseBody = body
+		b.DurationMs = now.Sub(start).Milliseconds()
+		b.UpdatedAt = orm.Time{Time: now}
+		switch {
+		case err == nil:
+			b.Status = DeliverySucceeded
+			b.LastError = ""
+		case final || row.Attempts >= cfg.MaxAttempts:
+			b.Status = DeliveryFailed
+			b.LastError = err.Error()
+		default:
+			b.NextAttemptAt = orm.Time{Time: now.Add(backoff(cfg, row.Attempts))}
+			b.LastError = err.Error()
+		}
+	}, orm.Where("id=?", row.ID)); uerr != nil {
+		slog.ErrorContext(ctx, "webhook delivery update", "id", row.ID, "err", uerr)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "webhook delivery", "id", row.ID, "webhook_id", row.WebhookID, "attempts", row.Attempts, "err", err)
+	}
+}
+
+// send 发送请求，响应非 2xx 视为失败
+func (d *Dispatcher) send(ctx context.Context, hook *Webhook, row *Delivery, timeout time.Duration) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(row.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goddd-webhook")
+	req.Header.Set(HeaderEvent, row.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(row.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, ts, []byte(row.Payload)))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(b), fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(b), nil
+}
+
+// backoff 按投递次数翻倍
+func backoff(cfg Config, attempts int) time.Duration {
+	d := cfg.MinBackoff
+	for i := 1; i < attempts && d < cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, cfg.MaxBackoff)
+}
+
+// Sign 签名，sha256= 加 HMAC-SHA256(secret, "时间戳.请求体") 的十六进制
+func Sign(secret string, timestamp int64, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	h.Write([]byte("."))
+	h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// ErrInvalidSignature 签名不匹配或时间戳超出容忍范围
+var ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+// Verify 接收方校验签名，tolerance 为允许的时间误差，用于防止重放
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if diff := time.Since(time.Unix(ts, 0)); diff > tolerance || diff < -tolerance {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(header.Get(HeaderSignature)), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/domain/webhook"
+	"github.com/ixugo/goddd/domain/webhook/store/webhookdb"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+func newTestStore(t *testing.T) (*gorm.DB, webhookdb.DB) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db, webhookdb.NewDB(db).AutoMigrate(true)
+}
+
+func waitFor(t *testing.T, msg string, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	db, store := newTestStore(t)
+	core := webhook.NewCore(store)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	var invalid atomic.Int32
+	var secret string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if webhook.Verify(secret, r.Header, body, time.Minute) != nil || r.Header.Get(webhook.HeaderEvent) != "token.logged_out" {
+			invalid.Add(1)
+		}
+		// 首次返回失败，验证重试
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	hook, err := core.AddWebhook(ctx, &webhook.AddWebhookInput{Name: "a", URL: srv.URL, Events: webhook.Events{"token.*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret = hook.Secret
+	disabled := false
+	for _, in := range []*webhook.AddWebhookInput{
+		{Name: "other", URL: srv.URL, Events: webhook.Events{"user.*"}},
+		{Name: "disabled", URL: srv.URL, Enabled: &disabled},
+	} {
+		if _, err := core.AddWebhook(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := webhook.NewDispatcher(store, webhook.Config{MinBackoff: time.Millisecond, PollInterval: 5 * time.Millisecond})
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go d.Run(runCtx)
+
+	if err := orm.Transaction(ctx, db, func(ctx context.Context) error {
+		n, err := d.Enqueue(ctx, "token.logged_out", map[string]string{"user_id": "1"})
+		if n != 1 {
+			t.Errorf("expect 1 delivery, got %d", n)
+		}
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []*webhook.Delivery
+	waitFor(t, "delivery not succeeded", func() bool {
+		items, _, err = core.FindDelivery(ctx, hook.ID, &webhook.FindDeliveryInput{PagerFilter: web.PagerFilter{Size: 10}})
+		return err == nil && len(items) == 1 && items[0].Status == webhook.DeliverySucceeded
+	})
+	if v := items[0]; v.Attempts != 2 || v.ResponseCode != http.StatusOK || v.ResponseBody != "ok" {
+		t.Fatalf("unexpected delivery %+v", v)
+	}
+	if invalid.Load() != 0 {
+		t.Fatal("invalid signature")
+	}
+}
+
+func TestDispatcherMaxAttempts(t *testing.T) {
+	_, store := newTestStore(t)
+	core := webhook.NewCore(store)
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	hook, err := core.AddWebhook(ctx, &webhook.AddWebhookInput{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := webhook.NewDispatcher(store, webhook.Config{MaxAttempts: 3, MinBackoff: time.Millisecond, PollInterval: 5 * time.Millisecond})
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go d.Run(runCtx)
+
+	if _, err := d.Enqueue(ctx, "user.deleted", nil); err != nil {
+		t.Fatal(err)
+	}
+	var items []*webhook.Delivery
+	waitFor(t, "delivery not failed", func() bool {
+		items, _, err = core.FindDelivery(ctx, hook.ID, &webhook.FindDeliveryInput{Status: webhook.DeliveryFailed})
+		return err == nil && len(items) == 1
+	})
+	if v := items[0]; v.Attempts != 3 || v.ResponseCode != http.StatusInternalServerError || v.LastError == "" {
+		t.Fatalf("unexpected delivery %+v", v)
+	}
+}
+
+func TestDispatcherSetConfig(t *testing.T) {
+	_, store := newTestStore(t)
+	core := webhook.NewCore(store)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var inflight, peak int
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		mu.Lock()
+		inflight++
+		peak = max(peak, inflight)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	if _, err := core.AddWebhook(ctx, &webhook.AddWebhookInput{URL: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	d := webhook.NewDispatcher(store, webhook.Config{Workers: 1, PollInterval: 5 * time.Millisecond})
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go d.Run(runCtx)
+
+	for range 3 {
+		if _, err := d.Enqueue(ctx, "user.deleted", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, "expect 1 inflight", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return inflight == 1
+	})
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	if peak != 1 {
+		t.Errorf("expect peak 1, got %d", peak)
+	}
+	mu.Unlock()
+
+	// 并发数调大后立即生效
+	d.SetConfig(webhook.Config{Workers: 3})
+	if cfg := d.Config(); cfg.Workers != 3 || cfg.Timeout != 10*time.Second {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	waitFor(t, "expect 3 inflight", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return inflight == 3
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 3 {
+		t.Fatalf("expect peak 3, got %d", peak)
+	}
+}
+
+func TestEditWebhook_IfMatch(t *testing.T) {
+	_, store := newTestStore(t)
+	core := webhook.NewCore(store)
+	hook, err := core.AddWebhook(context.Background(), &webhook.AddWebhookInput{URL: "http://127.0.0.1/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.PUT("/webhooks/:id", web.WrapH(func(c *gin.Context, in *webhook.EditWebhookInput) (*webhook.Webhook, error) {
+		return core.EditWebhook(c.Request.Context(), in, hook.ID)
+	}))
+	edit := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/webhooks/1", strings.NewReader(`{"url":"http://127.0.0.1/next"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := edit(""); w.Code != http.StatusPreconditionRequired {
+		t.Fatalf("expect 428, got %d", w.Code)
+	}
+	if w := edit(`"1"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("expect 200 with ETag 2, got %d %s", w.Code, w.Header().Get("ETag"))
+	}
+	// 持有旧版本号
+	if w := edit(`"1"`); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expect 412, got %d", w.Code)
+	}
+}
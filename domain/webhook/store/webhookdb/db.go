@@ -0,0 +1,43 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhookdb
+
+import (
+	"github.com/ixugo/goddd/domain/webhook"
+	"gorm.io/gorm"
+)
+
+var _ webhook.Storer = DB{}
+
+// DB Related business namespaces
+type DB struct {
+	db *gorm.DB
+}
+
+// NewDB instance object
+func NewDB(db *gorm.DB) DB {
+	return DB{db: db}
+}
+
+// Webhook Get business instance
+func (d DB) Webhook() webhook.WebhookStorer {
+	return Webhook(d)
+}
+
+// Delivery Get business instance
+func (d DB) Delivery() webhook.DeliveryStorer {
+	return Delivery(d)
+}
+
+// AutoMigrate sync database
+func (d DB) AutoMigrate(ok bool) DB {
+	if !ok {
+		return d
+	}
+	if err := d.db.AutoMigrate(
+		new(webhook.Webhook),
+		new(webhook.Delivery),
+	); err != nil {
+		panic(err)
+	}
+	return d
+}
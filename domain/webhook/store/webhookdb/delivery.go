@@ -0,0 +1,46 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhookdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/domain/webhook"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ webhook.DeliveryStorer = Delivery{}
+
+// Delivery Related business namespaces
+type Delivery DB
+
+// List implements webhook.DeliveryStorer.
+func (d Delivery) List(ctx context.Context, bs *[]*webhook.Delivery, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Create implements webhook.DeliveryStorer.
+func (d Delivery) Create(ctx context.Context, model *webhook.Delivery) error {
+	return orm.Conn(ctx, d.db).Create(model).Error
+}
+
+// Update implements webhook.DeliveryStorer.
+func (d Delivery) Update(ctx context.Context, model *webhook.Delivery, changeFn func(*webhook.Delivery), opts ...orm.QueryOption) error {
+	return orm.UpdateWithContext(ctx, d.db, model, changeFn, opts...)
+}
+
+// ListDue implements webhook.DeliveryStorer.
+func (d Delivery) ListDue(ctx context.Context, now time.Time, limit int) ([]*webhook.Delivery, error) {
+	var out []*webhook.Delivery
+	err := orm.Conn(ctx, d.db).Where("status = ? AND next_attempt_at <= ?", webhook.DeliveryPending, now).
+		Order("id ASC").Limit(limit).Find(&out).Error
+	return out, err
+}
+
+// Claim implements webhook.DeliveryStorer.
+func (d Delivery) Claim(ctx context.Context, id int64, attempts int, leaseUntil time.Time) (bool, error) {
+	result := orm.Conn(ctx, d.db).Model(new(webhook.Delivery)).
+		Where("id = ? AND status = ? AND attempts = ?", id, webhook.DeliveryPending, attempts).
+		Updates(map[string]any{"attempts": attempts + 1, "next_attempt_at": leaseUntil})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,44 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhookdb
+
+import (
+	"context"
+
+	"github.com/ixugo/goddd/domain/webhook"
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+var _ webhook.WebhookStorer = Webhook{}
+
+// Webhook Related business namespaces
+type Webhook DB
+
+// List implements webhook.WebhookStorer.
+func (d Webhook) List(ctx context.Context, bs *[]*webhook.Webhook, page orm.Pager, opts ...orm.QueryOption) (int64, error) {
+	return orm.FindWithContext(ctx, d.db, bs, page, opts...)
+}
+
+// Get implements webhook.WebhookStorer.
+func (d Webhook) Get(ctx context.Context, model *webhook.Webhook, opts ...orm.QueryOption) error {
+	return orm.FirstWithContext(ctx, d.db, model, opts...)
+}
+
+// Create implements webhook.WebhookStorer.
+func (d Webhook) Create(ctx context.Context, model *webhook.Webhook) error {
+	return orm.Conn(ctx, d.db).Create(model).Error
+}
+
+// Update implements webhook.WebhookStorer.
+func (d Webhook) Update(ctx context.Context, model *webhook.Webhook, changeFn func(*webhook.Webhook), opts ...orm.QueryOption) error {
+	return orm.UpdateWithContext(ctx, d.db, model, changeFn, opts...)
+}
+
+// UpdateOptimistic implements webhook.WebhookStorer.
+func (d Webhook) UpdateOptimistic(ctx context.Context, model *webhook.Webhook, version int, changeFn func(*webhook.Webhook) error, opts ...orm.QueryOption) error {
+	return orm.UpdateOptimistic(ctx, d.db, model, version, changeFn, opts...)
+}
+
+// Delete implements webhook.WebhookStorer.
+func (d Webhook) Delete(ctx context.Context, model *webhook.Webhook, opts ...orm.QueryOption) error {
+	return orm.DeleteWithContext(ctx, d.db, model, opts...)
+}
@@ -0,0 +1,104 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// WebhookStorer Instantiation interface
+type WebhookStorer interface {
+	List(context.Context, *[]*Webhook, orm.Pager, ...orm.QueryOption) (int64, error)
+	Get(context.Context, *Webhook, ...orm.QueryOption) error
+	Create(context.Context, *Webhook) error
+	Update(context.Context, *Webhook, func(*Webhook), ...orm.QueryOption) error
+	UpdateOptimistic(context.Context, *Webhook, int, func(*Webhook) error, ...orm.QueryOption) error
+	Delete(context.Context, *Webhook, ...orm.QueryOption) error
+}
+
+// FindWebhook Paginated search
+func (c Core) FindWebhook(ctx context.Context, in *FindWebhookInput) ([]*Webhook, int64, error) {
+	query := orm.NewQuery(2)
+	if in.Name != "" {
+		query.Where("name like ?", "%"+in.Name+"%")
+	}
+	query.OrderBy("id DESC")
+
+	items := make([]*Webhook, 0, in.Limit())
+	total, err := c.store.Webhook().List(ctx, &items, in, query.Encode()...)
+	if err != nil {
+		return nil, 0, reason.ErrDB.Withf(`Find err[%s]`, err.Error())
+	}
+	return items, total, nil
+}
+
+// GetWebhook Query a single object
+func (c Core) GetWebhook(ctx context.Context, id int) (*Webhook, error) {
+	var out Webhook
+	if err := c.store.Webhook().Get(ctx, &out, orm.Where("id=?", id)); err != nil {
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrNotFound.Withf(`Get err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Get err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// AddWebhook Insert into database
+// 未指定秘钥时随机生成，秘钥仅在此时返回
+func (c Core) AddWebhook(ctx context.Context, in *AddWebhookInput) (*AddWebhookOutput, error) {
+	out := Webhook{
+		Name:    in.Name,
+		URL:     in.URL,
+		Secret:  in.Secret,
+		Events:  in.Events,
+		Enabled: in.Enabled == nil || *in.Enabled,
+	}
+	if out.Secret == "" {
+		out.Secret = orm.GenerateRandomString(32)
+	}
+	if err := c.store.Webhook().Create(ctx, &out); err != nil {
+		return nil, reason.ErrDB.Withf(`Add err[%s]`, err.Error())
+	}
+	return &AddWebhookOutput{Webhook: &out, Secret: out.Secret}, nil
+}
+
+// EditWebhook Update object information
+func (c Core) EditWebhook(ctx context.Context, in *EditWebhookInput, id int) (*Webhook, error) {
+	var out Webhook
+	if err := c.store.Webhook().UpdateOptimistic(ctx, &out, 0, func(b *Webhook) error {
+		if !in.MatchVersion(b.Version) {
+			return orm.ErrVersionConflict
+		}
+		b.Name = in.Name
+		b.URL = in.URL
+		b.Events = in.Events
+		b.Enabled = in.Enabled
+		if in.Secret != "" {
+			b.Secret = in.Secret
+		}
+		return nil
+	}, orm.Where("id=?", id)); err != nil {
+		if errors.Is(err, orm.ErrVersionConflict) {
+			return nil, reason.ErrVersionConflict.Withf(`Edit err[%s]`, err.Error())
+		}
+		if orm.IsErrRecordNotFound(err) {
+			return nil, reason.ErrNotFound.Withf(`Edit err[%s]`, err.Error())
+		}
+		return nil, reason.ErrDB.Withf(`Edit err[%s]`, err.Error())
+	}
+	return &out, nil
+}
+
+// DelWebhook Delete object
+// 尚未完成的投递在下次尝试时标记为失败，投递日志保留
+func (c Core) DelWebhook(ctx context.Context, id int) (*Webhook, error) {
+	var out Webhook
+	if err := c.store.Webhook().Delete(ctx, &out, orm.Where("id=?", id)); err != nil {
+		return nil, reason.ErrDB.Withf(`Del err[%s]`, err.Error())
+	}
+	return &out, nil
+}
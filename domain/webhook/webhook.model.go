@@ -0,0 +1,69 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+// Webhook domain model
+type Webhook struct {
+	ID        int      `gorm:"primaryKey" json:"id"`
+	Name      string   `gorm:"column:name;notNull;default:'';comment:名称" json:"name"`            // 名称
+	URL       string   `gorm:"column:url;notNull;default:'';comment:回调地址" json:"url"`            // 回调地址
+	Secret    string   `gorm:"column:secret;notNull;default:'';comment:签名秘钥" json:"-"`           // 签名秘钥，仅创建时返回
+	Events    Events   `gorm:"column:events;type:json;comment:订阅的事件" json:"events"`              // 订阅的事件
+	Enabled   bool     `gorm:"column:enabled;notNull;default:false;comment:是否启用" json:"enabled"` // 是否启用
+	CreatedAt orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	Version   int      `gorm:"column:version;notNull;default:1;comment:版本号" json:"version"` // 乐观锁版本号
+}
+
+// TableName database table name
+func (*Webhook) TableName() string {
+	return "webhooks"
+}
+
+// GetVersion 实现 web.Versioned，响应时输出 ETag
+func (w *Webhook) GetVersion() int {
+	return w.Version
+}
+
+// Events 订阅的事件，为空时订阅全部
+// 支持 * 与 token.* 形式的前缀匹配
+type Events []string
+
+var _ orm.JSONValueScanner = (*Events)(nil)
+
+// Scan implements orm.JSONValueScanner.
+func (e *Events) Scan(input any) error {
+	return orm.JSONUnmarshal(input, e)
+}
+
+// Value implements orm.JSONValueScanner.
+func (e Events) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(e)
+	return string(b), err
+}
+
+// Match 是否订阅了 event
+func (e Events) Match(event string) bool {
+	if len(e) == 0 {
+		return true
+	}
+	for _, v := range e {
+		if v == "*" || v == event {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(v, "*"); ok && strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhook
+
+import "github.com/ixugo/goddd/pkg/web"
+
+type FindWebhookInput struct {
+	web.PagerFilter
+	Name string `form:"name"` // 名称
+}
+
+type EditWebhookInput struct {
+	web.IfMatch        // 请求头 If-Match 为 GET 响应的 ETag
+	Name        string `json:"name"`                                       // 名称
+	URL         string `json:"url" binding:"required,url,startswith=http"` // 回调地址
+	Events      Events `json:"events"`                                     // 订阅的事件，为空时订阅全部
+	Enabled     bool   `json:"enabled"`                                    // 是否启用
+	Secret      string `json:"secret" binding:"omitempty,min=16"`          // 签名秘钥，为空时不修改
+}
+
+type AddWebhookInput struct {
+	Name    string `json:"name"`                                       // 名称
+	URL     string `json:"url" binding:"required,url,startswith=http"` // 回调地址
+	Events  Events `json:"events"`                                     // 订阅的事件，为空时订阅全部
+	Enabled *bool  `json:"enabled"`                                    // 是否启用，默认启用
+	Secret  string `json:"secret" binding:"omitempty,min=16"`          // 签名秘钥，为空时随机生成
+}
+
+// AddWebhookOutput 秘钥仅在创建时返回
+type AddWebhookOutput struct {
+	*Webhook
+	Secret string `json:"secret"` // 签名秘钥
+}
@@ -0,0 +1,75 @@
+// Code generated by godddx, DO AVOID EDIT.
+package webhookapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/domain/webhook"
+	"github.com/ixugo/goddd/domain/webhook/store/webhookdb"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/web"
+	"gorm.io/gorm"
+)
+
+type WebhookAPI struct {
+	WebhookCore webhook.Core
+	Dispatcher  *webhook.Dispatcher
+}
+
+// NewWebhookAPI 投递由 Dispatcher.Run 执行，需要在程序启动时运行
+func NewWebhookAPI(db *gorm.DB, cfg webhook.Config) WebhookAPI {
+	store := webhookdb.NewDB(db).AutoMigrate(orm.GetEnabledAutoMigrate())
+	return WebhookAPI{
+		WebhookCore: webhook.NewCore(store),
+		Dispatcher:  webhook.NewDispatcher(store, cfg),
+	}
+}
+
+func Register(g gin.IRouter, api WebhookAPI, handler ...gin.HandlerFunc) {
+	{
+		group := g.Group("/webhooks", handler...)
+		web.Handle(group, http.MethodGet, "", api.findWebhook)
+		web.Handle(group, http.MethodGet, "/:id", api.getWebhook)
+		web.Handle(group, http.MethodPut, "/:id", api.editWebhook)
+		web.Handle(group, http.MethodPost, "", api.addWebhook)
+		web.Handle(group, http.MethodDelete, "/:id", api.delWebhook)
+
+		web.Handle(group, http.MethodGet, "/:id/deliveries", api.findDelivery)
+	}
+}
+
+// >>> webhook >>>>>>>>>>>>>>>>>>>>
+
+func (a WebhookAPI) findWebhook(c *gin.Context, in *webhook.FindWebhookInput) (*web.PageOutput[*webhook.Webhook], error) {
+	items, total, err := a.WebhookCore.FindWebhook(c.Request.Context(), in)
+	return &web.PageOutput[*webhook.Webhook]{Items: items, Total: total}, err
+}
+
+func (a WebhookAPI) getWebhook(c *gin.Context, _ *struct{}) (*webhook.Webhook, error) {
+	webhookID, _ := strconv.Atoi(c.Param("id"))
+	return a.WebhookCore.GetWebhook(c.Request.Context(), webhookID)
+}
+
+func (a WebhookAPI) editWebhook(c *gin.Context, in *webhook.EditWebhookInput) (*webhook.Webhook, error) {
+	webhookID, _ := strconv.Atoi(c.Param("id"))
+	return a.WebhookCore.EditWebhook(c.Request.Context(), in, webhookID)
+}
+
+func (a WebhookAPI) addWebhook(c *gin.Context, in *webhook.AddWebhookInput) (*webhook.AddWebhookOutput, error) {
+	return a.WebhookCore.AddWebhook(c.Request.Context(), in)
+}
+
+func (a WebhookAPI) delWebhook(c *gin.Context, _ *struct{}) (*webhook.Webhook, error) {
+	webhookID, _ := strconv.Atoi(c.Param("id"))
+	return a.WebhookCore.DelWebhook(c.Request.Context(), webhookID)
+}
+
+// >>> delivery >>>>>>>>>>>>>>>>>>>>
+
+func (a WebhookAPI) findDelivery(c *gin.Context, in *webhook.FindDeliveryInput) (*web.PageOutput[*webhook.Delivery], error) {
+	webhookID, _ := strconv.Atoi(c.Param("id"))
+	items, total, err := a.WebhookCore.FindDelivery(c.Request.Context(), webhookID, in)
+	return &web.PageOutput[*webhook.Delivery]{Items: items, Total: total}, err
+}
@@ -4,19 +4,34 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"syscall"
 
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/domain/webhook"
 	"github.com/ixugo/goddd/internal/conf"
+	"github.com/ixugo/goddd/internal/web/api"
+	"github.com/ixugo/goddd/pkg/event"
 	"github.com/ixugo/goddd/pkg/logger"
 	"github.com/ixugo/goddd/pkg/orm"
 	"github.com/ixugo/goddd/pkg/server"
 	"github.com/ixugo/goddd/pkg/system"
+	"github.com/ixugo/goddd/pkg/trace"
 )
 
+// App wire 构建的程序组件
+type App struct {
+	Handler  http.Handler
+	Events   *event.Bus          // 事件总线，由 Run 启动发件箱投递
+	Webhooks *webhook.Dispatcher // webhook 投递，由 Run 启动
+	Jobs     *job.Queue          // 后台任务，由 Run 启动
+}
+
 func Run(bc *conf.Bootstrap) {
 	// 以可执行文件所在目录为工作目录，防止以服务方式运行时，工作目录切换到其它位置
 	bin, _ := os.Executable()
@@ -27,24 +42,51 @@ func Run(bc *conf.Bootstrap) {
 	log, clean := SetupLog(bc)
 	defer clean()
 
+	// 在 SetupLog 之后，上报失败时记录日志；关闭时上报剩余的 span
+	closeTrace := SetupTrace(bc)
+	defer closeTrace()
+
 	// 检查是否设置了 JWT 密钥，如果未设置，则生成一个长度为 32 的随机字符串作为密钥
-	if bc.Server.HTTP.JwtSecret == "" {
+	// 写回配置文件，避免每次重启后已签发的 token 全部失效
+	var changed bool
+	if bc.Server.HTTP.JwtSecret == "" && bc.Server.HTTP.JWT.PrivateKey == "" {
 		bc.Server.HTTP.JwtSecret = orm.GenerateRandomString(32) // 生成一个长度为 32 的随机字符串作为密钥
+		changed = true
 	}
+	// 游标分页的签名秘钥，与 JWT 无关，保证重启后与多副本间游标仍然有效
+	if bc.Server.HTTP.CursorSecret == "" {
+		bc.Server.HTTP.CursorSecret = orm.GenerateRandomString(32)
+		changed = true
+	}
+	if path := bc.Runtime.ConfigPath; changed && path != "" {
+		if err := conf.WriteConfig(bc, path); err != nil {
+			slog.Warn("秘钥写回配置文件失败，重启后需要重新登录，游标分页失效", "err", err)
+		}
+	}
+	orm.SetCursorSecret(bc.Server.HTTP.CursorSecret)
 
-	handler, cleanUp, err := WireApp(bc, log)
+	app, cleanUp, err := WireApp(bc, log)
 	if err != nil {
 		slog.Error("程序构建失败", "err", err)
 		panic(err)
 	}
 	defer cleanUp()
 
+	// 后台任务随服务关闭而退出，需要在 cleanUp 关闭数据库之前结束
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	var bg sync.WaitGroup
+	defer bg.Wait()
+	defer bgCancel()
+	bg.Go(func() { app.Events.Run(bgCtx) })
+	bg.Go(func() { app.Webhooks.Run(bgCtx) })
+	bg.Go(func() { app.Jobs.Run(bgCtx) })
+
 	// 启动配置文件热重载
 	watchCtx, watchCancel := context.WithCancel(context.Background())
 	defer watchCancel()
-	go conf.WatchConfig(watchCtx, bc, webhookWorkersReloader())
+	go conf.WatchConfig(watchCtx, bc, webhookWorkersReloader(app.Webhooks))
 
-	svc := server.New(handler,
+	svc := server.New(app.Handler,
 		server.Port(strconv.Itoa(bc.Server.HTTP.Port)),
 		server.ReadTimeout(bc.Server.HTTP.Timeout.Duration()),
 		server.WriteTimeout(bc.Server.HTTP.Timeout.Duration()),
@@ -84,9 +126,42 @@ func SetupLog(bc *conf.Bootstrap) (*slog.Logger, func()) {
 	})
 }
 
-func webhookWorkersReloader() conf.ReloadCallback {
+// webhookWorkersReloader 并发数与超时时间变更后立即生效，无需重启
+func webhookWorkersReloader(d *webhook.Dispatcher) conf.ReloadCallback {
 	return func(old, new *conf.Bootstrap) error {
-		slog.Info("配置变更")
+		if old.Webhook == new.Webhook {
+			return nil
+		}
+		d.SetConfig(api.WebhookConfig(new.Webhook))
+		slog.Info("webhook 配置变更", "workers", new.Webhook.Workers, "timeout", new.Webhook.Timeout.Duration(), "max_attempts", new.Webhook.MaxAttempts)
 		return nil
 	}
 }
+
+// SetupTrace 初始化链路追踪，未配置 Endpoint 与 File 时只生成 trace id
+func SetupTrace(bc *conf.Bootstrap) func() {
+	cfg := bc.Trace
+	exporters := make([]trace.Exporter, 0, 2)
+	if cfg.Endpoint != "" {
+		exporters = append(exporters, trace.NewOTLPExporter(cfg.Endpoint, cfg.Headers))
+	}
+	if cfg.File != "" {
+		e, err := trace.NewFileExporter(filepath.Join(system.Getwd(), cfg.File))
+		if err != nil {
+			slog.Error("trace file exporter", "err", err)
+		} else {
+			exporters = append(exporters, e)
+		}
+	}
+	t := trace.New(trace.Config{
+		ServiceName:    "goddd",
+		ServiceVersion: bc.Runtime.BuildVersion,
+		SampleRatio:    cfg.SampleRatio,
+	}, exporters...)
+	trace.SetDefault(t)
+	return func() {
+		if err := t.Close(); err != nil {
+			slog.Error("close trace", "err", err)
+		}
+	}
+}
@@ -4,7 +4,6 @@ package app
 
 import (
 	"log/slog"
-	"net/http"
 
 	"github.com/google/wire"
 	"github.com/ixugo/goddd/internal/conf"
@@ -12,6 +11,6 @@ import (
 	"github.com/ixugo/goddd/internal/web/api"
 )
 
-func WireApp(bc *conf.Bootstrap, log *slog.Logger) (http.Handler, func(), error) {
-	panic(wire.Build(data.ProviderSet, api.ProviderVersionSet, api.ProviderSet))
+func WireApp(bc *conf.Bootstrap, log *slog.Logger) (*App, func(), error) {
+	panic(wire.Build(data.ProviderSet, api.ProviderVersionSet, api.ProviderSet, wire.Struct(new(App), "*")))
 }
@@ -7,29 +7,82 @@
 package app
 
 import (
+	"github.com/ixugo/goddd/domain/rbac/rbacapi"
 	"github.com/ixugo/goddd/domain/version/versionapi"
 	"github.com/ixugo/goddd/internal/conf"
 	"github.com/ixugo/goddd/internal/data"
 	"github.com/ixugo/goddd/internal/web/api"
 	"log/slog"
-	"net/http"
 )
 
 // Injectors from wire.go:
 
-func WireApp(bc *conf.Bootstrap, log *slog.Logger) (http.Handler, func(), error) {
-	db, err := data.SetupDB(bc)
+func WireApp(bc *conf.Bootstrap, log *slog.Logger) (*App, func(), error) {
+	db, cleanup, err := data.SetupDB(bc)
 	if err != nil {
 		return nil, nil, err
 	}
-	core := versionapi.NewVersionCore(db)
+	locker, err := data.SetupLocker(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	core := versionapi.NewVersionCore(db, locker)
 	versionapiAPI := versionapi.New(core)
+	keySet, err := api.NewKeySet(bc)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	cacher, cleanup2, err := data.SetupCache(bc)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	bus := data.SetupEventBus(db)
+	tokenAPI := api.NewTokenAPI(keySet, db, cacher, bus)
+	rbacapiRBACAPI := rbacapi.NewRBACAPI(db, cacher)
+	webhookAPI := api.NewWebhookAPI(bc, db, bus)
+	auditapiAPI, err := api.NewAuditAPI(bc, db)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	jobapiAPI, err := api.NewJobAPI(bc, db, tokenAPI, rbacapiRBACAPI, auditapiAPI)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
 	usecase := &api.Usecase{
 		Conf:    bc,
 		DB:      db,
 		Version: versionapiAPI,
+		Token:   tokenAPI,
+		RBAC:    rbacapiRBACAPI,
+		Keys:    keySet,
+		Events:  bus,
+		Webhook: webhookAPI,
+		Job:     jobapiAPI,
+		Audit:   auditapiAPI,
+	}
+	handler, err := api.NewHTTPHandler(usecase)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	dispatcher := webhookAPI.Dispatcher
+	queue := jobapiAPI.Queue
+	app := &App{
+		Handler:  handler,
+		Events:   bus,
+		Webhooks: dispatcher,
+		Jobs:     queue,
 	}
-	handler := api.NewHTTPHandler(usecase)
-	return handler, func() {
+	return app, func() {
+		cleanup2()
+		cleanup()
 	}, nil
 }
@@ -7,6 +7,23 @@ type Bootstrap struct {
 	Server  Server  // 服务器
 	Data    Data    // 数据
 	Log     Log     // 日志
+	Webhook Webhook `comment:"webhook 投递，修改后无需重启"`                                 // webhook 投递
+	Trace   Trace   `comment:"链路追踪，兼容 OpenTelemetry，Endpoint 与 File 均为空时不记录 span"` // 链路追踪
+}
+
+// Trace 链路追踪配置
+type Trace struct {
+	Endpoint    string            `comment:"OTLP/HTTP 地址，例如 http://127.0.0.1:4318"`        // OTLP/HTTP 地址
+	Headers     map[string]string `comment:"上报时附加的请求头，例如 { Authorization = 'Basic xxx' }"` // 上报时附加的请求头
+	File        string            `comment:"span 写入文件用于离线排查，每行一批 OTLP JSON"`               // 导出文件
+	SampleRatio float64           `comment:"采样率 0~1，上游传入 traceparent 时跟随上游的采样标记"`          // 采样率
+}
+
+// Webhook 投递配置，支持热更新
+type Webhook struct {
+	Workers     int      `comment:"并发投递数量"`         // 并发投递数量
+	Timeout     Duration `comment:"单次请求超时时间"`       // 单次请求超时时间
+	MaxAttempts int      `comment:"最大投递次数，超过后标记失败"` // 最大投递次数
 }
 
 type Runtime struct {
@@ -21,10 +38,50 @@ type Server struct {
 }
 
 type ServerHTTP struct {
-	Port      int         `comment:"http 端口"`                // 服务器端口号
-	Timeout   Duration    `comment:"请求超时时间"`                 // 请求超时时间
-	JwtSecret string      `comment:"jwt 秘钥，空串时，每次启动程序将随机赋值"` // JWT密钥
-	PProf     ServerPPROF // Pprof配置
+	Port         int           `comment:"http 端口"`                                               // 服务器端口号
+	Timeout      Duration      `comment:"请求超时时间"`                                                // 请求超时时间
+	JwtSecret    string        `comment:"jwt 秘钥，HS256 签名使用，空串且未配置 JWT.PrivateKey 时，随机生成并写回配置文件"` // JWT密钥
+	CursorSecret string        `comment:"游标分页的签名秘钥，空串时随机生成并写回配置文件，多副本间需要一致"`                     // 游标秘钥
+	JWT          ServerJWT     `comment:"非对称签名，配置 PrivateKey 后代替 JwtSecret 签发 token"`            // JWT 非对称签名
+	PProf        ServerPPROF   // Pprof配置
+	OpenAPI      ServerOpenAPI // OpenAPI 文档
+	Metrics      ServerMetrics // Prometheus 指标
+	Errors       ServerErrors  // 错误响应格式
+}
+
+// ServerErrors 错误响应的格式，problem 为 RFC 9457 application/problem+json
+type ServerErrors struct {
+	Format   string `comment:"auto/envelope/problem，auto 按请求头 Accept 协商，默认返回 {reason,msg,details}"`     // 错误格式
+	TypeBase string `comment:"problem 的 type 前缀，例如 https://example.com/errors/，为空时 type 为 about:blank"` // type 前缀
+}
+
+// ServerMetrics 以 Prometheus/OpenMetrics 格式输出请求、数据库与运行时指标
+type ServerMetrics struct {
+	Enabled bool `comment:"是否开放 /debug/metrics，访问白名单与 Pprof 相同"` // 是否启用
+}
+
+// ServerOpenAPI 根据 web.Handle 注册的路由生成 OpenAPI 3.1 文档
+type ServerOpenAPI struct {
+	Enabled bool   `comment:"是否开放 OpenAPI 文档，文档列出全部接口且不需要登录，建议仅在内网或调试时开启"` // 是否启用
+	Path    string `comment:"文档路由，默认 /openapi.json"`                       // 文档路由
+}
+
+// ServerJWT 非对称签名与密钥轮换
+// 轮换时将当前密钥移入 PreviousKeys，再配置新的 PrivateKey，待旧 token 全部过期后删除旧密钥
+type ServerJWT struct {
+	KeyID         string         `comment:"当前密钥 id，写入 token 头部的 kid，配置 PrivateKey 时必填"`                             // 当前密钥 id
+	Alg           string         `comment:"签名算法，为空时根据密钥类型推断，RSA 为 RS256，ECDSA 为 ES256/ES384/ES512，Ed25519 为 EdDSA"` // 签名算法
+	PrivateKey    string         `comment:"签名私钥 PEM 文件路径，相对路径基于配置文件目录"`                                             // 签名私钥
+	PreviousKeys  []ServerJWTKey `comment:"轮换前的密钥，仅用于验证尚未过期的 token"`                                                // 旧密钥
+	JWKS          bool           `comment:"是否开放 /.well-known/jwks.json，供其它服务验证 token"`                              // 是否开放公钥
+	RequireTenant bool           `comment:"多租户部署时开启，拒绝不携带 tenant_id 的 token"`                                       // 是否要求租户
+}
+
+// ServerJWTKey 仅用于验证的密钥
+type ServerJWTKey struct {
+	KeyID     string // 密钥 id
+	Alg       string // 签名算法，为空时根据密钥类型推断
+	PublicKey string `comment:"PEM 文件路径，公钥、私钥或证书均可"` // 公钥文件
 }
 
 // ServerPPROF 结构体，包含 Enabled 和 AccessIps 两个字段
@@ -37,13 +94,49 @@ type ServerPPROF struct {
 type Data struct {
 	// Database 数据库
 	Database Database `comment:"数据库支持 sqlite 和 postgres 两种，使用 sqlite 时 dsn 应当填写文件存储路径"`
-	// Redis Redis数据库
-	// Redis DataRedis
+	// Redis 缓存
+	Redis DataRedis `comment:"缓存服务，兼容 RESP 协议，Addr 为空时使用进程内缓存，多副本部署时应当配置"`
+	// Trash 回收站
+	Trash DataTrash `comment:"软删除的数据保留一段时间后，由定时任务彻底删除"`
+	// Audit 审计日志
+	Audit DataAudit `comment:"记录数据表的增删改，修改 Tables 后需要重启"`
+}
+
+// DataAudit 审计日志配置
+type DataAudit struct {
+	Retention Duration `comment:"保留时长，为 0 时不清理"` // 保留时长
+	Tables    []string `comment:"记录变更的表名"`       // 记录变更的表名
+}
+
+// DataTrash 回收站保留时长
+type DataTrash struct {
+	Retention Duration            `comment:"默认保留时长"`                             // 默认保留时长
+	Tables    map[string]Duration `comment:"按表名单独设置，例如 { roles = '2160h0m0s' }"` // 按表名单独设置
+}
+
+// RetentionOf 表的保留时长，未单独设置时使用默认值
+func (t DataTrash) RetentionOf(table string) time.Duration {
+	if v, ok := t.Tables[table]; ok {
+		return v.Duration()
+	}
+	return t.Retention.Duration()
+}
+
+// DataRedis 缓存配置
+type DataRedis struct {
+	Addr     string   `comment:"地址，例如 127.0.0.1:6379"` // 地址
+	Password string   // 密码
+	DB       int      // 数据库编号
+	PoolSize int      `comment:"最大空闲连接数"`         // 最大空闲连接数
+	Prefix   string   `comment:"键前缀，多个服务共用时用于隔离"` // 键前缀
+	TTL      Duration `comment:"缓存过期时间"`          // 缓存过期时间
 }
 
 // Database 结构体，包含 Dsn、MaxIdleConns、MaxOpenConns、ConnMaxLifetime 和 SlowThreshold 五个字段
 type Database struct {
 	Dsn             string   // 数据源名称
+	Replicas        []string // 只读副本的数据源，不在事务中的查询优先使用副本
+	ReadYourWrites  Duration // 同一请求写入后，此时长内的查询使用主库
 	MaxIdleConns    int32    // 最大空闲连接数
 	MaxOpenConns    int32    // 最大打开连接数
 	ConnMaxLifetime Duration // 连接最大生命周期
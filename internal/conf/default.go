@@ -10,13 +10,24 @@ func DefaultConfig() Bootstrap {
 	return Bootstrap{
 		Server: Server{
 			HTTP: ServerHTTP{
-				Port:      8080,
-				Timeout:   Duration(30 * time.Second),
-				JwtSecret: orm.GenerateRandomString(32),
+				Port:         8080,
+				Timeout:      Duration(30 * time.Second),
+				JwtSecret:    orm.GenerateRandomString(32),
+				CursorSecret: orm.GenerateRandomString(32),
 				PProf: ServerPPROF{
 					Enabled:   true,
 					AccessIps: []string{"::1", "127.0.0.1"},
 				},
+				OpenAPI: ServerOpenAPI{
+					Enabled: false,
+					Path:    "/openapi.json",
+				},
+				Metrics: ServerMetrics{
+					Enabled: true,
+				},
+				Errors: ServerErrors{
+					Format: "auto",
+				},
 			},
 		},
 		Data: Data{
@@ -26,6 +37,19 @@ func DefaultConfig() Bootstrap {
 				MaxOpenConns:    50,
 				ConnMaxLifetime: Duration(6 * time.Hour),
 				SlowThreshold:   Duration(200 * time.Millisecond),
+				ReadYourWrites:  Duration(5 * time.Second),
+			},
+			Redis: DataRedis{
+				PoolSize: 10,
+				Prefix:   "goddd:",
+				TTL:      Duration(time.Hour),
+			},
+			Trash: DataTrash{
+				Retention: Duration(30 * 24 * time.Hour),
+			},
+			Audit: DataAudit{
+				Retention: Duration(180 * 24 * time.Hour),
+				Tables:    []string{"roles", "permissions", "role_permissions", "webhooks"},
 			},
 		},
 		Log: Log{
@@ -37,5 +61,13 @@ func DefaultConfig() Bootstrap {
 			Compress:     false,
 			MaxBackups:   0,
 		},
+		Webhook: Webhook{
+			Workers:     4,
+			Timeout:     Duration(10 * time.Second),
+			MaxAttempts: 8,
+		},
+		Trace: Trace{
+			SampleRatio: 1,
+		},
 	}
 }
@@ -0,0 +1,38 @@
+package data
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ixugo/goddd/internal/conf"
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/resp"
+)
+
+// SetupCache 初始化缓存
+// 未配置 Redis 地址时使用进程内缓存，此时缓存失效无法同步到其它副本
+func SetupCache(c *conf.Bootstrap) (conc.Cacher, func(), error) {
+	cfg := c.Data.Redis
+	ttl := cfg.TTL.Duration()
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	if cfg.Addr == "" {
+		return conc.NewTTLCache(ttl), func() {}, nil
+	}
+	cli, err := resp.NewClient(resp.Config{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	cache := conc.NewRedisCache(cli, conc.WithPrefix(cfg.Prefix), conc.WithTTL(ttl))
+	return cache, func() {
+		if err := cli.Close(); err != nil {
+			slog.Error("close redis", "err", err)
+		}
+	}, nil
+}
@@ -1,12 +1,15 @@
 package data
 
 import (
+	"fmt"
+	"log/slog"
 	"path/filepath"
 	"strings"
 
 	"github.com/glebarez/sqlite"
 	"github.com/google/wire"
 	"github.com/ixugo/goddd/internal/conf"
+	"github.com/ixugo/goddd/pkg/event"
 	"github.com/ixugo/goddd/pkg/orm"
 	"github.com/ixugo/goddd/pkg/system"
 	"gorm.io/driver/postgres"
@@ -14,23 +17,65 @@ import (
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(SetupDB)
+var ProviderSet = wire.NewSet(SetupDB, SetupCache, SetupEventBus, SetupLocker)
 
 // SetupDB 初始化数据存储
-func SetupDB(c *conf.Bootstrap) (*gorm.DB, error) {
+// 配置 Replicas 时读写分离，查询路由到健康的副本
+func SetupDB(c *conf.Bootstrap) (*gorm.DB, func(), error) {
 	cfg := c.Data.Database
 	dial, isSQLite := getDialector(cfg.Dsn)
 	if isSQLite {
 		cfg.MaxIdleConns = 1
 		cfg.MaxOpenConns = 1
 	}
-	db, err := orm.New(dial, orm.Config{
+	ormCfg := orm.Config{
 		MaxIdleConns:    int(cfg.MaxIdleConns),
 		MaxOpenConns:    int(cfg.MaxOpenConns),
 		ConnMaxLifetime: cfg.ConnMaxLifetime.Duration(),
 		SlowThreshold:   cfg.SlowThreshold.Duration(),
-	})
-	return db, err
+	}
+	db, err := orm.New(dial, ormCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(cfg.Replicas) == 0 {
+		if sqlDB, err := db.DB(); err == nil {
+			orm.PublishDBStats("primary", sqlDB)
+		}
+		return db, func() {}, nil
+	}
+
+	replicas := make([]*gorm.DB, 0, len(cfg.Replicas))
+	for _, dsn := range cfg.Replicas {
+		dial, _ := getDialector(dsn)
+		replica, err := orm.New(dial, ormCfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("replica: %w", err)
+		}
+		replicas = append(replicas, replica)
+	}
+	resolver, err := orm.NewReplicas(orm.ReplicaConfig{ReadYourWrites: cfg.ReadYourWrites.Duration()}, replicas...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := db.Use(resolver); err != nil {
+		return nil, nil, err
+	}
+	return db, func() {
+		if err := resolver.Close(); err != nil {
+			slog.Error("close replicas", "err", err)
+		}
+	}, nil
+}
+
+// SetupEventBus 事件总线，发件箱的投递由 app.Run 启动
+func SetupEventBus(db *gorm.DB) *event.Bus {
+	return event.NewBus(db, event.Config{}).AutoMigrate(orm.GetEnabledAutoMigrate())
+}
+
+// SetupLocker 分布式锁，多副本部署时保证同一时刻只有一个副本执行
+func SetupLocker(db *gorm.DB) (*orm.Locker, error) {
+	return orm.NewLocker(db, orm.LockConfig{})
 }
 
 // getDialector 返回 dial 和 是否 sqlite
@@ -11,7 +11,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/domain/audit/auditapi"
+	"github.com/ixugo/goddd/domain/job/jobapi"
+	"github.com/ixugo/goddd/domain/rbac/rbacapi"
+	"github.com/ixugo/goddd/domain/token/tokenapi"
 	"github.com/ixugo/goddd/domain/version/versionapi"
+	"github.com/ixugo/goddd/domain/webhook/webhookapi"
 	"github.com/ixugo/goddd/pkg/web"
 )
 
@@ -27,6 +32,10 @@ func setupRouter(r *gin.Engine, uc *Usecase) {
 		}),
 		web.Metrics(),
 		web.Logger(),
+		// 审计日志的操作人，需要在 Logger 生成 trace id 之后
+		auditapi.Actor(),
+		// 读写分离时，请求内写入后的查询使用主库
+		web.ReadYourWrites(),
 		// debug 环境中配合 debug 日志级别，记录请求体与响应体
 		web.LoggerWithBody(web.DefaultBodyLimit, func(_ *gin.Context) bool {
 			// true: 表示忽略记录日志
@@ -36,11 +45,36 @@ func setupRouter(r *gin.Engine, uc *Usecase) {
 	)
 	go web.CountGoroutines(10*time.Minute, 20)
 
-	auth := web.AuthMiddleware(uc.Conf.Server.HTTP.JwtSecret)
-	r.Any("/health", web.WrapH(uc.getHealth))
-	r.GET("/app/metrics/api", web.WrapH(uc.getMetricsAPI))
-
-	versionapi.Register(r, uc.Version, auth)
+	web.SetRequireTenant(uc.Conf.Server.HTTP.JWT.RequireTenant)
+	auth := web.AuthMiddlewareWithKeySet(uc.Keys)
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		web.Handle(r, method, "/health", uc.getHealth)
+	}
+	if uc.Conf.Server.HTTP.JWT.JWKS {
+		r.GET("/.well-known/jwks.json", uc.Keys.JWKSHandler())
+	}
+	web.Handle(r, http.MethodGet, "/app/metrics/api", uc.getMetricsAPI)
+
+	versionapi.Register(r, uc.Version, auth, web.AuthLevel(1))
+	tokenapi.Register(r, uc.Token, auth, web.AuthLevel(1))
+
+	// 业务路由可使用 web.RequirePermission("resource:action") 校验角色权限
+	// 角色与权限的管理仅开放给最高等级，避免初始没有任何权限时无法分配
+	web.SetPermissionChecker(uc.RBAC.RBACCore)
+	rbacapi.Register(r, uc.RBAC, auth, web.AuthLevel(1))
+	registerEvent(r, uc, auth, web.AuthLevel(1))
+	webhookapi.Register(r, uc.Webhook, auth, web.AuthLevel(1))
+	jobapi.Register(r, uc.Job, auth, web.AuthLevel(1))
+	auditapi.Register(r, uc.Audit, auth, web.AuthLevel(1))
+
+	// 文档根据已注册的路由生成，需要放在最后
+	if cfg := uc.Conf.Server.HTTP.OpenAPI; cfg.Enabled {
+		path := cfg.Path
+		if path == "" {
+			path = "/openapi.json"
+		}
+		r.GET(path, web.OpenAPIHandler(r, web.OpenAPIInfo{Title: "goddd", Version: uc.Conf.Runtime.BuildVersion}))
+	}
 }
 
 type getHealthOutput struct {
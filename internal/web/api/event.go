@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/event"
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/web"
+)
+
+// registerEvent 发件箱死信，超过最大投递次数的事件需要人工确认后重新投递
+func registerEvent(g gin.IRouter, uc *Usecase, handler ...gin.HandlerFunc) {
+	group := g.Group("/events", handler...)
+	web.Handle(group, http.MethodGet, "/dead-letters", uc.listDeadLetters)
+	web.CustomEndpoints(group, "/dead-letters", map[string]web.Endpoint{
+		"retry": web.NewEndpoint(uc.retryDeadLetters),
+	})
+}
+
+type findDeadLetterInput struct {
+	web.PagerFilter
+	event.FindDeadLetterInput
+}
+
+func (uc *Usecase) listDeadLetters(c *gin.Context, in *findDeadLetterInput) (*web.PageOutput[*event.Outbox], error) {
+	items, total, err := uc.Events.DeadLetters(c.Request.Context(), &in.FindDeadLetterInput, in)
+	if err != nil {
+		return nil, reason.ErrDB.Withf(`DeadLetters err[%s]`, err.Error())
+	}
+	return &web.PageOutput[*event.Outbox]{Items: items, Total: total}, nil
+}
+
+type retryDeadLettersInput struct {
+	IDs []int64 `json:"ids" binding:"required,min=1,max=1000"` // 死信 id
+}
+
+type retryDeadLettersOutput struct {
+	Retried int64 `json:"retried"` // 重新投递的数量，已重试或不存在的 id 被忽略
+}
+
+func (uc *Usecase) retryDeadLetters(c *gin.Context, in *retryDeadLettersInput) (*retryDeadLettersOutput, error) {
+	n, err := uc.Events.Retry(c.Request.Context(), in.IDs...)
+	if err != nil {
+		return nil, reason.ErrDB.Withf(`Retry err[%s]`, err.Error())
+	}
+	return &retryDeadLettersOutput{Retried: n}, nil
+}
@@ -1,14 +1,30 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"path/filepath"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/wire"
+	"github.com/ixugo/goddd/domain/audit"
+	"github.com/ixugo/goddd/domain/audit/auditapi"
+	"github.com/ixugo/goddd/domain/job"
+	"github.com/ixugo/goddd/domain/job/jobapi"
+	"github.com/ixugo/goddd/domain/rbac/rbacapi"
+	"github.com/ixugo/goddd/domain/token"
+	"github.com/ixugo/goddd/domain/token/tokenapi"
 	"github.com/ixugo/goddd/domain/uniqueid"
 	"github.com/ixugo/goddd/domain/uniqueid/store/uniqueiddb"
 	"github.com/ixugo/goddd/domain/version/versionapi"
+	"github.com/ixugo/goddd/domain/webhook"
+	"github.com/ixugo/goddd/domain/webhook/webhookapi"
 	"github.com/ixugo/goddd/internal/conf"
+	"github.com/ixugo/goddd/pkg/conc"
+	"github.com/ixugo/goddd/pkg/event"
 	"github.com/ixugo/goddd/pkg/orm"
 	"github.com/ixugo/goddd/pkg/web"
 	"gorm.io/gorm"
@@ -20,6 +36,14 @@ var (
 		wire.Struct(new(Usecase), "*"),
 		NewHTTPHandler,
 		versionapi.New,
+		NewTokenAPI,
+		NewKeySet,
+		rbacapi.NewRBACAPI,
+		NewWebhookAPI,
+		wire.FieldsOf(new(webhookapi.WebhookAPI), "Dispatcher"),
+		NewJobAPI,
+		NewAuditAPI,
+		wire.FieldsOf(new(jobapi.API), "Queue"),
 	)
 )
 
@@ -27,10 +51,17 @@ type Usecase struct {
 	Conf    *conf.Bootstrap
 	DB      *gorm.DB
 	Version versionapi.API
+	Token   tokenapi.TokenAPI
+	RBAC    rbacapi.RBACAPI
+	Keys    *web.KeySet
+	Events  *event.Bus
+	Webhook webhookapi.WebhookAPI
+	Job     jobapi.API
+	Audit   auditapi.API
 }
 
 // NewHTTPHandler 生成Gin框架路由内容
-func NewHTTPHandler(uc *Usecase) http.Handler {
+func NewHTTPHandler(uc *Usecase) (http.Handler, error) {
 	cfg := uc.Conf
 	// 如果不处于调试模式，将 Gin 设置为发布模式
 	if !uc.Conf.Runtime.Debug {
@@ -45,12 +76,20 @@ func NewHTTPHandler(uc *Usecase) http.Handler {
 	if cfg.Server.HTTP.PProf.Enabled {
 		web.SetupPProf(g, &cfg.Server.HTTP.PProf.AccessIps)
 	}
+	if cfg.Server.HTTP.Metrics.Enabled {
+		web.SetupMetrics(g, &cfg.Server.HTTP.PProf.AccessIps)
+	}
+	web.SetErrorFormat(web.ErrorFormat(cfg.Server.HTTP.Errors.Format), cfg.Server.HTTP.Errors.TypeBase)
 
 	setupRouter(g, uc)
+	// 版本化迁移依赖 AutoMigrate 创建的表，放在其后执行
+	if err := uc.Version.Migrate(); err != nil {
+		return nil, err
+	}
 	// 在确认所有表迁移完成后，再更新版本记录
 	// 防止更新中断的情况，后续启动中无法更新版本号
 	uc.Version.RecordVersion()
-	return g
+	return g, nil
 }
 
 // NewUniqueID 生成唯一 id
@@ -58,3 +97,135 @@ func NewUniqueID(db *gorm.DB) uniqueid.Core {
 	store := uniqueiddb.NewDB(db).AutoMigrate(orm.GetEnabledAutoMigrate())
 	return uniqueid.NewCore(store, 6)
 }
+
+// NewTokenAPI 令牌签发与管理
+func NewTokenAPI(keys *web.KeySet, db *gorm.DB, cache conc.Cacher, events *event.Bus) tokenapi.TokenAPI {
+	return tokenapi.NewTokenAPI(db, keys, cache, events)
+}
+
+// NewWebhookAPI webhook 管理与投递，领域事件通过发件箱转为 webhook 投递
+func NewWebhookAPI(bc *conf.Bootstrap, db *gorm.DB, events *event.Bus) webhookapi.WebhookAPI {
+	api := webhookapi.NewWebhookAPI(db, WebhookConfig(bc.Webhook))
+	event.Subscribe(events, "webhook", func(ctx context.Context, e token.LoggedOutEvent) error {
+		// 令牌摘要仅供内部使用，不发送给外部
+		_, err := api.Dispatcher.Enqueue(ctx, e.Topic(), map[string]string{
+			"scope":   e.Scope,
+			"user_id": e.UserID,
+			"reason":  e.Reason,
+		})
+		return err
+	})
+	return api
+}
+
+// WebhookConfig 转换为投递配置，配置热更新时同样使用
+func WebhookConfig(c conf.Webhook) webhook.Config {
+	return webhook.Config{
+		Workers:     c.Workers,
+		Timeout:     c.Timeout.Duration(),
+		MaxAttempts: c.MaxAttempts,
+	}
+}
+
+// NewAuditAPI 审计日志，记录 Data.Audit.Tables 中的表的增删改
+func NewAuditAPI(bc *conf.Bootstrap, db *gorm.DB) (auditapi.API, error) {
+	return auditapi.New(db, audit.Config{Tables: bc.Data.Audit.Tables})
+}
+
+// NewJobAPI 后台任务，处理函数与周期任务在此注册
+func NewJobAPI(bc *conf.Bootstrap, db *gorm.DB, tokenAPI tokenapi.TokenAPI, rbacAPI rbacapi.RBACAPI, auditAPI auditapi.API) (jobapi.API, error) {
+	api := jobapi.New(db, job.Config{})
+
+	// 清理过期令牌，多副本部署时只有一个副本执行
+	job.Handle(api.Queue, "token.delete_expired", func(ctx context.Context, _ struct{}) error {
+		_, err := tokenAPI.TokenCore.DeleteExpired(ctx, time.Now())
+		return err
+	})
+	if err := api.Queue.Schedule("token.delete_expired", "@hourly", nil); err != nil {
+		return api, err
+	}
+
+	// 清理回收站，key 为表名，按 Data.Trash 配置的保留时长删除
+	purges := map[string]func(context.Context, time.Time) (int64, error){
+		"roles":  rbacAPI.RBACCore.PurgeRoles,
+		"tokens": tokenAPI.TokenCore.PurgeTokens,
+	}
+	job.Handle(api.Queue, "trash.purge", func(ctx context.Context, _ struct{}) error {
+		var errs []error
+		for table, purge := range purges {
+			retention := bc.Data.Trash.RetentionOf(table)
+			// 未配置保留时长时不清理，防止误删刚删除的数据
+			if retention <= 0 {
+				continue
+			}
+			n, err := purge(ctx, time.Now().Add(-retention))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			slog.InfoContext(ctx, "trash purged", "table", table, "rows", n)
+		}
+		return errors.Join(errs...)
+	})
+	if err := api.Queue.Schedule("trash.purge", "@daily", nil); err != nil {
+		return api, err
+	}
+
+	// 清理审计日志，保留时长为 0 时不清理
+	job.Handle(api.Queue, "audit.purge", func(ctx context.Context, _ struct{}) error {
+		retention := bc.Data.Audit.Retention.Duration()
+		if retention <= 0 {
+			return nil
+		}
+		n, err := auditAPI.AuditCore.PurgeLog(ctx, time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "audit purged", "rows", n)
+		return nil
+	})
+	if err := api.Queue.Schedule("audit.purge", "@daily", nil); err != nil {
+		return api, err
+	}
+	return api, nil
+}
+
+// NewKeySet jwt 签名密钥
+// 配置 JWT.PrivateKey 时使用非对称签名，JwtSecret 不为空则保留用于验证轮换前签发的 HS256 token
+func NewKeySet(bc *conf.Bootstrap) (*web.KeySet, error) {
+	cfg := bc.Server.HTTP.JWT
+	if cfg.PrivateKey == "" {
+		return web.NewHMACKeySet(bc.Server.HTTP.JwtSecret)
+	}
+	// 旧的 HS256 token 没有 kid，JwtSecret 作为 kid 为空的密钥保留验证，当前密钥必须指定 kid
+	if cfg.KeyID == "" {
+		return nil, errors.New("Server.HTTP.JWT.KeyID 不能为空")
+	}
+	abs := func(path string) string {
+		if filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(bc.Runtime.ConfigDir, path)
+	}
+
+	current, err := web.LoadKeyFile(cfg.KeyID, cfg.Alg, abs(cfg.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	previous := make([]*web.Key, 0, len(cfg.PreviousKeys)+1)
+	for _, v := range cfg.PreviousKeys {
+		key, err := web.LoadKeyFile(v.KeyID, v.Alg, abs(v.PublicKey))
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+	if secret := bc.Server.HTTP.JwtSecret; secret != "" {
+		key, err := web.NewHMACKey("", secret)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+	return web.NewKeySet(current, previous...)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"expvar"
 	"flag"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/ixugo/goddd/domain/version/versionapi"
 	"github.com/ixugo/goddd/internal/app"
 	"github.com/ixugo/goddd/internal/conf"
+	"github.com/ixugo/goddd/pkg/reason"
 	"github.com/ixugo/goddd/pkg/system"
 )
 
@@ -25,6 +27,9 @@ var (
 // 自定义配置目录
 var configDir = flag.String("conf", "./configs", "config directory, eg: -conf /configs/")
 
+// 输出已注册的错误，用于生成客户端的错误枚举
+var printReasons = flag.Bool("reasons", false, "print registered error reasons as json and exit")
+
 func getBuildRelease() bool {
 	v, _ := strconv.ParseBool(release)
 	return v
@@ -33,6 +38,16 @@ func getBuildRelease() bool {
 func main() {
 	flag.Parse()
 
+	// reason 在包初始化时注册，重复时已经 panic，此处输出全部错误后退出
+	if *printReasons {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reason.Registered()); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	// 初始化配置
 	var bc conf.Bootstrap
 	fileDir, _ := system.Abs(*configDir)
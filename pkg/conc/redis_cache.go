@@ -0,0 +1,114 @@
+package conc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/resp"
+)
+
+var _ Cacher = (*RedisCache)(nil)
+
+// Codec 缓存值的序列化方式
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec 默认的序列化方式
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// RedisCache 基于 RESP 协议的网络缓存，多副本部署时共享缓存与失效
+// 与 TTLCache 一致，Set/Del 失败仅记录日志，读取时回源数据库
+type RedisCache struct {
+	cli    *resp.Client
+	prefix string
+	ttl    time.Duration
+	codec  Codec
+}
+
+// RedisCacheOption 缓存配置
+type RedisCacheOption func(*RedisCache)
+
+// WithPrefix 键前缀，用于多个服务共用一个 redis
+func WithPrefix(prefix string) RedisCacheOption {
+	return func(rc *RedisCache) {
+		rc.prefix = prefix
+	}
+}
+
+// WithTTL 过期时间，<=0 表示不过期
+func WithTTL(ttl time.Duration) RedisCacheOption {
+	return func(rc *RedisCache) {
+		rc.ttl = ttl
+	}
+}
+
+// WithCodec 自定义序列化
+func WithCodec(codec Codec) RedisCacheOption {
+	return func(rc *RedisCache) {
+		rc.codec = codec
+	}
+}
+
+// NewRedisCache 默认过期时间 1 小时，使用 json 序列化
+func NewRedisCache(cli *resp.Client, opts ...RedisCacheOption) *RedisCache {
+	rc := RedisCache{
+		cli:   cli,
+		ttl:   time.Hour,
+		codec: JSONCodec{},
+	}
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	return &rc
+}
+
+// Set 设置缓存值
+func (r *RedisCache) Set(ctx context.Context, key string, value any) {
+	b, err := r.codec.Marshal(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "RedisCache.Set marshal", "key", key, "err", err)
+		return
+	}
+	if err := r.cli.Set(ctx, r.prefix+key, b, r.ttl); err != nil {
+		slog.ErrorContext(ctx, "RedisCache.Set", "key", key, "err", err)
+	}
+}
+
+// Del 删除缓存值
+func (r *RedisCache) Del(ctx context.Context, key string) {
+	if _, err := r.cli.Del(ctx, r.prefix+key); err != nil {
+		slog.ErrorContext(ctx, "RedisCache.Del", "key", key, "err", err)
+	}
+}
+
+// Get 获取缓存值，将结果反序列化到 dest 中
+func (r *RedisCache) Get(ctx context.Context, key string, dest any) error {
+	b, err := r.cli.Get(ctx, r.prefix+key)
+	if err != nil {
+		if errors.Is(err, resp.ErrNil) {
+			return ErrCacheNotFound
+		}
+		return err
+	}
+	return r.codec.Unmarshal(b, dest)
+}
+
+// SetNX 仅当键不存在时设置值
+func (r *RedisCache) SetNX(ctx context.Context, key string, value any) {
+	b, err := r.codec.Marshal(value)
+	if err != nil {
+		slog.ErrorContext(ctx, "RedisCache.SetNX marshal", "key", key, "err", err)
+		return
+	}
+	if _, err := r.cli.SetNX(ctx, r.prefix+key, b, r.ttl); err != nil {
+		slog.ErrorContext(ctx, "RedisCache.SetNX", "key", key, "err", err)
+	}
+}
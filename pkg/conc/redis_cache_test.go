@@ -0,0 +1,60 @@
+package conc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/internal/resptest"
+	"github.com/ixugo/goddd/pkg/resp"
+)
+
+func TestRedisCache(t *testing.T) {
+	srv, err := resptest.NewServer("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	cli, err := resp.NewClient(resp.Config{Addr: srv.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	type user struct {
+		ID   int
+		Name string
+	}
+	ctx := context.Background()
+	// 模拟两个副本共用一个缓存
+	a := NewRedisCache(cli, WithPrefix("app:"), WithTTL(time.Minute))
+	b := NewRedisCache(cli, WithPrefix("app:"), WithTTL(time.Minute))
+
+	a.Set(ctx, "u1", &user{ID: 1, Name: "a"})
+	var out user
+	if err := b.Get(ctx, "u1", &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != 1 || out.Name != "a" {
+		t.Fatalf("expect {1 a}, got %+v", out)
+	}
+
+	b.SetNX(ctx, "u1", &user{ID: 2})
+	if err := a.Get(ctx, "u1", &out); err != nil || out.ID != 1 {
+		t.Fatalf("expect setnx ignored, got %+v %v", out, err)
+	}
+
+	// 副本 b 的失效，副本 a 可见
+	b.Del(ctx, "u1")
+	if err := a.Get(ctx, "u1", &out); !errors.Is(err, ErrCacheNotFound) {
+		t.Fatalf("expect ErrCacheNotFound, got %v", err)
+	}
+
+	// 前缀隔离
+	c := NewRedisCache(cli, WithPrefix("other:"))
+	a.Set(ctx, "u2", &user{ID: 2})
+	if err := c.Get(ctx, "u2", &out); !errors.Is(err, ErrCacheNotFound) {
+		t.Fatalf("expect ErrCacheNotFound, got %v", err)
+	}
+}
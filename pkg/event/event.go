@@ -0,0 +1,302 @@
+// event
+// 领域事件与事务发件箱
+// Publish 将事件写入发件箱表，与业务数据处于同一事务，事务回滚时事件一并丢弃
+// Run 轮询发件箱投递给进程内订阅者，至少投递一次，失败后退避重试，超过次数进入死信
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+// Event 领域事件，通过 json 序列化写入发件箱
+// Topic 应当使用值接收器，订阅时通过零值获取
+type Event interface {
+	Topic() string
+}
+
+// Publisher 领域发布事件依赖的接口，Bus 已实现
+type Publisher interface {
+	// Transaction 开启工作单元，fn 内的 store 操作与 Publish 写入的事件在同一事务中
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// Config 投递配置，零值使用默认值
+type Config struct {
+	PollInterval time.Duration // 轮询间隔，默认 1 秒，事务提交后会立即唤醒
+	BatchSize    int           // 每次取出的数量，默认 100
+	MaxAttempts  int           // 最大投递次数，超过后进入死信，默认 10
+	MinBackoff   time.Duration // 首次重试间隔，之后翻倍，默认 1 秒
+	MaxBackoff   time.Duration // 最大重试间隔，默认 10 分钟
+	Lease        time.Duration // 单次投递的超时时间，进程崩溃后超过此时间重新投递，默认 30 秒
+	Retention    time.Duration // 已投递事件的保留时间，默认 7 天
+}
+
+func (c *Config) setDefaults() {
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 10
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 10 * time.Minute
+	}
+	if c.Lease <= 0 {
+		c.Lease = 30 * time.Second
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+}
+
+type subscriber struct {
+	name string
+	fn   func(ctx context.Context, payload []byte) error
+}
+
+var _ Publisher = (*Bus)(nil)
+
+// Bus 事件总线
+type Bus struct {
+	db   *gorm.DB
+	cfg  Config
+	wake chan struct{}
+
+	mu   sync.RWMutex
+	subs map[string][]subscriber
+}
+
+// NewBus 创建事件总线
+func NewBus(db *gorm.DB, cfg Config) *Bus {
+	cfg.setDefaults()
+	return &Bus{
+		db:   db,
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+		subs: make(map[string][]subscriber),
+	}
+}
+
+// AutoMigrate sync database
+func (b *Bus) AutoMigrate(ok bool) *Bus {
+	if !ok {
+		return b
+	}
+	if err := b.db.AutoMigrate(new(Outbox)); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Subscribe 订阅 E 类型的事件，name 在同一 topic 下唯一，用于记录投递状态
+// 应当在 Publish 之前完成订阅，发布时按当时的订阅者写入发件箱
+// 同一事件可能重复投递，fn 需要保证幂等
+func Subscribe[E Event](b *Bus, name string, fn func(ctx context.Context, e E) error) {
+	var zero E
+	topic := zero.Topic()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subs[topic] {
+		if s.name == name {
+			panic(fmt.Sprintf("event: subscriber %s already exists on %s", name, topic))
+		}
+	}
+	b.subs[topic] = append(b.subs[topic], subscriber{
+		name: name,
+		fn: func(ctx context.Context, payload []byte) error {
+			var e E
+			if err := json.Unmarshal(payload, &e); err != nil {
+				return err
+			}
+			return fn(ctx, e)
+		},
+	})
+}
+
+// Transaction implements Publisher.
+func (b *Bus) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return orm.Transaction(ctx, b.db, fn)
+}
+
+// Publish 为每个订阅者写入一条发件箱记录，ctx 处于 orm.Transaction 中时加入该事务
+// 没有订阅者的事件不会写入
+func (b *Bus) Publish(ctx context.Context, events ...Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := orm.Now()
+	rows := make([]*Outbox, 0, len(events))
+	for _, e := range events {
+		subs := b.subs[e.Topic()]
+		if len(subs) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		for _, s := range subs {
+			rows = append(rows, &Outbox{
+				Topic:         e.Topic(),
+				Subscriber:    s.name,
+				Payload:       string(payload),
+				Status:        StatusPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := orm.Conn(ctx, b.db).Create(&rows).Error; err != nil {
+		return err
+	}
+	orm.AfterCommit(ctx, b.notify)
+	return nil
+}
+
+// notify 唤醒 Run 立即投递
+func (b *Bus) notify(context.Context) {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 投递发件箱中的事件，直到 ctx 结束
+// 多副本同时运行时，通过条件更新保证同一时刻只有一个副本投递同一条记录
+func (b *Bus) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.cfg.PollInterval)
+	defer ticker.Stop()
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		for {
+			n, err := b.deliver(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "event deliver", "err", err)
+			}
+			// 取满一批说明还有积压，继续投递
+			if err != nil || n < b.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.wake:
+		case <-ticker.C:
+		case <-purge.C:
+			if err := b.purge(ctx); err != nil {
+				slog.ErrorContext(ctx, "event purge", "err", err)
+			}
+		}
+	}
+}
+
+// deliver 投递一批到期的事件，返回取出的数量
+func (b *Bus) deliver(ctx context.Context) (int, error) {
+	var rows []*Outbox
+	if err := b.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", StatusPending, time.Now()).
+		Order("id ASC").Limit(b.cfg.BatchSize).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+	for _, row := range rows {
+		if ctx.Err() != nil {
+			return len(rows), ctx.Err()
+		}
+		if err := b.deliverOne(ctx, row); err != nil {
+			return len(rows), err
+		}
+	}
+	return len(rows), nil
+}
+
+func (b *Bus) deliverOne(ctx context.Context, row *Outbox) error {
+	// 抢占租约，投递期间其它副本不会取出此记录，进程崩溃后租约到期重新投递
+	claim := b.db.WithContext(ctx).Model(new(Outbox)).
+		Where("id = ? AND status = ? AND attempts = ?", row.ID, StatusPending, row.Attempts).
+		Updates(map[string]any{"attempts": row.Attempts + 1, "next_attempt_at": time.Now().Add(b.cfg.Lease)})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		return claim.Error
+	}
+	row.Attempts++
+
+	err := b.call(ctx, row)
+	now := time.Now()
+	data := map[string]any{"updated_at": now}
+	switch {
+	case err == nil:
+		data["status"] = StatusDelivered
+		data["last_error"] = ""
+	case row.Attempts >= b.cfg.MaxAttempts:
+		data["status"] = StatusDead
+		data["last_error"] = err.Error()
+		slog.ErrorContext(ctx, "event dead letter", "id", row.ID, "topic", row.Topic, "subscriber", row.Subscriber, "err", err)
+	default:
+		data["next_attempt_at"] = now.Add(b.backoff(row.Attempts))
+		data["last_error"] = err.Error()
+		slog.WarnContext(ctx, "event retry", "id", row.ID, "topic", row.Topic, "subscriber", row.Subscriber, "attempts", row.Attempts, "err", err)
+	}
+	// 投递完成后使用不会被取消的 ctx 记录结果，避免关闭时重复投递
+	return b.db.WithContext(context.WithoutCancel(ctx)).Model(new(Outbox)).Where("id = ?", row.ID).Updates(data).Error
+}
+
+func (b *Bus) call(ctx context.Context, row *Outbox) (err error) {
+	b.mu.RLock()
+	var fn func(context.Context, []byte) error
+	for _, s := range b.subs[row.Topic] {
+		if s.name == row.Subscriber {
+			fn = s.fn
+			break
+		}
+	}
+	b.mu.RUnlock()
+	if fn == nil {
+		return fmt.Errorf("event: subscriber %s not found on %s", row.Subscriber, row.Topic)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.Lease)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("PANIC[%v] TRACE[%s]", r, debug.Stack())
+		}
+	}()
+	return fn(ctx, []byte(row.Payload))
+}
+
+// backoff 按投递次数翻倍
+func (b *Bus) backoff(attempts int) time.Duration {
+	d := b.cfg.MinBackoff
+	for i := 1; i < attempts && d < b.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, b.cfg.MaxBackoff)
+}
+
+// purge 删除超过保留时间的已投递事件
+func (b *Bus) purge(ctx context.Context) error {
+	return b.db.WithContext(ctx).Where("status = ? AND updated_at < ?", StatusDelivered, time.Now().Add(-b.cfg.Retention)).
+		Delete(new(Outbox)).Error
+}
@@ -0,0 +1,127 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/ixugo/goddd/pkg/orm"
+	"gorm.io/gorm"
+)
+
+type userDeleted struct {
+	UserID string `json:"user_id"`
+}
+
+func (userDeleted) Topic() string { return "user.deleted" }
+
+func newTestBus(t *testing.T) *Bus {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return NewBus(db, Config{MaxAttempts: 3, MinBackoff: time.Nanosecond}).AutoMigrate(true)
+}
+
+func TestBus(t *testing.T) {
+	bus := newTestBus(t)
+	ctx := context.Background()
+
+	var received []string
+	Subscribe(bus, "ok", func(_ context.Context, e userDeleted) error {
+		received = append(received, e.UserID)
+		return nil
+	})
+	var failed int
+	Subscribe(bus, "fail", func(context.Context, userDeleted) error {
+		failed++
+		if failed == 2 {
+			panic("boom")
+		}
+		return errors.New("unavailable")
+	})
+
+	// 事务回滚时事件一并丢弃
+	errRollback := errors.New("rollback")
+	err := bus.Transaction(ctx, func(ctx context.Context) error {
+		if err := bus.Publish(ctx, userDeleted{UserID: "1"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	if err := bus.Transaction(ctx, func(ctx context.Context) error {
+		return bus.Publish(ctx, userDeleted{UserID: "2"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		if _, err := bus.deliver(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(received) != 1 || received[0] != "2" {
+		t.Fatalf("expect [2], got %v", received)
+	}
+	// 订阅者各自重试，失败的订阅者不影响已投递的订阅者
+	if failed != 3 {
+		t.Fatalf("expect 3 attempts, got %d", failed)
+	}
+
+	dead, total, err := bus.DeadLetters(ctx, &FindDeadLetterInput{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || dead[0].Subscriber != "fail" || dead[0].Attempts != 3 || dead[0].LastError != "unavailable" {
+		t.Fatalf("unexpected dead letters %d %+v", total, dead)
+	}
+
+	n, err := bus.Retry(ctx, dead[0].ID)
+	if err != nil || n != 1 {
+		t.Fatalf("retry %d %v", n, err)
+	}
+	if _, err := bus.deliver(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if failed != 4 || len(received) != 1 {
+		t.Fatalf("expect only failed subscriber retried, got %d %v", failed, received)
+	}
+}
+
+func TestBusRun(t *testing.T) {
+	bus := newTestBus(t)
+	bus.cfg.PollInterval = time.Hour
+
+	var received atomic.Int32
+	done := make(chan struct{})
+	Subscribe(bus, "ok", func(context.Context, userDeleted) error {
+		if received.Add(1) == 2 {
+			close(done)
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bus.Run(ctx)
+
+	// 提交后立即唤醒，无需等待轮询
+	if err := orm.Transaction(ctx, bus.db, func(ctx context.Context) error {
+		return bus.Publish(ctx, userDeleted{UserID: "1"}, userDeleted{UserID: "2"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expect 2 events delivered, got %d", received.Load())
+	}
+}
@@ -0,0 +1,71 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/orm"
+)
+
+// 投递状态
+const (
+	StatusPending   = "pending"   // 等待投递或重试
+	StatusDelivered = "delivered" // 已投递
+	StatusDead      = "dead"      // 超过最大投递次数，需要人工处理
+)
+
+// Outbox 发件箱，每个订阅者一条记录，各自独立重试
+type Outbox struct {
+	ID            int64    `gorm:"primaryKey" json:"id"`
+	Topic         string   `gorm:"column:topic;notNull;default:'';comment:事件主题" json:"topic"`                                                          // 事件主题
+	Subscriber    string   `gorm:"column:subscriber;notNull;default:'';comment:订阅者" json:"subscriber"`                                                 // 订阅者
+	Payload       string   `gorm:"column:payload;notNull;default:'';comment:事件内容" json:"payload"`                                                      // 事件内容，json
+	Status        string   `gorm:"column:status;notNull;default:'';index:idx_event_outbox_status_next;comment:投递状态" json:"status"`                     // 投递状态
+	Attempts      int      `gorm:"column:attempts;notNull;default:0;comment:投递次数" json:"attempts"`                                                     // 投递次数
+	NextAttemptAt orm.Time `gorm:"column:next_attempt_at;notNull;default:CURRENT_TIMESTAMP;index:idx_event_outbox_status_next" json:"next_attempt_at"` // 下次投递时间
+	LastError     string   `gorm:"column:last_error;notNull;default:'';comment:最后一次失败原因" json:"last_error"`                                            // 最后一次失败原因
+	CreatedAt     orm.Time `gorm:"column:created_at;notNull;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     orm.Time `gorm:"column:updated_at;notNull;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName database table name
+func (*Outbox) TableName() string {
+	return "event_outbox"
+}
+
+// FindDeadLetterInput 死信查询
+type FindDeadLetterInput struct {
+	Topic      string `form:"topic"`      // 事件主题
+	Subscriber string `form:"subscriber"` // 订阅者
+}
+
+// DeadLetters 超过最大投递次数的事件，按时间倒序
+func (b *Bus) DeadLetters(ctx context.Context, in *FindDeadLetterInput, page orm.Pager) ([]*Outbox, int64, error) {
+	query := orm.NewQuery(4).Where("status = ?", StatusDead)
+	if in.Topic != "" {
+		query.Where("topic = ?", in.Topic)
+	}
+	if in.Subscriber != "" {
+		query.Where("subscriber = ?", in.Subscriber)
+	}
+	query.OrderBy("id DESC")
+
+	items := make([]*Outbox, 0, 10)
+	total, err := orm.ListWithContext(ctx, b.db, &items, page, query.Encode()...)
+	return items, total, err
+}
+
+// Retry 将死信重新放回队列，重置投递次数，返回放回的数量
+func (b *Bus) Retry(ctx context.Context, ids ...int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	now := time.Now()
+	result := orm.Conn(ctx, b.db).Model(new(Outbox)).Where("id IN ? AND status = ?", ids, StatusDead).
+		Updates(map[string]any{"status": StatusPending, "attempts": 0, "next_attempt_at": now, "updated_at": now})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	orm.AfterCommit(ctx, b.notify)
+	return result.RowsAffected, nil
+}
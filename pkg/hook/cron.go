@@ -0,0 +1,286 @@
+package hook
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 计算下一次执行时间
+type Schedule interface {
+	// Next 返回 t 之后的下一次执行时间，找不到时返回零值
+	Next(t time.Time) time.Time
+}
+
+// ParseCron 解析 cron 表达式
+// 支持 5 段(分 时 日 月 周)与 6 段(秒 分 时 日 月 周)，每段支持 * ? , - / 以及 JAN-DEC、SUN-SAT
+// 支持 @yearly(@annually) @monthly @weekly @daily(@midnight) @hourly @every 5m
+// 默认使用本地时区，可以通过前缀指定，例如 "CRON_TZ=Asia/Shanghai 0 9 * * *"
+// 日与周同时指定时满足其一即可，与标准 cron 一致
+//
+// 夏令时：跳过的时刻顺延到切换后，例如 02:30 在当天不存在时于 03:30 执行；
+// 重复的时刻只执行一次
+func ParseCron(spec string) (Schedule, error) {
+	raw := spec
+	loc := time.Local
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("cron: invalid time zone %q: %w", name, err)
+		}
+		loc, spec = l, strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		return parseDescriptor(raw, spec, loc)
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, got %d in %q", len(fields), raw)
+	}
+
+	s := cronSchedule{loc: loc}
+	var err error
+	for i, b := range cronBounds {
+		var star bool
+		if s.fields[i], star, err = parseField(fields[i], b); err != nil {
+			return nil, fmt.Errorf("cron: %w in %q", err, raw)
+		}
+		switch i {
+		case fieldDom:
+			s.domStar = star
+		case fieldDow:
+			s.dowStar = star
+		}
+	}
+	return &s, nil
+}
+
+// MustParseCron 解析失败时 panic，适用于常量表达式
+func MustParseCron(spec string) Schedule {
+	s, err := ParseCron(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Every 固定间隔执行，不受时区与夏令时影响
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func parseDescriptor(raw, spec string, loc *time.Location) (Schedule, error) {
+	if v, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("cron: invalid duration in %q", raw)
+		}
+		return Every(d), nil
+	}
+	expr, ok := map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}[spec]
+	if !ok {
+		return nil, fmt.Errorf("cron: unknown descriptor %q", raw)
+	}
+	s, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	s.(*cronSchedule).loc = loc
+	return s, nil
+}
+
+const (
+	fieldSecond = iota
+	fieldMinute
+	fieldHour
+	fieldDom
+	fieldMonth
+	fieldDow
+)
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var cronBounds = [6]bounds{
+	{min: 0, max: 59},
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	// 7 同样表示周日
+	{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// parseField 返回位图，star 表示未限制取值
+func parseField(field string, b bounds) (bits uint64, star bool, err error) {
+	for part := range strings.SplitSeq(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, false, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case expr == "*" || expr == "?":
+			lo, hi = b.min, b.max
+			star = star || !hasStep
+		default:
+			loStr, hiStr, isRange := strings.Cut(expr, "-")
+			if lo, err = parseValue(loStr, b); err != nil {
+				return 0, false, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(hiStr, b); err != nil {
+					return 0, false, err
+				}
+			} else if hasStep {
+				// a/n 表示从 a 开始到最大值
+				hi = b.max
+			}
+		}
+		if lo > hi {
+			return 0, false, fmt.Errorf("invalid range %q", part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	// 周日写作 7 时与 0 等价
+	if b.max == 7 && bits&(1<<7) != 0 {
+		bits |= 1
+	}
+	return bits, star, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %q out of range [%d, %d]", s, b.min, b.max)
+	}
+	return v, nil
+}
+
+type cronSchedule struct {
+	fields  [6]uint64
+	domStar bool
+	dowStar bool
+	loc     *time.Location
+}
+
+func (s *cronSchedule) match(field, v int) bool {
+	return s.fields[field]&(1<<uint(v)) != 0
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.match(fieldDom, t.Day())
+	dow := s.match(fieldDow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next 按墙上时间逐级匹配，每个墙上时间只对应一个时间点，因此重复的时刻只执行一次
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	after := t.In(s.loc)
+	// 最多查找 5 年，避免 2 月 30 日这类永远不满足的表达式死循环
+	limit := after.Year() + 5
+	day := time.Date(after.Year(), after.Month(), after.Day(), 12, 0, 0, 0, s.loc)
+	for ; day.Year() <= limit; day = day.AddDate(0, 0, 1) {
+		if !s.match(fieldMonth, int(day.Month())) {
+			// 跳到下个月 1 日，AddDate 之后为 1 日
+			day = time.Date(day.Year(), day.Month()+1, 0, 12, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(day) {
+			continue
+		}
+		if next, ok := s.nextInDay(day, after); ok {
+			return next.In(t.Location())
+		}
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) nextInDay(day, after time.Time) (time.Time, bool) {
+	y, m, d := day.Date()
+	date := func(h, mi, sec int) time.Time {
+		t := time.Date(y, m, d, h, mi, sec, 0, s.loc)
+		if t.Hour() == h && t.Minute() == mi {
+			return t
+		}
+		// 夏令时跳过的时刻，按切换前的时差计算，即顺延到切换后
+		_, offset := t.Add(-12 * time.Hour).Zone()
+		return time.Date(y, m, d, h, mi, sec, 0, time.UTC).Add(-time.Duration(offset) * time.Second).In(s.loc)
+	}
+	for h := 0; h < 24; h++ {
+		if !s.match(fieldHour, h) || !date(h, 59, 59).After(after) {
+			continue
+		}
+		for mi := 0; mi < 60; mi++ {
+			if !s.match(fieldMinute, mi) || !date(h, mi, 59).After(after) {
+				continue
+			}
+			for sec := 0; sec < 60; sec++ {
+				if !s.match(fieldSecond, sec) {
+					continue
+				}
+				if next := date(h, mi, sec); next.After(after) {
+					return next, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// NextTimeSchedule 配合 UseTimer 使用，返回距离下一次执行的时长
+// 表达式永远不满足时返回最大时长，不再执行
+func NextTimeSchedule(s Schedule) func() time.Duration {
+	return func() time.Duration {
+		now := time.Now()
+		next := s.Next(now)
+		if next.IsZero() {
+			return math.MaxInt64
+		}
+		return next.Sub(now)
+	}
+}
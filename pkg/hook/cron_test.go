@@ -0,0 +1,145 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseCron(t *testing.T) {
+	now := time.Date(2024, 3, 6, 10, 20, 30, 0, time.UTC) // 周三
+	for _, tc := range []struct {
+		spec string
+		next time.Time
+	}{
+		{"TZ=UTC * * * * *", time.Date(2024, 3, 6, 10, 21, 0, 0, time.UTC)},
+		{"TZ=UTC */15 * * * * *", time.Date(2024, 3, 6, 10, 20, 45, 0, time.UTC)},
+		{"TZ=UTC 0 9 * * MON-FRI", time.Date(2024, 3, 7, 9, 0, 0, 0, time.UTC)},
+		{"TZ=UTC 30 10-12 * * *", time.Date(2024, 3, 6, 10, 30, 0, 0, time.UTC)},
+		{"TZ=UTC 0 0 1,15 * *", time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC 0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC 0 0 * * 7", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		// 日与周同时指定时满足其一即可
+		{"TZ=UTC 0 0 1 * FRI", time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC 0 0 1 JAN ?", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=Asia/Shanghai 0 9 * * *", time.Date(2024, 3, 7, 1, 0, 0, 0, time.UTC)},
+		{"TZ=UTC @hourly", time.Date(2024, 3, 6, 11, 0, 0, 0, time.UTC)},
+		{"TZ=UTC @daily", time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC @weekly", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC @monthly", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"TZ=UTC @yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", now.Add(90 * time.Second)},
+	} {
+		s, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.spec, err)
+		}
+		if next := s.Next(now); !next.Equal(tc.next) {
+			t.Errorf("%s: expect %s, got %s", tc.spec, tc.next, next)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every -1s", "@often", "TZ=Mars/Base * * * * *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("%q: expect error", spec)
+		}
+	}
+	if next := MustParseCron("TZ=UTC 0 0 30 2 *").Next(now); !next.IsZero() {
+		t.Errorf("expect zero, got %s", next)
+	}
+}
+
+func TestParseCronDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2024-03-10 02:00 跳到 03:00，02:30 顺延到 03:30
+	s := MustParseCron("CRON_TZ=America/New_York 30 2 * * *")
+	next := s.Next(time.Date(2024, 3, 10, 1, 0, 0, 0, loc))
+	if expect := time.Date(2024, 3, 10, 3, 30, 0, 0, loc); !next.Equal(expect) {
+		t.Errorf("spring forward: expect %s, got %s", expect, next)
+	}
+	if next = s.Next(next); !next.Equal(time.Date(2024, 3, 11, 2, 30, 0, 0, loc)) {
+		t.Errorf("spring forward: expect next day, got %s", next)
+	}
+
+	// 2024-11-03 02:00 回到 01:00，01:30 只执行一次
+	s = MustParseCron("CRON_TZ=America/New_York 30 1 * * *")
+	first := s.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, loc))
+	if first.Hour() != 1 || first.Minute() != 30 || first.Day() != 3 {
+		t.Fatalf("fall back: unexpected %s", first)
+	}
+	if next = s.Next(first); !next.Equal(time.Date(2024, 11, 4, 1, 30, 0, 0, loc)) {
+		t.Errorf("fall back: expect next day, got %s", next)
+	}
+
+	// 按天执行的任务在切换日仍是当地零点
+	s = MustParseCron("CRON_TZ=America/New_York @daily")
+	next = s.Next(time.Date(2024, 3, 9, 12, 0, 0, 0, loc))
+	if expect := time.Date(2024, 3, 10, 0, 0, 0, 0, loc); !next.Equal(expect) {
+		t.Errorf("daily: expect %s, got %s", expect, next)
+	}
+	if next = s.Next(next); next.Sub(time.Date(2024, 3, 10, 0, 0, 0, 0, loc)) != 23*time.Hour {
+		t.Errorf("daily: expect 23h day, got %s", next)
+	}
+}
+
+func TestNextTimeTomorrow(t *testing.T) {
+	d := NextTimeTomorrow(time.Now().Hour(), time.Now().Minute(), time.Now().Second())
+	if d < 23*time.Hour || d > 25*time.Hour {
+		t.Fatalf("expect about 24h, got %s", d)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler("test_scheduler")
+	var fast, slow, panics atomic.Int32
+	release := make(chan struct{})
+	s.AddSchedule("fast", "@every 10ms", Every(10*time.Millisecond), func(context.Context) error {
+		fast.Add(1)
+		return errors.New("unavailable")
+	})
+	// 上一次尚未结束时跳过
+	s.AddSchedule("slow", "@every 10ms", Every(10*time.Millisecond), func(context.Context) error {
+		slow.Add(1)
+		<-release
+		return nil
+	})
+	s.AddSchedule("panic", "@every 10ms", Every(10*time.Millisecond), func(context.Context) error {
+		panics.Add(1)
+		panic("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	cancel()
+	<-done
+
+	if fast.Load() < 3 || panics.Load() < 3 || slow.Load() != 1 {
+		t.Fatalf("unexpected runs fast %d slow %d panic %d", fast.Load(), slow.Load(), panics.Load())
+	}
+	status := s.Status()
+	if len(status) != 3 || status[0].Name != "fast" || status[2].Name != "slow" {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	if v := status[0]; v.LastError != "unavailable" || v.Running || v.NextRun.IsZero() {
+		t.Errorf("unexpected fast status %+v", v)
+	}
+	if v := status[1]; v.LastError == "" {
+		t.Errorf("expect panic recorded, got %+v", v)
+	}
+	if v := status[2]; v.Runs != 1 || v.Skipped == 0 {
+		t.Errorf("expect slow skipped, got %+v", v)
+	}
+}
@@ -0,0 +1,184 @@
+package hook
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/conc"
+)
+
+// CronStatus 定时任务的执行情况
+type CronStatus struct {
+	Name         string    `json:"name"`
+	Spec         string    `json:"spec"`
+	Running      bool      `json:"running"`       // 是否正在执行
+	Runs         int64     `json:"runs"`          // 执行次数
+	Skipped      int64     `json:"skipped"`       // 上一次尚未结束而跳过的次数
+	LastRun      time.Time `json:"last_run"`      // 最后一次开始时间
+	LastDuration string    `json:"last_duration"` // 最后一次耗时
+	LastError    string    `json:"last_error"`    // 最后一次失败原因，成功时为空
+	NextRun      time.Time `json:"next_run"`      // 下一次执行时间
+}
+
+type cronEntry struct {
+	schedule Schedule
+	fn       func(context.Context) error
+	status   CronStatus
+}
+
+// Scheduler 进程内的定时任务，同一任务上一次尚未结束时跳过本次
+// 仅在当前进程执行，多副本部署且只需执行一次时，应当使用持久化的任务队列，或在 fn 中使用 orm.Locker.TryRun
+type Scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*cronEntry
+	wake    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewScheduler name 不为空时，通过 expvar 发布各任务的执行情况
+func NewScheduler(name string) *Scheduler {
+	s := Scheduler{
+		entries: make(map[string]*cronEntry),
+		wake:    make(chan struct{}, 1),
+	}
+	if name != "" && expvar.Get(name) == nil {
+		expvar.Publish(name, expvar.Func(func() any { return s.Status() }))
+	}
+	return &s
+}
+
+// Add 添加任务，spec 格式参考 ParseCron，同名任务会被替换
+func (s *Scheduler) Add(name, spec string, fn func(ctx context.Context) error) error {
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	s.AddSchedule(name, spec, schedule, fn)
+	return nil
+}
+
+// AddSchedule 添加任务，spec 仅用于展示
+func (s *Scheduler) AddSchedule(name, spec string, schedule Schedule, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	entry := cronEntry{schedule: schedule, fn: fn, status: CronStatus{Name: name, Spec: spec}}
+	if old, ok := s.entries[name]; ok {
+		// 保留执行记录，正在执行的旧任务结束后只更新旧记录
+		entry.status = old.status
+		entry.status.Spec = spec
+		entry.status.Running = false
+	}
+	entry.status.NextRun = schedule.Next(time.Now())
+	s.entries[name] = &entry
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Remove 移除任务，正在执行的不受影响
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	delete(s.entries, name)
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Status 各任务的执行情况，按名称排序
+func (s *Scheduler) Status() []CronStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]CronStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, e.status)
+	}
+	slices.SortFunc(out, func(a, b CronStatus) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 按计划执行任务，直到 ctx 结束，退出前等待正在执行的任务
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.wg.Wait()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+		timer.Reset(s.runDue(ctx))
+	}
+}
+
+// runDue 启动到期的任务，返回距离下一次执行的时长
+func (s *Scheduler) runDue(ctx context.Context) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	wait := time.Duration(-1)
+	for name, e := range s.entries {
+		if next := e.status.NextRun; !next.IsZero() && !next.After(now) {
+			if e.status.Running {
+				e.status.Skipped++
+				slog.WarnContext(ctx, "cron skipped", "name", name, "last_run", e.status.LastRun)
+			} else {
+				s.start(ctx, e)
+			}
+			e.status.NextRun = e.schedule.Next(now)
+		}
+		if next := e.status.NextRun; !next.IsZero() && (wait < 0 || next.Sub(now) < wait) {
+			wait = next.Sub(now)
+		}
+	}
+	if wait < 0 {
+		// 没有待执行的任务，等待 Add 唤醒
+		return time.Hour
+	}
+	return wait
+}
+
+func (s *Scheduler) start(ctx context.Context, e *cronEntry) {
+	e.status.Running = true
+	e.status.Runs++
+	e.status.LastRun = time.Now()
+
+	s.wg.Add(1)
+	conc.GoSafe(func() {
+		var err error
+		defer s.wg.Done()
+		defer func() {
+			r := recover()
+			if r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+			s.mu.Lock()
+			e.status.Running = false
+			e.status.LastDuration = time.Since(e.status.LastRun).String()
+			e.status.LastError = ""
+			if err != nil {
+				e.status.LastError = err.Error()
+			}
+			s.mu.Unlock()
+			// 交由 GoSafe 记录堆栈
+			if r != nil {
+				panic(r)
+			}
+		}()
+		if err = e.fn(ctx); err != nil {
+			slog.ErrorContext(ctx, "cron", "name", e.status.Name, "err", err)
+		}
+	})
+}
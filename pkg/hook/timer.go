@@ -23,7 +23,7 @@ func UseTimer(ctx context.Context, fn func(), nextTime func() time.Duration) {
 // hour: 0-23, minute: 0-59, second: 0-59
 // 返回距离明天指定时间的时长
 func NextTimeTomorrow(hour, minute, second int) time.Duration {
-	now := time.Now().AddDate(0, 0, 1)
+	now := time.Now()
 	tomorrow := now.AddDate(0, 0, 1)
 	nextTime := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), hour, minute, second, 0, now.Location())
 	return nextTime.Sub(now)
@@ -0,0 +1,295 @@
+// httpclient
+// 调用其它服务的 http 客户端，提供超时、幂等请求的重试、按 host 熔断与链路传递
+// 响应为 reason.Error 格式时还原为 reason.CustomError，跨服务后 errors.Is(err, reason.ErrNotFound) 仍然成立
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/trace"
+)
+
+// HeaderIdempotencyKey 非幂等的请求携带此请求头时，允许重试
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// Config 客户端配置，零值使用默认值
+type Config struct {
+	// Timeout 单次请求的超时时间，默认 10s；ctx 的截止时间更早时以 ctx 为准
+	Timeout time.Duration
+	// MaxRetries 失败后的最大重试次数，默认 2，小于 0 时不重试
+	MaxRetries int
+	// MinBackoff 首次重试的等待时间，之后翻倍并加入随机抖动，默认 100ms
+	MinBackoff time.Duration
+	// MaxBackoff 重试等待时间的上限，默认 2s
+	MaxBackoff time.Duration
+	// FailureThreshold 同一 host 连续失败次数达到阈值后熔断，默认 5
+	FailureThreshold int
+	// OpenTimeout 熔断持续时间，之后放行一个探测请求，默认 30s
+	OpenTimeout time.Duration
+	// Transport 为 nil 时使用 http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+func (c *Config) setDefaults() {
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 2
+	}
+	c.MaxRetries = max(c.MaxRetries, 0)
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = 100 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 2 * time.Second
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+}
+
+// Client 可以被多个 goroutine 共用
+type Client struct {
+	cfg      Config
+	client   *http.Client
+	breakers sync.Map
+}
+
+// New 创建客户端，请求经过 trace.Transport，向下游传递 traceparent
+func New(cfg Config) *Client {
+	cfg.setDefaults()
+	return &Client{
+		cfg:    cfg,
+		client: &http.Client{Transport: trace.Transport(cfg.Transport)},
+	}
+}
+
+func (c *Client) breaker(host string) *breaker {
+	if v, ok := c.breakers.Load(host); ok {
+		return v.(*breaker)
+	}
+	v, _ := c.breakers.LoadOrStore(host, &breaker{threshold: c.cfg.FailureThreshold, openTimeout: c.cfg.OpenTimeout})
+	return v.(*breaker)
+}
+
+// Do 发送请求，返回最后一次的响应，非 2xx 不视为错误，可使用 DecodeError 转换
+// 幂等的请求(GET/HEAD/OPTIONS/PUT/DELETE 或携带 Idempotency-Key)在网络错误与 429/502/503/504 时重试
+// 网络错误返回 reason.ErrNetworkError 或 reason.ErrTimeout，熔断时返回 reason.ErrServiceUnavailable，均可通过 errors.Is 判断
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	cb := c.breaker(req.URL.Host)
+
+	for attempt := 0; ; attempt++ {
+		if !cb.allow(time.Now()) {
+			return nil, reason.ErrServiceUnavailable.SetHTTPStatus(http.StatusServiceUnavailable).Withf("circuit breaker is open: %s", req.URL.Host)
+		}
+		resp, err := c.do(req, attempt)
+		// 调用方取消的请求不计入熔断
+		if ctx.Err() == nil {
+			cb.done(err == nil && resp.StatusCode < 500, time.Now())
+		} else {
+			cb.cancel()
+		}
+
+		if attempt >= c.cfg.MaxRetries || !retryable || ctx.Err() != nil || !shouldRetry(resp, err) {
+			if err != nil {
+				return nil, wrapNetErr(ctx, err)
+			}
+			return resp, nil
+		}
+
+		wait := backoff(c.cfg, attempt)
+		if resp != nil {
+			if v := retryAfter(resp); v > 0 {
+				wait = min(max(wait, v), c.cfg.MaxBackoff)
+			}
+			// 读完响应体以便复用连接
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		slog.WarnContext(ctx, "httpclient retry", "method", req.Method, "host", req.URL.Host, "attempt", attempt+1, "wait", wait, "err", errOrStatus(resp, err))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, wrapNetErr(ctx, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// do 发送一次请求，单次请求的超时在读完响应体后释放
+func (c *Client) do(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.cfg.Timeout)
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+	resp, err := c.client.Do(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// JSON in 不为 nil 时编码为请求体，2xx 时将响应解码到 out，否则返回 DecodeError 的结果
+func (c *Client) JSON(ctx context.Context, method, url string, in, out any, opts ...RequestOption) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return reason.ErrJSON.With(err.Error())
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return reason.ErrBadRequest.With(err.Error())
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return DecodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return reason.ErrJSON.With(err.Error())
+	}
+	return nil
+}
+
+// Get 请求并解码响应
+func (c *Client) Get(ctx context.Context, url string, out any, opts ...RequestOption) error {
+	return c.JSON(ctx, http.MethodGet, url, nil, out, opts...)
+}
+
+// Post 非幂等，需要重试时使用 WithIdempotencyKey
+func (c *Client) Post(ctx context.Context, url string, in, out any, opts ...RequestOption) error {
+	return c.JSON(ctx, http.MethodPost, url, in, out, opts...)
+}
+
+// Put 请求并解码响应
+func (c *Client) Put(ctx context.Context, url string, in, out any, opts ...RequestOption) error {
+	return c.JSON(ctx, http.MethodPut, url, in, out, opts...)
+}
+
+// Delete 请求并解码响应
+func (c *Client) Delete(ctx context.Context, url string, out any, opts ...RequestOption) error {
+	return c.JSON(ctx, http.MethodDelete, url, nil, out, opts...)
+}
+
+// RequestOption 修改请求，例如设置请求头
+type RequestOption func(*http.Request)
+
+// WithHeader 设置请求头
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+// WithIdempotencyKey 非幂等的请求允许重试，下游应当按 key 去重
+func WithIdempotencyKey(key string) RequestOption {
+	return WithHeader(HeaderIdempotencyKey, key)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff 按重试次数翻倍，在 [d/2, d] 之间随机，避免多个客户端同时重试
+func backoff(cfg Config, attempt int) time.Duration {
+	d := cfg.MinBackoff
+	for i := 0; i < attempt && d < cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, cfg.MaxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter 解析秒数形式的 Retry-After
+func retryAfter(resp *http.Response) time.Duration {
+	v, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || v < 0 {
+		return 0
+	}
+	return time.Duration(v) * time.Second
+}
+
+// wrapNetErr 网络错误转为 reason 错误，可以直接由 web.Fail 返回
+func wrapNetErr(ctx context.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return reason.ErrTimeout.SetHTTPStatus(http.StatusGatewayTimeout).With(err.Error())
+	}
+	return reason.ErrNetworkError.SetHTTPStatus(http.StatusBadGateway).With(err.Error())
+}
+
+func errOrStatus(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
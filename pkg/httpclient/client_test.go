@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/reason"
+	"github.com/ixugo/goddd/pkg/trace"
+)
+
+func TestClient(t *testing.T) {
+	var calls atomic.Int32
+	var traceparent atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent.Store(r.Header.Get(trace.HeaderTraceparent))
+		switch r.URL.Path {
+		case "/flaky":
+			// 前两次失败，第三次成功
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"ok"}`))
+		case "/post":
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"reason":"ErrNotFound","msg":"用户不存在","details":["id=1"],"trace_id":"x"}`))
+		case "/plain":
+			w.WriteHeader(http.StatusForbidden)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Timeout: 50 * time.Millisecond, FailureThreshold: 100})
+	ctx := context.Background()
+
+	var out struct{ Name string }
+	if err := c.Get(ctx, srv.URL+"/flaky", &out); err != nil || out.Name != "ok" || calls.Load() != 3 {
+		t.Fatalf("expect retried success, got %v %+v %d", err, out, calls.Load())
+	}
+	if _, ok := trace.ParseTraceparent(traceparent.Load().(string), ""); !ok {
+		t.Fatalf("expect traceparent, got %q", traceparent.Load())
+	}
+
+	// POST 不重试，携带 Idempotency-Key 时重试
+	calls.Store(0)
+	if err := c.Post(ctx, srv.URL+"/post", map[string]int{"a": 1}, nil); !errors.Is(err, reason.ErrServer) || calls.Load() != 1 {
+		t.Fatalf("expect ErrServer without retry, got %v %d", err, calls.Load())
+	}
+	calls.Store(0)
+	if err := c.Post(ctx, srv.URL+"/post", map[string]int{"a": 1}, nil, WithIdempotencyKey("k1")); err == nil || calls.Load() != 3 {
+		t.Fatalf("expect 3 attempts, got %v %d", err, calls.Load())
+	}
+
+	// 还原下游的 reason
+	err := c.Get(ctx, srv.URL+"/missing", nil)
+	if !errors.Is(err, reason.ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+	if e := err.(reason.CustomError); e.GetHTTPCode() != 404 || e.GetMessage() != "用户不存在" || len(e.GetDetails()) != 1 {
+		t.Fatalf("unexpected %+v", e)
+	}
+	if err := c.Get(ctx, srv.URL+"/plain", nil); !errors.Is(err, reason.ErrPermissionDenied) {
+		t.Fatalf("expect ErrPermissionDenied, got %v", err)
+	}
+
+	// 单次请求超时
+	if err := c.Get(ctx, srv.URL+"/slow", nil); !errors.Is(err, reason.ErrTimeout) {
+		t.Fatalf("expect ErrTimeout, got %v", err)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{MaxRetries: -1, FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	ctx := context.Background()
+	for range 2 {
+		if err := c.Get(ctx, srv.URL, nil); !errors.Is(err, reason.ErrServer) {
+			t.Fatalf("expect ErrServer, got %v", err)
+		}
+	}
+	// 熔断后不发送请求
+	if err := c.Get(ctx, srv.URL, nil); !errors.Is(err, reason.ErrServiceUnavailable) || calls.Load() != 2 {
+		t.Fatalf("expect circuit open, got %v %d", err, calls.Load())
+	}
+
+	// 超过 OpenTimeout 后探测成功，恢复
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	for range 2 {
+		if err := c.Get(ctx, srv.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("expect 4 calls, got %d", calls.Load())
+	}
+}
+
+func TestBreaker_CancelProbe(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Query().Has("slow") {
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	c := New(Config{MaxRetries: -1, FailureThreshold: 1, OpenTimeout: 50 * time.Millisecond})
+	if err := c.Get(context.Background(), srv.URL, nil); !errors.Is(err, reason.ErrServer) {
+		t.Fatalf("expect ErrServer, got %v", err)
+	}
+
+	// 探测请求被调用方取消，不应一直占用探测名额
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Get(ctx, srv.URL+"?slow=1", nil); err == nil {
+		t.Fatal("expect canceled probe failed")
+	}
+	if err := c.Get(context.Background(), srv.URL, nil); err != nil {
+		t.Fatalf("expect next probe allowed, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+const maxErrorBody = 64 << 10
+
+// DecodeError 非 2xx 的响应转为 reason.CustomError，不关闭响应体
+// 响应为 web.Fail 输出的 {"reason","msg","details"} 时保留原有的 reason，errors.Is(err, reason.ErrNotFound) 等判断跨服务仍然成立
+// 其它响应按状态码对应到常用错误，msg 为响应中的 msg 或状态描述
+func DecodeError(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var out reason.Error
+	_ = json.Unmarshal(b, &out)
+	if out.Reason != "" {
+		out.HTTPStatus = resp.StatusCode
+		return &out
+	}
+
+	msg := out.Msg
+	if msg == "" {
+		msg = strings.TrimSpace(resp.Status)
+	}
+	return statusError(resp.StatusCode).SetMsg(msg).SetHTTPStatus(resp.StatusCode)
+}
+
+// statusError 没有 reason 时按状态码对应
+func statusError(code int) reason.CustomError {
+	switch code {
+	case http.StatusUnauthorized:
+		return reason.ErrUnauthorizedToken
+	case http.StatusForbidden:
+		return reason.ErrPermissionDenied
+	case http.StatusNotFound:
+		return reason.ErrNotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return reason.ErrTimeout
+	case http.StatusRequestEntityTooLarge:
+		return reason.ErrContentTooLarge
+	case http.StatusUnsupportedMediaType:
+		return reason.ErrUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return reason.ErrTooManyRequests
+	case http.StatusPreconditionFailed:
+		return reason.ErrVersionConflict
+	case http.StatusPreconditionRequired:
+		return reason.ErrPreconditionRequired
+	case http.StatusServiceUnavailable:
+		return reason.ErrServiceUnavailable
+	}
+	if code >= 500 {
+		return reason.ErrServer
+	}
+	return reason.ErrBadRequest
+}
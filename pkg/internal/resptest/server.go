@@ -0,0 +1,291 @@
+// resptest
+// 进程内的 RESP 服务，供 pkg 下的单元测试使用
+package resptest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server 进程内的 RESP 服务，仅实现缓存常用命令
+type Server struct {
+	lis      net.Listener
+	password string
+
+	mu   sync.Mutex
+	data map[string]memoryItem
+
+	wg sync.WaitGroup
+}
+
+type memoryItem struct {
+	value    string
+	expireAt time.Time
+}
+
+func (m memoryItem) expired(now time.Time) bool {
+	return !m.expireAt.IsZero() && !now.Before(m.expireAt)
+}
+
+// NewServer 监听 addr 并启动服务，addr 为空时随机端口监听 127.0.0.1
+func NewServer(addr, password string) (*Server, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := Server{
+		lis:      lis,
+		password: password,
+		data:     make(map[string]memoryItem),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return &s, nil
+}
+
+// Addr 监听地址
+func (s *Server) Addr() string {
+	return s.lis.Addr().String()
+}
+
+// Close 停止服务
+func (s *Server) Close() error {
+	err := s.lis.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		nc, err := s.lis.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(nc)
+	}
+}
+
+func (s *Server) handle(nc net.Conn) {
+	defer nc.Close()
+	r := bufio.NewReader(nc)
+	w := bufio.NewWriter(nc)
+	authed := s.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		cmd := strings.ToUpper(args[0])
+		switch {
+		case cmd == "QUIT":
+			writeSimple(w, "OK")
+			_ = w.Flush()
+			return
+		case cmd == "AUTH":
+			if len(args) == 2 && args[1] == s.password {
+				authed = true
+				writeSimple(w, "OK")
+			} else {
+				writeError(w, "WRONGPASS invalid password")
+			}
+		case !authed:
+			writeError(w, "NOAUTH Authentication required.")
+		default:
+			s.exec(w, cmd, args[1:])
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) exec(w *bufio.Writer, cmd string, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+
+	switch cmd {
+	case "PING":
+		writeSimple(w, "PONG")
+	case "SELECT":
+		writeSimple(w, "OK")
+	case "GET":
+		if len(args) != 1 {
+			writeError(w, "ERR wrong number of arguments for 'get' command")
+			return
+		}
+		item, ok := s.data[args[0]]
+		if !ok || item.expired(now) {
+			delete(s.data, args[0])
+			writeBulk(w, nil)
+			return
+		}
+		writeBulk(w, &item.value)
+	case "SET":
+		s.set(w, now, args)
+	case "DEL":
+		var n int64
+		for _, k := range args {
+			if item, ok := s.data[k]; ok {
+				delete(s.data, k)
+				if !item.expired(now) {
+					n++
+				}
+			}
+		}
+		writeInt(w, n)
+	case "EXISTS":
+		var n int64
+		for _, k := range args {
+			if item, ok := s.data[k]; ok && !item.expired(now) {
+				n++
+			}
+		}
+		writeInt(w, n)
+	case "PTTL":
+		if len(args) != 1 {
+			writeError(w, "ERR wrong number of arguments for 'pttl' command")
+			return
+		}
+		item, ok := s.data[args[0]]
+		switch {
+		case !ok || item.expired(now):
+			writeInt(w, -2)
+		case item.expireAt.IsZero():
+			writeInt(w, -1)
+		default:
+			writeInt(w, item.expireAt.Sub(now).Milliseconds())
+		}
+	case "FLUSHDB", "FLUSHALL":
+		s.data = make(map[string]memoryItem)
+		writeSimple(w, "OK")
+	default:
+		writeError(w, fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(cmd)))
+	}
+}
+
+// set 支持 SET key value [NX|XX] [EX seconds|PX milliseconds]
+func (s *Server) set(w *bufio.Writer, now time.Time, args []string) {
+	if len(args) < 2 {
+		writeError(w, "ERR wrong number of arguments for 'set' command")
+		return
+	}
+	key, item := args[0], memoryItem{value: args[1]}
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				writeError(w, "ERR syntax error")
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				writeError(w, "ERR invalid expire time in 'set' command")
+				return
+			}
+			unit := time.Millisecond
+			if strings.EqualFold(args[i], "EX") {
+				unit = time.Second
+			}
+			item.expireAt = now.Add(time.Duration(n) * unit)
+			i++
+		default:
+			writeError(w, "ERR syntax error")
+			return
+		}
+	}
+	old, exists := s.data[key]
+	exists = exists && !old.expired(now)
+	if (nx && exists) || (xx && !exists) {
+		writeBulk(w, nil)
+		return
+	}
+	s.data[key] = item
+	writeSimple(w, "OK")
+}
+
+// readCommand 读取客户端发送的 RESP 数组命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		// 兼容 inline 命令，例如 telnet 调试
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for range n {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("resp: expect bulk string")
+		}
+		l, err := strconv.Atoi(line[1:])
+		if err != nil || l < 0 {
+			return nil, errors.New("resp: invalid bulk length")
+		}
+		b := make([]byte, l+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args = append(args, string(b[:l]))
+	}
+	return args, nil
+}
+
+func writeSimple(w *bufio.Writer, s string) {
+	_, _ = w.WriteString("+" + s + "\r\n")
+}
+
+func writeError(w *bufio.Writer, s string) {
+	_, _ = w.WriteString("-" + s + "\r\n")
+}
+
+func writeInt(w *bufio.Writer, n int64) {
+	_, _ = w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func writeBulk(w *bufio.Writer, s *string) {
+	if s == nil {
+		_, _ = w.WriteString("$-1\r\n")
+		return
+	}
+	_, _ = w.WriteString("$" + strconv.Itoa(len(*s)) + "\r\n" + *s + "\r\n")
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("resp: invalid line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
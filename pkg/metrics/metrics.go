@@ -0,0 +1,177 @@
+// metrics
+// 精简的指标注册表，以 Prometheus 文本格式或 OpenMetrics 格式输出
+// 提供 Counter、Gauge、Histogram 与采集时读取的 GaugeFunc/CounterFunc
+package metrics
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认的耗时分布，单位秒
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// ExponentialBuckets 从 start 开始，每个桶为上一个的 factor 倍，共 count 个
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	out := make([]float64, count)
+	for i := range out {
+		out[i] = start
+		start *= factor
+	}
+	return out
+}
+
+// atomicFloat 以 bit 存储的 float64
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := f.bits.Load()
+		if f.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	f.bits.Store(math.Float64bits(v))
+}
+
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// Counter 只增不减的计数
+type Counter struct {
+	v atomicFloat
+}
+
+// Inc 加 1
+func (c *Counter) Inc() {
+	c.v.add(1)
+}
+
+// Add v 小于 0 时忽略
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		c.v.add(v)
+	}
+}
+
+// Gauge 可增可减的瞬时值
+type Gauge struct {
+	v atomicFloat
+}
+
+func (g *Gauge) Set(v float64) { g.v.set(v) }
+func (g *Gauge) Add(v float64) { g.v.add(v) }
+func (g *Gauge) Inc()          { g.v.add(1) }
+func (g *Gauge) Dec()          { g.v.add(-1) }
+
+// Histogram 分布统计，桶内计数在输出时累加
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64
+	sum     atomicFloat
+	count   atomic.Uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]atomic.Uint64, len(buckets))}
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(v float64) {
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i].Add(1)
+	}
+	h.sum.add(v)
+	h.count.Add(1)
+}
+
+// vec 按标签值区分的指标集合
+type vec[T any] struct {
+	labels []string
+	newFn  func() *T
+	mu     sync.RWMutex
+	series map[string]*labeled[T]
+}
+
+type labeled[T any] struct {
+	values []string
+	metric *T
+}
+
+func newVec[T any](labels []string, fn func() *T) *vec[T] {
+	return &vec[T]{labels: labels, newFn: fn, series: make(map[string]*labeled[T])}
+}
+
+// with 标签值的数量需要与注册时的标签一致，缺少的补空串
+func (v *vec[T]) with(values ...string) *T {
+	values = fixValues(values, len(v.labels))
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s.metric
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.series[key]; ok {
+		return s.metric
+	}
+	s = &labeled[T]{values: values, metric: v.newFn()}
+	v.series[key] = s
+	return s.metric
+}
+
+// each 按标签值排序遍历，输出稳定
+func (v *vec[T]) each(fn func(values []string, m *T)) {
+	v.mu.RLock()
+	items := make([]*labeled[T], 0, len(v.series))
+	for _, s := range v.series {
+		items = append(items, s)
+	}
+	v.mu.RUnlock()
+	slices.SortFunc(items, func(a, b *labeled[T]) int {
+		return slices.Compare(a.values, b.values)
+	})
+	for _, s := range items {
+		fn(s.values, s.metric)
+	}
+}
+
+func fixValues(values []string, n int) []string {
+	if len(values) == n {
+		return values
+	}
+	out := make([]string, n)
+	copy(out, values)
+	return out
+}
+
+// CounterVec 带标签的 Counter
+type CounterVec struct{ *vec[Counter] }
+
+// With 按标签值获取 Counter，顺序与注册时一致
+func (c *CounterVec) With(values ...string) *Counter { return c.with(values...) }
+
+// GaugeVec 带标签的 Gauge
+type GaugeVec struct{ *vec[Gauge] }
+
+// With 按标签值获取 Gauge，顺序与注册时一致
+func (g *GaugeVec) With(values ...string) *Gauge { return g.with(values...) }
+
+// HistogramVec 带标签的 Histogram
+type HistogramVec struct{ *vec[Histogram] }
+
+// With 按标签值获取 Histogram，顺序与注册时一致
+func (h *HistogramVec) With(values ...string) *Histogram { return h.with(values...) }
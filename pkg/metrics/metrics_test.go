@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("http_responses_total", "Total responses.", "route", "class")
+	requests.With("/a", "2xx").Inc()
+	requests.With("/a", "2xx").Add(2)
+	requests.With("/b", "5xx").Inc()
+	requests.With("/b", "5xx").Add(-1)
+
+	// 重复注册返回同一个指标
+	if r.NewCounter("http_responses_total", "", "route", "class").With("/a", "2xx") != requests.With("/a", "2xx") {
+		t.Fatal("expect same counter")
+	}
+
+	inflight := r.NewGauge("http_requests_in_flight", "In flight.")
+	inflight.With().Inc()
+	inflight.With().Inc()
+	inflight.With().Dec()
+
+	duration := r.NewHistogram("http_request_duration_seconds", "Duration.", []float64{1, 0.1}, "route")
+	for _, v := range []float64{0.05, 0.5, 0.5, 3} {
+		duration.With(`/"q"`).Observe(v)
+	}
+	r.NewGaugeFunc("db_pool_open_connections", "Open connections.", []string{"pool"}, func(observe Observe) {
+		observe(3, "primary")
+	})
+
+	var b strings.Builder
+	if err := r.Write(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	text := b.String()
+	for _, line := range []string{
+		`# TYPE http_responses_total counter`,
+		`http_responses_total{route="/a",class="2xx"} 3`,
+		`http_responses_total{route="/b",class="5xx"} 1`,
+		`http_requests_in_flight 1`,
+		`http_request_duration_seconds_bucket{route="/\"q\"",le="0.1"} 1`,
+		`http_request_duration_seconds_bucket{route="/\"q\"",le="1"} 3`,
+		`http_request_duration_seconds_bucket{route="/\"q\"",le="+Inf"} 4`,
+		`http_request_duration_seconds_sum{route="/\"q\""} 4.05`,
+		`http_request_duration_seconds_count{route="/\"q\""} 4`,
+		`db_pool_open_connections{pool="primary"} 3`,
+	} {
+		if !strings.Contains(text, line+"\n") {
+			t.Fatalf("expect %q in\n%s", line, text)
+		}
+	}
+	if strings.Contains(text, "# EOF") {
+		t.Fatal("unexpected EOF in text format")
+	}
+
+	// OpenMetrics 格式
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, req)
+	body := w.Body.String()
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/openmetrics-text") {
+		t.Fatalf("unexpected content type %s", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, "# TYPE http_responses counter\n") || !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatalf("unexpected openmetrics\n%s", body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic when labels differ")
+		}
+	}()
+	r.NewCounter("http_responses_total", "", "route")
+}
+
+func TestDefault(t *testing.T) {
+	var b strings.Builder
+	if err := Default.Write(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "go_goroutines ") || !strings.Contains(b.String(), "go_memstats_heap_alloc_bytes ") {
+		t.Fatalf("expect runtime metrics\n%s", b.String())
+	}
+}
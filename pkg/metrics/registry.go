@@ -0,0 +1,289 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+
+	contentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Observe 采集函数通过 observe 上报当前值，标签值顺序与注册时一致
+type Observe func(v float64, labelValues ...string)
+
+type family struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+	// write 输出全部样本
+	write func(w *writer)
+	// key 用于判断重复注册的指标是否一致
+	key string
+}
+
+// Registry 指标注册表，同名指标重复注册时返回已注册的指标，类型或标签不一致时 panic
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+	metrics  map[string]any
+}
+
+// Default 默认注册表，已包含 go 运行时指标
+var Default = newDefault()
+
+// NewRegistry 空注册表
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family), metrics: make(map[string]any)}
+}
+
+func newDefault() *Registry {
+	r := NewRegistry()
+	RegisterRuntime(r)
+	return r
+}
+
+// register 注册或返回已存在的指标
+func (r *Registry) register(name, help, typ string, labels []string, extra string, create func(f *family) any) any {
+	key := typ + "|" + strings.Join(labels, ",") + "|" + extra
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.key != key {
+			panic(fmt.Sprintf("metrics: %s already registered with different type or labels", name))
+		}
+		return r.metrics[name]
+	}
+	f := family{name: name, help: help, typ: typ, labels: labels, key: key}
+	m := create(&f)
+	r.families[name] = &f
+	r.metrics[name] = m
+	return m
+}
+
+// NewCounter 注册计数，名称按惯例以 _total 结尾
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	return r.register(name, help, typeCounter, labels, "", func(f *family) any {
+		v := CounterVec{newVec(labels, func() *Counter { return new(Counter) })}
+		f.write = func(w *writer) {
+			v.each(func(values []string, c *Counter) {
+				w.sample(name, labels, values, c.v.load())
+			})
+		}
+		return &v
+	}).(*CounterVec)
+}
+
+// NewGauge 注册瞬时值
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	return r.register(name, help, typeGauge, labels, "", func(f *family) any {
+		v := GaugeVec{newVec(labels, func() *Gauge { return new(Gauge) })}
+		f.write = func(w *writer) {
+			v.each(func(values []string, g *Gauge) {
+				w.sample(name, labels, values, g.v.load())
+			})
+		}
+		return &v
+	}).(*GaugeVec)
+}
+
+// NewHistogram 注册分布统计，buckets 为空时使用 DefBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	extra := fmt.Sprint(buckets)
+	return r.register(name, help, typeHistogram, labels, extra, func(f *family) any {
+		v := HistogramVec{newVec(labels, func() *Histogram { return newHistogram(buckets) })}
+		bucketLabels := append(slices.Clone(labels), "le")
+		f.write = func(w *writer) {
+			v.each(func(values []string, h *Histogram) {
+				bucketValues := append(slices.Clone(values), "")
+				var cumulative uint64
+				for i, b := range h.buckets {
+					cumulative += h.counts[i].Load()
+					bucketValues[len(values)] = formatFloat(b)
+					w.sample(name+"_bucket", bucketLabels, bucketValues, float64(cumulative))
+				}
+				count := h.count.Load()
+				bucketValues[len(values)] = "+Inf"
+				w.sample(name+"_bucket", bucketLabels, bucketValues, float64(count))
+				w.sample(name+"_sum", labels, values, h.sum.load())
+				w.sample(name+"_count", labels, values, float64(count))
+			})
+		}
+		return &v
+	}).(*HistogramVec)
+}
+
+// NewGaugeFunc 输出时调用 fn 读取当前值，用于连接池、运行时等已有的统计
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, fn func(observe Observe)) {
+	r.newFunc(name, help, typeGauge, labels, fn)
+}
+
+// NewCounterFunc 同 NewGaugeFunc，fn 上报的值应当只增不减
+func (r *Registry) NewCounterFunc(name, help string, labels []string, fn func(observe Observe)) {
+	r.newFunc(name, help, typeCounter, labels, fn)
+}
+
+func (r *Registry) newFunc(name, help, typ string, labels []string, fn func(observe Observe)) {
+	r.register(name, help, typ, labels, "func", func(f *family) any {
+		f.write = func(w *writer) {
+			fn(func(v float64, values ...string) {
+				w.sample(name, labels, fixValues(values, len(labels)), v)
+			})
+		}
+		return f
+	})
+}
+
+// Write 输出全部指标，openMetrics 为 true 时使用 OpenMetrics 格式
+func (r *Registry) Write(out io.Writer, openMetrics bool) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	slices.SortFunc(families, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	w := writer{Writer: bufio.NewWriter(out)}
+	for _, f := range families {
+		name := f.name
+		// OpenMetrics 中计数的指标族名称不包含 _total 后缀
+		if openMetrics && f.typ == typeCounter {
+			name = strings.TrimSuffix(name, "_total")
+		}
+		if f.help != "" {
+			fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(f.help))
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, f.typ)
+		f.write(&w)
+	}
+	if openMetrics {
+		_, _ = w.WriteString("# EOF\n")
+	}
+	return w.Flush()
+}
+
+// Handler 根据 Accept 请求头选择 OpenMetrics 或 Prometheus 文本格式
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		openMetrics := strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text")
+		contentType := contentTypeText
+		if openMetrics {
+			contentType = contentTypeOpenMetrics
+		}
+		rw.Header().Set("Content-Type", contentType)
+		_ = r.Write(rw, openMetrics)
+	})
+}
+
+type writer struct {
+	*bufio.Writer
+}
+
+func (w *writer) sample(name string, labels, values []string, v float64) {
+	_, _ = w.WriteString(name)
+	if len(labels) > 0 {
+		_ = w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				_ = w.WriteByte(',')
+			}
+			_, _ = w.WriteString(l)
+			_, _ = w.WriteString(`="`)
+			_, _ = w.WriteString(escapeLabel(values[i]))
+			_ = w.WriteByte('"')
+		}
+		_ = w.WriteByte('}')
+	}
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(formatFloat(v))
+	_ = w.WriteByte('\n')
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelReplacer.Replace(s) }
+func escapeHelp(s string) string  { return helpReplacer.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	memStatsMu sync.Mutex
+	memStats   runtime.MemStats
+	memStatsAt time.Time
+)
+
+// readMemStats 同一次输出中多个指标共用，避免重复 STW
+func readMemStats() runtime.MemStats {
+	memStatsMu.Lock()
+	defer memStatsMu.Unlock()
+	if time.Since(memStatsAt) > time.Second {
+		runtime.ReadMemStats(&memStats)
+		memStatsAt = time.Now()
+	}
+	return memStats
+}
+
+// RegisterRuntime 注册 go 运行时指标，Default 已注册
+func RegisterRuntime(r *Registry) {
+	start := float64(time.Now().Unix())
+	r.NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", nil, func(observe Observe) {
+		observe(start)
+	})
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", nil, func(observe Observe) {
+		observe(float64(runtime.NumGoroutine()))
+	})
+	r.NewGaugeFunc("go_memstats_sys_bytes", "Number of bytes obtained from system.", nil, func(observe Observe) {
+		observe(float64(readMemStats().Sys))
+	})
+	r.NewGaugeFunc("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", nil, func(observe Observe) {
+		observe(float64(readMemStats().HeapAlloc))
+	})
+	r.NewGaugeFunc("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", nil, func(observe Observe) {
+		observe(float64(readMemStats().HeapInuse))
+	})
+	r.NewGaugeFunc("go_memstats_heap_objects", "Number of allocated objects.", nil, func(observe Observe) {
+		observe(float64(readMemStats().HeapObjects))
+	})
+	r.NewCounterFunc("go_gc_cycles_total", "Number of completed GC cycles.", nil, func(observe Observe) {
+		observe(float64(readMemStats().NumGC))
+	})
+	r.NewCounterFunc("go_gc_pause_seconds_total", "Total GC pause time in seconds.", nil, func(observe Observe) {
+		observe(float64(readMemStats().PauseTotalNs) / 1e9)
+	})
+}
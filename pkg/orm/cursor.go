@@ -0,0 +1,276 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor 游标被篡改、已过期或与排序条件不匹配
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ScrollPager 游标分页，web.PagerFilter 已实现
+// 排序列应当在 SortSafelist 中，且不能为 NULL
+type ScrollPager interface {
+	Limit() int
+	GetCursor() string
+	SortColumn() (string, bool)
+	SortDirection() string
+}
+
+var scrollSchemas sync.Map
+
+var cursorKey = struct {
+	sync.RWMutex
+	key []byte
+}{key: randomCursorKey()}
+
+func randomCursorKey() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}
+
+// SetCursorSecret 设置游标签名秘钥
+// 默认每次启动随机生成，重启后旧游标失效，多副本部署时各副本应当使用相同的秘钥
+func SetCursorSecret(secret string) {
+	sum := sha256.Sum256([]byte("orm.cursor:" + secret))
+	cursorKey.Lock()
+	defer cursorKey.Unlock()
+	cursorKey.key = sum[:]
+}
+
+// cursor 上一页最后一条记录的排序值
+type cursor struct {
+	Column string        `json:"c"`
+	Desc   bool          `json:"d"`
+	Values []cursorValue `json:"v"` // 排序列与主键的值
+}
+
+// cursorValue 数据库驱动的值类型各不相同，记录类型以便原样还原
+type cursorValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+func newCursorValue(v any) (cursorValue, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return cursorValue{}, err
+		}
+		v = dv
+	}
+	rv := reflect.ValueOf(v)
+	var typ string
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		typ, v = "i", rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		typ, v = "u", rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		typ, v = "f", rv.Float()
+	case reflect.String:
+		typ, v = "s", rv.String()
+	case reflect.Bool:
+		typ = "b"
+	default:
+		switch val := v.(type) {
+		case time.Time:
+			typ, v = "t", val.Format(time.RFC3339Nano)
+		case []byte:
+			typ = "x"
+		default:
+			return cursorValue{}, fmt.Errorf("cursor: unsupported type %T", v)
+		}
+	}
+	b, err := json.Marshal(v)
+	return cursorValue{Type: typ, Value: b}, err
+}
+
+func (c cursorValue) value() (any, error) {
+	var err error
+	switch c.Type {
+	case "i":
+		var v int64
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case "u":
+		var v uint64
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case "f":
+		var v float64
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case "s":
+		var v string
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case "b":
+		var v bool
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case "t":
+		var s string
+		if err = json.Unmarshal(c.Value, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case "x":
+		var v []byte
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	}
+	return nil, ErrInvalidCursor
+}
+
+// encode base64(json).base64(hmac)
+func (c *cursor) encode() (string, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b) + "." + base64.RawURLEncoding.EncodeToString(signCursor(b)), nil
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	payload, sign, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	b, err1 := base64.RawURLEncoding.DecodeString(payload)
+	mac, err2 := base64.RawURLEncoding.DecodeString(sign)
+	if err1 != nil || err2 != nil || !hmac.Equal(mac, signCursor(b)) {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil || len(c.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func signCursor(b []byte) []byte {
+	cursorKey.RLock()
+	defer cursorKey.RUnlock()
+	h := hmac.New(sha256.New, cursorKey.key)
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// ScrollWithContext 游标分页，不执行 COUNT，返回下一页的游标，没有更多数据时为空串
+// 按 SortColumn 排序，主键作为第二排序条件保证顺序稳定，未指定排序时按主键倒序
+func ScrollWithContext[T any](ctx context.Context, db *gorm.DB, out *[]*T, p ScrollPager, opts ...QueryOption) (string, error) {
+	sch, err := schema.Parse(new(T), &scrollSchemas, db.NamingStrategy)
+	if err != nil {
+		return "", err
+	}
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return "", fmt.Errorf("cursor: %s has no primary key", sch.Name)
+	}
+
+	cur := cursor{Column: pk.DBName, Desc: true}
+	if column, ok := p.SortColumn(); ok {
+		field := sch.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return "", fmt.Errorf("cursor: unknown column %s", column)
+		}
+		cur.Column, cur.Desc = field.DBName, p.SortDirection() == "DESC"
+	}
+	fields := []*schema.Field{sch.LookUpField(cur.Column)}
+	if cur.Column != pk.DBName {
+		fields = append(fields, pk)
+	}
+
+	db = Conn(ctx, db).Model(new(T))
+	for _, opt := range opts {
+		db = opt(db)
+	}
+
+	if s := p.GetCursor(); s != "" {
+		prev, err := decodeCursor(s)
+		if err != nil {
+			return "", err
+		}
+		// 排序条件变化后，旧游标没有意义
+		if prev.Column != cur.Column || prev.Desc != cur.Desc || len(prev.Values) != len(fields) {
+			return "", ErrInvalidCursor
+		}
+		values := make([]any, len(prev.Values))
+		for i, v := range prev.Values {
+			if values[i], err = v.value(); err != nil {
+				return "", ErrInvalidCursor
+			}
+		}
+		db = db.Where(keysetCondition(sch.Table, fields, values, cur.Desc))
+	}
+	for _, f := range fields {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Table: sch.Table, Name: f.DBName}, Desc: cur.Desc})
+	}
+
+	limit := p.Limit()
+	// 多查一条，用于判断是否还有下一页
+	if err := db.Limit(limit + 1).Find(out).Error; err != nil {
+		return "", err
+	}
+	if len(*out) <= limit {
+		return "", nil
+	}
+	*out = (*out)[:limit]
+
+	last := reflect.ValueOf((*out)[limit-1])
+	cur.Values = make([]cursorValue, len(fields))
+	for i, f := range fields {
+		v, _ := f.ValueOf(ctx, last)
+		if cur.Values[i], err = newCursorValue(v); err != nil {
+			return "", err
+		}
+	}
+	return cur.encode()
+}
+
+// keysetCondition 生成 (a > ? OR (a = ? AND id > ?)) 的条件，倒序时使用小于
+// 未使用行值比较 (a, id) > (?, ?)，保证 sqlite 与 postgres 行为一致
+func keysetCondition(table string, fields []*schema.Field, values []any, desc bool) clause.Expression {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	column := func(f *schema.Field) clause.Column {
+		return clause.Column{Table: table, Name: f.DBName}
+	}
+	var exprs []clause.Expression
+	for i := range fields {
+		and := make([]clause.Expression, 0, i+1)
+		for j := range i {
+			and = append(and, clause.Eq{Column: column(fields[j]), Value: values[j]})
+		}
+		and = append(and, clause.Expr{SQL: "? " + op + " ?", Vars: []any{column(fields[i]), values[i]}})
+		exprs = append(exprs, clause.And(and...))
+	}
+	return clause.Or(exprs...)
+}
+
+// Scroll 游标分页，参考 ScrollWithContext
+func (t Type[T]) Scroll(ctx context.Context, out *[]*T, p ScrollPager, opts ...QueryOption) (string, error) {
+	return ScrollWithContext(ctx, t.db, out, p, opts...)
+}
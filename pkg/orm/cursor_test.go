@@ -0,0 +1,108 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type scrollItem struct {
+	ID        int    `gorm:"primaryKey"`
+	Name      string `gorm:"notNull;default:''"`
+	Score     int    `gorm:"notNull;default:0"`
+	CreatedAt Time   `gorm:"notNull;default:CURRENT_TIMESTAMP"`
+}
+
+type scrollPager struct {
+	size   int
+	sort   string
+	cursor string
+}
+
+func (p scrollPager) Limit() int        { return p.size }
+func (p scrollPager) GetCursor() string { return p.cursor }
+func (p scrollPager) SortColumn() (string, bool) {
+	return strings.TrimPrefix(p.sort, "-"), p.sort != ""
+}
+
+func (p scrollPager) SortDirection() string {
+	if strings.HasPrefix(p.sort, "-") {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+func TestScrollWithContext(t *testing.T) {
+	db := newTestDB(t, new(scrollItem))
+	now := time.Now()
+	for i := range 25 {
+		// 分数与时间大量重复，验证主键作为第二排序条件
+		item := scrollItem{Name: "n", Score: i % 3, CreatedAt: Time{Time: now.Add(time.Duration(i/4) * time.Millisecond)}}
+		if err := db.Create(&item).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+
+	for _, sort := range []string{"", "id", "score", "-score", "created_at", "-created_at"} {
+		t.Run("sort_"+sort, func(t *testing.T) {
+			seen := make(map[int]bool)
+			p := scrollPager{size: 7, sort: sort}
+			var pages int
+			for {
+				var items []*scrollItem
+				next, err := ScrollWithContext(ctx, db, &items, p, Where("name = ?", "n"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, v := range items {
+					if seen[v.ID] {
+						t.Fatalf("duplicated id %d", v.ID)
+					}
+					seen[v.ID] = true
+				}
+				pages++
+				if next == "" {
+					break
+				}
+				p.cursor = next
+			}
+			if len(seen) != 25 || pages != 4 {
+				t.Fatalf("expect 25 items in 4 pages, got %d in %d", len(seen), pages)
+			}
+		})
+	}
+
+	var items []*scrollItem
+	next, _ := ScrollWithContext(ctx, db, &items, scrollPager{size: 10, sort: "score"})
+	if items[0].Score != 0 || items[len(items)-1].Score != 1 {
+		t.Fatalf("unexpected order %d..%d", items[0].Score, items[len(items)-1].Score)
+	}
+
+	// 篡改与排序条件不一致的游标都应当拒绝
+	for _, p := range []scrollPager{
+		{size: 10, sort: "score", cursor: "x" + next},
+		{size: 10, sort: "-score", cursor: next},
+		{size: 10, sort: "score", cursor: "abc"},
+	} {
+		if _, err := ScrollWithContext(ctx, db, &items, p); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expect ErrInvalidCursor, got %v", err)
+		}
+	}
+
+	// 更换签名秘钥后，旧游标失效
+	cursorKey.RLock()
+	prev := cursorKey.key
+	cursorKey.RUnlock()
+	t.Cleanup(func() {
+		cursorKey.Lock()
+		defer cursorKey.Unlock()
+		cursorKey.key = prev
+	})
+	SetCursorSecret("another")
+	if _, err := ScrollWithContext(ctx, db, &items, scrollPager{size: 10, sort: "score", cursor: next}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expect ErrInvalidCursor, got %v", err)
+	}
+}
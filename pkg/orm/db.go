@@ -48,6 +48,12 @@ func New(dialector gorm.Dialector, cfg Config, opts ...GormOption) (*gorm.DB, er
 	if err != nil {
 		return nil, err
 	}
+	if err := db.Use(TenantPlugin{}); err != nil {
+		return nil, err
+	}
+	if err := db.Use(TracePlugin{}); err != nil {
+		return nil, err
+	}
 
 	// 检查连接状态
 	sqlDB, err := db.DB()
@@ -0,0 +1,270 @@
+package orm
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FilterOp 过滤运算符
+type FilterOp string
+
+const (
+	FilterEq   FilterOp = "eq"
+	FilterNe   FilterOp = "ne"
+	FilterGt   FilterOp = "gt"
+	FilterGte  FilterOp = "gte"
+	FilterLt   FilterOp = "lt"
+	FilterLte  FilterOp = "lte"
+	FilterIn   FilterOp = "in"
+	FilterLike FilterOp = "like" // 包含，% 与 _ 按普通字符处理
+)
+
+var filterOperators = map[FilterOp]string{
+	FilterEq:  "=",
+	FilterNe:  "<>",
+	FilterGt:  ">",
+	FilterGte: ">=",
+	FilterLt:  "<",
+	FilterLte: "<=",
+}
+
+// 限制条件数量，防止构造超大的查询
+const (
+	maxFilterConditions = 20
+	maxFilterValues     = 100
+)
+
+// Filter 一个过滤条件
+type Filter struct {
+	Field  string
+	Op     FilterOp
+	Values []string // in 运算符可以有多个值，其它运算符只有一个
+}
+
+// FilterField 允许过滤的字段
+type FilterField struct {
+	Column string                    // 数据库列名，为空时与字段名相同
+	Ops    []FilterOp                // 允许的运算符，为空时允许除 like 以外的全部运算符
+	Parse  func(string) (any, error) // 值转换，为空时使用字符串，参考 ParseFilterInt 等函数
+}
+
+// FilterSafelist 字段名到过滤规则，未在其中的字段不允许过滤
+type FilterSafelist map[string]FilterField
+
+// ParseFilter 解析过滤表达式，条件之间使用 and 连接，例如
+// scope eq "web" and expired_at gt 1700000000000 and id in (1, 2)
+// 字符串值包含空格时需要使用双引号，支持 \" 与 \\ 转义
+func ParseFilter(s string) ([]Filter, error) {
+	tokens, err := tokenizeFilter(s)
+	if err != nil {
+		return nil, err
+	}
+	var out []Filter
+	for i := 0; i < len(tokens); {
+		if len(out) > 0 {
+			if !strings.EqualFold(tokens[i].text, "and") || tokens[i].quoted {
+				return nil, fmt.Errorf("filter: expect and, got %q", tokens[i].text)
+			}
+			i++
+		}
+		if i+2 >= len(tokens) {
+			return nil, fmt.Errorf("filter: incomplete condition")
+		}
+		f := Filter{Field: tokens[i].text, Op: FilterOp(strings.ToLower(tokens[i+1].text))}
+		if !f.Op.valid() {
+			return nil, fmt.Errorf("filter: unknown operator %q", tokens[i+1].text)
+		}
+		i += 2
+		if f.Op == FilterIn {
+			if tokens[i].text != "(" || tokens[i].quoted {
+				return nil, fmt.Errorf("filter: in expects (")
+			}
+			for i++; ; i += 2 {
+				if i+1 >= len(tokens) {
+					return nil, fmt.Errorf("filter: in expects )")
+				}
+				f.Values = append(f.Values, tokens[i].text)
+				if sep := tokens[i+1]; sep.text == ")" && !sep.quoted {
+					i += 2
+					break
+				} else if sep.text != "," || sep.quoted {
+					return nil, fmt.Errorf("filter: in expects , or )")
+				}
+			}
+		} else {
+			f.Values = []string{tokens[i].text}
+			i++
+		}
+		out = append(out, f)
+		if len(out) > maxFilterConditions {
+			return nil, fmt.Errorf("filter: too many conditions")
+		}
+	}
+	return out, nil
+}
+
+type filterToken struct {
+	text   string
+	quoted bool
+}
+
+func tokenizeFilter(s string) ([]filterToken, error) {
+	var out []filterToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			out = append(out, filterToken{text: string(c)})
+			i++
+		case c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, fmt.Errorf("filter: unterminated string")
+			}
+			i++
+			out = append(out, filterToken{text: b.String(), quoted: true})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t(),\"", rune(s[i])) {
+				i++
+			}
+			out = append(out, filterToken{text: s[start:i]})
+		}
+	}
+	return out, nil
+}
+
+// ParseFilterBrackets 解析 expired_at[gte]=1700000000000 形式的查询参数
+// 仅处理带有 [op] 的参数，in 运算符的多个值使用逗号分隔
+func ParseFilterBrackets(query map[string][]string) ([]Filter, error) {
+	out := make([]Filter, 0, 2)
+	for key, values := range query {
+		field, op, ok := strings.Cut(key, "[")
+		if !ok || !strings.HasSuffix(op, "]") || field == "" {
+			continue
+		}
+		op = strings.ToLower(strings.TrimSuffix(op, "]"))
+		for _, v := range values {
+			f := Filter{Field: field, Op: FilterOp(op), Values: []string{v}}
+			if f.Op == FilterIn {
+				f.Values = strings.Split(v, ",")
+			}
+			out = append(out, f)
+		}
+		if len(out) > maxFilterConditions {
+			return nil, fmt.Errorf("filter: too many conditions")
+		}
+	}
+	// map 遍历无序，排序后生成的 SQL 稳定，便于复用预编译语句
+	slices.SortFunc(out, func(a, b Filter) int {
+		return strings.Compare(a.Field+string(a.Op), b.Field+string(b.Op))
+	})
+	return out, nil
+}
+
+// CompileFilters 校验字段与运算符，生成参数绑定的查询条件
+func CompileFilters(filters []Filter, safelist FilterSafelist) ([]QueryOption, error) {
+	out := make([]QueryOption, 0, len(filters))
+	for _, f := range filters {
+		rule, ok := safelist[f.Field]
+		if !ok {
+			return nil, fmt.Errorf("filter: field %q is not allowed", f.Field)
+		}
+		if !rule.allow(f.Op) {
+			return nil, fmt.Errorf("filter: operator %q is not allowed on %q", f.Op, f.Field)
+		}
+		if len(f.Values) == 0 || len(f.Values) > maxFilterValues {
+			return nil, fmt.Errorf("filter: %q expects 1~%d values", f.Field, maxFilterValues)
+		}
+		values := make([]any, len(f.Values))
+		for i, v := range f.Values {
+			v = strings.TrimSpace(v)
+			if rule.Parse == nil {
+				values[i] = v
+				continue
+			}
+			val, err := rule.Parse(v)
+			if err != nil {
+				return nil, fmt.Errorf("filter: invalid value %q for %q", v, f.Field)
+			}
+			values[i] = val
+		}
+
+		column := clause.Column{Name: rule.Column}
+		if column.Name == "" {
+			column.Name = f.Field
+		}
+		var expr clause.Expression
+		switch f.Op {
+		case FilterIn:
+			expr = clause.IN{Column: column, Values: values}
+		case FilterLike:
+			expr = clause.Expr{SQL: `? LIKE ? ESCAPE '\'`, Vars: []any{column, "%" + escapeLike(fmt.Sprint(values[0])) + "%"}}
+		default:
+			if len(values) != 1 {
+				return nil, fmt.Errorf("filter: %q expects 1 value", f.Field)
+			}
+			expr = clause.Expr{SQL: "? " + filterOperators[f.Op] + " ?", Vars: []any{column, values[0]}}
+		}
+		out = append(out, func(db *gorm.DB) *gorm.DB {
+			return db.Where(expr)
+		})
+	}
+	return out, nil
+}
+
+func (op FilterOp) valid() bool {
+	return op == FilterIn || op == FilterLike || filterOperators[op] != ""
+}
+
+func (f FilterField) allow(op FilterOp) bool {
+	if len(f.Ops) == 0 {
+		return op == FilterIn || filterOperators[op] != ""
+	}
+	return slices.Contains(f.Ops, op)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ParseFilterInt 整数
+func ParseFilterInt(s string) (any, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// ParseFilterBool 布尔值，支持 1/0/true/false
+func ParseFilterBool(s string) (any, error) {
+	return strconv.ParseBool(s)
+}
+
+// ParseFilterTime 时间，支持毫秒时间戳、RFC3339 与 2006-01-02 15:04:05
+func ParseFilterTime(s string) (any, error) {
+	if !strings.ContainsFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) {
+		ms, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMilli(ms), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateTime, s, time.Local)
+}
@@ -0,0 +1,120 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect []Filter
+		err    bool
+	}{
+		{in: "", expect: nil},
+		{in: `scope eq "web" and expired_at gt 1700000000000`, expect: []Filter{
+			{Field: "scope", Op: FilterEq, Values: []string{"web"}},
+			{Field: "expired_at", Op: FilterGt, Values: []string{"1700000000000"}},
+		}},
+		{in: `id IN (1, 2,3) AND name like "a \"b\" c"`, expect: []Filter{
+			{Field: "id", Op: FilterIn, Values: []string{"1", "2", "3"}},
+			{Field: "name", Op: FilterLike, Values: []string{`a "b" c`}},
+		}},
+		{in: `name eq "a and b"`, expect: []Filter{
+			{Field: "name", Op: FilterEq, Values: []string{"a and b"}},
+		}},
+		{in: "scope eq", err: true},
+		{in: `scope eq "web`, err: true},
+		{in: "scope = web", err: true},
+		{in: "scope eq web or id eq 1", err: true},
+		{in: "id in (1, 2", err: true},
+		{in: "id in 1", err: true},
+	}
+	for _, c := range cases {
+		out, err := ParseFilter(c.in)
+		if (err != nil) != c.err {
+			t.Fatalf("%q: err %v", c.in, err)
+		}
+		if !c.err && !reflect.DeepEqual(out, c.expect) {
+			t.Fatalf("%q: expect %+v, got %+v", c.in, c.expect, out)
+		}
+	}
+}
+
+func TestParseFilterBrackets(t *testing.T) {
+	out, err := ParseFilterBrackets(map[string][]string{
+		"size":            {"10"},
+		"expired_at[gte]": {"1"},
+		"expired_at[LT]":  {"2"},
+		"id[in]":          {"1,2"},
+		"[eq]":            {"x"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Filter{
+		{Field: "expired_at", Op: FilterGte, Values: []string{"1"}},
+		{Field: "expired_at", Op: FilterLt, Values: []string{"2"}},
+		{Field: "id", Op: FilterIn, Values: []string{"1", "2"}},
+	}
+	if !reflect.DeepEqual(out, expect) {
+		t.Fatalf("expect %+v, got %+v", expect, out)
+	}
+}
+
+func TestCompileFilters(t *testing.T) {
+	db := newTestDB(t, new(scrollItem))
+	for i, name := range []string{"a", "b", "a_b", "a%b", "x' OR '1'='1"} {
+		if err := db.Create(&scrollItem{Name: name, Score: i}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	safelist := FilterSafelist{
+		"name":  {Ops: []FilterOp{FilterEq, FilterLike, FilterIn}},
+		"score": {Parse: ParseFilterInt},
+		"label": {Column: "name"},
+	}
+
+	cases := []struct {
+		filter string
+		expect []string
+		err    bool
+	}{
+		{filter: `name eq "a"`, expect: []string{"a"}},
+		{filter: `label eq "b"`, expect: []string{"b"}},
+		{filter: `score gte 1 and score lt 3`, expect: []string{"b", "a_b"}},
+		{filter: `score in (0, 4)`, expect: []string{"a", "x' OR '1'='1"}},
+		{filter: `name like "_"`, expect: []string{"a_b"}},
+		{filter: `name like "%"`, expect: []string{"a%b"}},
+		{filter: `name eq "x' OR '1'='1"`, expect: []string{"x' OR '1'='1"}},
+		{filter: `name gt "a"`, err: true},
+		{filter: `score like "1"`, err: true},
+		{filter: `score eq abc`, err: true},
+		{filter: `id eq 1`, err: true},
+		{filter: `name;drop eq 1`, err: true},
+	}
+	for _, c := range cases {
+		filters, err := ParseFilter(c.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts, err := CompileFilters(filters, safelist)
+		if (err != nil) != c.err {
+			t.Fatalf("%q: err %v", c.filter, err)
+		}
+		if c.err {
+			continue
+		}
+		q := db.Model(new(scrollItem)).Order("id")
+		for _, opt := range opts {
+			q = opt(q)
+		}
+		var names []string
+		if err := q.Pluck("name", &names).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(names, c.expect) {
+			t.Fatalf("%q: expect %v, got %v", c.filter, c.expect, names)
+		}
+	}
+}
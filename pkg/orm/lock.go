@@ -0,0 +1,280 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLockLost 持有期间租约丢失，例如续期失败超过 TTL 或被其它进程抢占
+var ErrLockLost = errors.New("lock lost")
+
+// LockConfig 分布式锁配置
+type LockConfig struct {
+	TTL           time.Duration // 租约时长，持有期间每 TTL/3 续期一次，默认 30 秒
+	RetryInterval time.Duration // Lock 抢锁失败后的重试间隔，默认 500 毫秒
+}
+
+// Locker 基于数据库的分布式锁，多副本共用同一个数据库时互斥
+// postgres 使用会话级 advisory lock，每把锁独占一个连接，连接断开即释放
+// 其它数据库使用租约表 orm_locks，持有者崩溃后租约到期即可被抢占
+// 锁不可重入，不要在 Transaction 中加锁，sqlite 只有一个连接时会死锁
+type Locker struct {
+	db       *gorm.DB
+	cfg      LockConfig
+	advisory bool
+	owner    string
+}
+
+// lease 租约表，同一 name 同时只有一个 owner
+type lease struct {
+	Name      string    `gorm:"primaryKey;size:191"`
+	Owner     string    `gorm:"size:191;notNull;default:''"`
+	ExpiresAt time.Time `gorm:"notNull"`
+}
+
+func (*lease) TableName() string {
+	return "orm_locks"
+}
+
+// NewLocker 非 postgres 时创建租约表
+func NewLocker(db *gorm.DB, cfg LockConfig) (*Locker, error) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 30 * time.Second
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 500 * time.Millisecond
+	}
+	host, _ := os.Hostname()
+	l := Locker{
+		db:       db,
+		cfg:      cfg,
+		advisory: db.Dialector.Name() == "postgres",
+		owner:    fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+	if !l.advisory {
+		if err := db.AutoMigrate(new(lease)); err != nil {
+			return nil, err
+		}
+	}
+	return &l, nil
+}
+
+// Lock 已持有的锁，Unlock 前持续续期
+type Lock struct {
+	key     string
+	lost    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	err     error
+	release func(context.Context) error
+}
+
+// Key 锁的名称
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Lost 租约丢失时关闭，此后其它进程可能已经持有该锁，应当尽快停止受保护的操作
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Unlock 停止续期并释放锁，可以重复调用
+func (lk *Lock) Unlock(ctx context.Context) error {
+	lk.once.Do(func() {
+		close(lk.stop)
+		<-lk.done
+		lk.err = lk.release(ctx)
+	})
+	return lk.err
+}
+
+// TryLock 尝试加锁，已被其它持有者占用时返回 false
+func (l *Locker) TryLock(ctx context.Context, key string) (*Lock, bool, error) {
+	if l.advisory {
+		return l.tryAdvisoryLock(ctx, key)
+	}
+	return l.tryLeaseLock(ctx, key)
+}
+
+// Lock 阻塞直到加锁成功或 ctx 结束
+func (l *Locker) Lock(ctx context.Context, key string) (*Lock, error) {
+	ticker := time.NewTicker(l.cfg.RetryInterval)
+	defer ticker.Stop()
+	for {
+		lk, ok, err := l.TryLock(ctx, key)
+		if err != nil || ok {
+			return lk, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// TryRun 加锁成功时执行 fn，返回是否执行
+// 适用于多副本中只需一个副本执行的定时任务，租约丢失时取消 fn 的 ctx
+func (l *Locker) TryRun(ctx context.Context, key string, fn func(ctx context.Context) error) (bool, error) {
+	lk, ok, err := l.TryLock(ctx, key)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer func() {
+		if err := lk.Unlock(context.WithoutCancel(ctx)); err != nil {
+			slog.ErrorContext(ctx, "unlock", "key", key, "err", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-lk.Lost():
+			cancel(ErrLockLost)
+		case <-ctx.Done():
+		}
+	}()
+	return true, fn(ctx)
+}
+
+func (l *Locker) newLock(key string, renew, release func(context.Context) error) *Lock {
+	lk := Lock{
+		key:     key,
+		lost:    make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		release: release,
+	}
+	go lk.keepalive(l.cfg.TTL, renew)
+	return &lk
+}
+
+// keepalive 续期失败时继续重试，直到确认丢失或超过 TTL
+func (lk *Lock) keepalive(ttl time.Duration, renew func(context.Context) error) {
+	defer close(lk.done)
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	deadline := time.Now().Add(ttl)
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		err := renew(ctx)
+		cancel()
+		if err == nil {
+			deadline = start.Add(ttl)
+			continue
+		}
+		slog.Warn("lock renew", "key", lk.key, "err", err)
+		if errors.Is(err, ErrLockLost) || time.Now().After(deadline) {
+			close(lk.lost)
+			return
+		}
+	}
+}
+
+func (l *Locker) tryLeaseLock(ctx context.Context, key string) (*Lock, bool, error) {
+	owner := l.owner + ":" + GenerateRandomString(8)
+	now := time.Now().UTC()
+	// 不存在或已过期时写入，否则不更新任何行
+	result := l.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"owner", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "orm_locks", Name: "expires_at"}, Value: now},
+		}},
+	}).Create(&lease{Name: key, Owner: owner, ExpiresAt: now.Add(l.cfg.TTL)})
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+
+	where := func(ctx context.Context) *gorm.DB {
+		return l.db.WithContext(ctx).Model(new(lease)).Where("name = ? AND owner = ?", key, owner)
+	}
+	renew := func(ctx context.Context) error {
+		result := where(ctx).Update("expires_at", time.Now().UTC().Add(l.cfg.TTL))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLockLost
+		}
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		return where(ctx).Delete(new(lease)).Error
+	}
+	return l.newLock(key, renew, release), true, nil
+}
+
+func (l *Locker) tryAdvisoryLock(ctx context.Context, key string) (*Lock, bool, error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	// advisory lock 绑定在会话上，持有期间独占该连接
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	id := advisoryKey(key)
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", id).Scan(&ok); err != nil {
+		discardConn(conn)
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	renew := func(ctx context.Context) error {
+		// 连接断开时会话级的锁已被数据库释放
+		if err := conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("%w: %w", ErrLockLost, err)
+		}
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", id); err != nil {
+			discardConn(conn)
+			return err
+		}
+		return conn.Close()
+	}
+	return l.newLock(key, renew, release), true, nil
+}
+
+// discardConn 关闭底层连接而不是放回连接池，避免未释放的锁随连接被复用
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
+
+// advisoryKey 将名称映射为 advisory lock 的 bigint 键
+func advisoryKey(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("orm.lock:" + key))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,135 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestLocker(t *testing.T, ttl time.Duration) (*gorm.DB, *Locker) {
+	db := newTestDB(t)
+	l, err := NewLocker(db, LockConfig{TTL: ttl, RetryInterval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, l
+}
+
+func TestLocker(t *testing.T) {
+	_, l := newTestLocker(t, time.Second)
+	ctx := context.Background()
+
+	a, ok, err := l.TryLock(ctx, "a")
+	if err != nil || !ok {
+		t.Fatalf("expect locked, got %v %v", ok, err)
+	}
+	// 不可重入，同一进程内同样互斥
+	if _, ok, err := l.TryLock(ctx, "a"); err != nil || ok {
+		t.Fatalf("expect held, got %v %v", ok, err)
+	}
+	b, ok, err := l.TryLock(ctx, "b")
+	if err != nil || !ok {
+		t.Fatalf("expect locked, got %v %v", ok, err)
+	}
+	defer b.Unlock(ctx)
+
+	timeout, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(timeout, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect deadline exceeded, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		a.Unlock(ctx)
+	}()
+	a2, err := l.Lock(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a2.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := a2.Unlock(ctx); err != nil {
+		t.Fatalf("expect unlock idempotent, got %v", err)
+	}
+}
+
+func TestLockerLease(t *testing.T) {
+	db, l := newTestLocker(t, 60*time.Millisecond)
+	ctx := context.Background()
+
+	lk, ok, err := l.TryLock(ctx, "a")
+	if err != nil || !ok {
+		t.Fatalf("expect locked, got %v %v", ok, err)
+	}
+	// 持续续期，超过 TTL 仍然持有
+	time.Sleep(150 * time.Millisecond)
+	if _, ok, _ := l.TryLock(ctx, "a"); ok {
+		t.Fatal("expect lease renewed")
+	}
+	select {
+	case <-lk.Lost():
+		t.Fatal("unexpected lost")
+	default:
+	}
+
+	// 模拟租约被其它进程抢占
+	if err := db.Model(new(lease)).Where("name = ?", "a").Update("owner", "other").Error; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-lk.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expect lost")
+	}
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 持有者崩溃后，租约到期即可被抢占
+	if err := db.Model(new(lease)).Where("name = ?", "a").Update("expires_at", time.Now().UTC().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	lk, ok, err = l.TryLock(ctx, "a")
+	if err != nil || !ok {
+		t.Fatalf("expect takeover, got %v %v", ok, err)
+	}
+	lk.Unlock(ctx)
+}
+
+func TestLockerTryRun(t *testing.T) {
+	db, l := newTestLocker(t, 60*time.Millisecond)
+	ctx := context.Background()
+
+	var inner bool
+	ran, err := l.TryRun(ctx, "cleanup", func(ctx context.Context) error {
+		// 其它副本同时执行时跳过
+		ran, err := l.TryRun(ctx, "cleanup", func(context.Context) error {
+			inner = true
+			return nil
+		})
+		if ran || err != nil {
+			t.Errorf("expect skipped, got %v %v", ran, err)
+		}
+		return nil
+	})
+	if !ran || err != nil || inner {
+		t.Fatalf("unexpected ran %v err %v inner %v", ran, err, inner)
+	}
+
+	// 租约丢失时取消 ctx
+	ran, err = l.TryRun(ctx, "cleanup", func(ctx context.Context) error {
+		if err := db.Model(new(lease)).Where("name = ?", "cleanup").Update("owner", "other").Error; err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})
+	if !ran || !errors.Is(err, ErrLockLost) {
+		t.Fatalf("expect ErrLockLost, got %v %v", ran, err)
+	}
+}
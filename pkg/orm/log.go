@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/ixugo/goddd/pkg/metrics"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+var queryDuration = metrics.Default.NewHistogram("gorm_query_duration_seconds", "Duration of gorm statements in seconds.", metrics.DefBuckets, "op", "status")
+
 type Logger struct {
 	*slog.Logger
 	slow     time.Duration
@@ -69,6 +73,11 @@ func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
 func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
 	elapsed := time.Since(begin)
 	sql, rows := fc()
+	status := "ok"
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		status = "error"
+	}
+	queryDuration.With(sqlOp(sql), status).Observe(elapsed.Seconds())
 
 	// 在业务里通常应该主动处理 ErrDuplicatedKey 错误，这里应该忽略掉
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, gorm.ErrDuplicatedKey) {
@@ -84,3 +93,13 @@ func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql stri
 	// 仅 debug 状态会打印所有 sql
 	l.DebugContext(ctx, "gorm trace", "duration_ms", elapsed.Milliseconds(), "sql", sql, "rows", rows)
 }
+
+// sqlOp 语句类型作为指标标签，不使用完整的 sql 避免标签过多
+func sqlOp(sql string) string {
+	op, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	switch op = strings.ToLower(op); op {
+	case "select", "insert", "update", "delete":
+		return op
+	}
+	return "other"
+}
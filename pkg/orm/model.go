@@ -33,10 +33,17 @@ type JSONValueScanner interface {
 
 // Model int id 模型
 // sqlite 不支持 default:now()，支持 CURRENT_TIMESTAMP
+// Version 为乐观锁版本号，配合 UpdateOptimistic 使用，外层模型存在同名字段时以外层为准
 type Model struct {
 	ID        int  `gorm:"primaryKey;" json:"id"`
 	CreatedAt Time `gorm:"notNull;default:CURRENT_TIMESTAMP;index;comment:创建时间" json:"created_at"`
 	UpdatedAt Time `gorm:"notNull;default:CURRENT_TIMESTAMP;comment:更新时间" json:"updated_at"`
+	Version   int  `gorm:"notNull;default:1;comment:版本号" json:"version"`
+}
+
+// GetVersion 乐观锁版本号，web.WrapH 据此输出 ETag
+func (d Model) GetVersion() int {
+	return d.Version
 }
 
 // ModelWithStrID string id 模型
@@ -60,6 +67,9 @@ func (d *ModelWithStrID) BeforeUpdate(*gorm.DB) error {
 func (d *Model) BeforeCreate(*gorm.DB) error {
 	d.CreatedAt = Now()
 	d.UpdatedAt = Now()
+	if d.Version <= 0 {
+		d.Version = 1
+	}
 	return nil
 }
 
@@ -80,9 +90,7 @@ type DeletedModel struct {
 }
 
 func (d *DeletedModel) BeforeCreate(*gorm.DB) error {
-	d.CreatedAt = Now()
-	d.UpdatedAt = Now()
-	return nil
+	return d.Model.BeforeCreate(nil)
 }
 
 func (d *DeletedModel) BeforeUpdate(*gorm.DB) error {
@@ -28,7 +28,7 @@ func (t Type[T]) Edit(ctx context.Context, model *T, changeFn func(*T) error, op
 
 // Deprecated: 请使用 Create
 func (t Type[T]) Add(ctx context.Context, model *T) error {
-	return t.db.WithContext(ctx).Create(model).Error
+	return Conn(ctx, t.db).Create(model).Error
 }
 
 // Deprecated: 请使用 Delete
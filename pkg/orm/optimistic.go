@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrVersionConflict 版本号不一致，数据已被其它请求修改
+var ErrVersionConflict = errors.New("version conflict")
+
+// versionField 返回模型的整数 version 字段
+func versionField(db *gorm.DB, model any) (*schema.Field, string, error) {
+	stmt := gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, "", err
+	}
+	f := stmt.Schema.LookUpField("version")
+	if f == nil {
+		return nil, "", fmt.Errorf("%s has no version column", stmt.Schema.Name)
+	}
+	switch f.FieldType.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, "", fmt.Errorf("%s version column must be integer", stmt.Schema.Name)
+	}
+	return f, stmt.Quote(f.DBName), nil
+}
+
+// UpdateOptimistic 乐观锁更新，不持有行锁
+// 读取后执行 changeFn，再通过 UPDATE ... WHERE version = ? 写入全部字段，版本号加一
+// version 为客户端持有的版本号，大于 0 时必须与读取到的一致；为 0 时仅防止读写之间的并发修改
+// 版本号不一致或写入前已被修改时返回 ErrVersionConflict，调用方可以重新读取后重试
+func UpdateOptimistic[T any](ctx context.Context, db *gorm.DB, model *T, version int, changeFn func(*T) error, opts ...QueryOption) error {
+	if len(opts) == 0 {
+		panic("where is empty")
+	}
+	field, col, err := versionField(db, model)
+	if err != nil {
+		return err
+	}
+	tx := Conn(ctx, db)
+	{
+		// 副本可能延迟，读取主库的版本号
+		tx := Primary()(tx)
+		for _, opt := range opts {
+			tx = opt(tx)
+		}
+		if err := tx.First(model).Error; err != nil {
+			return err
+		}
+	}
+
+	rv := reflect.ValueOf(model).Elem()
+	v, _ := field.ValueOf(ctx, rv)
+	current := reflect.ValueOf(v).Convert(reflect.TypeFor[int64]()).Int()
+	if version > 0 && int64(version) != current {
+		return ErrVersionConflict
+	}
+	if err := changeFn(model); err != nil {
+		return err
+	}
+
+	if err := field.Set(ctx, rv, current+1); err != nil {
+		return err
+	}
+	result := tx.Model(model).Where(col+" = ?", current).Select("*").Updates(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		_ = field.Set(ctx, rv, current)
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// UpdateOptimistic 乐观锁更新
+func (t Type[T]) UpdateOptimistic(ctx context.Context, model *T, version int, changeFn func(*T) error, opts ...QueryOption) error {
+	return UpdateOptimistic(ctx, t.db, model, version, changeFn, opts...)
+}
@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type versionItem struct {
+	Model
+	Name string `gorm:"notNull;default:''"`
+}
+
+func TestUpdateOptimistic(t *testing.T) {
+	db := newTestDB(t, new(versionItem), new(scrollItem))
+	store := NewType[versionItem](db)
+	ctx := context.Background()
+
+	item := versionItem{Name: "a"}
+	if err := store.Create(ctx, &item); err != nil {
+		t.Fatal(err)
+	}
+	if item.Version != 1 || item.GetVersion() != 1 {
+		t.Fatalf("expect version 1, got %d", item.Version)
+	}
+
+	var out versionItem
+	if err := store.UpdateOptimistic(ctx, &out, 1, func(v *versionItem) error {
+		v.Name = "b"
+		return nil
+	}, Where("id = ?", item.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if out.Version != 2 || out.Name != "b" {
+		t.Fatalf("unexpected %+v", out)
+	}
+
+	// 客户端持有旧版本号
+	if err := store.UpdateOptimistic(ctx, new(versionItem), 1, func(*versionItem) error { return nil }, Where("id = ?", item.ID)); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expect ErrVersionConflict, got %v", err)
+	}
+
+	// 读取之后、写入之前被其它请求修改
+	out = versionItem{}
+	err := store.UpdateOptimistic(ctx, &out, 0, func(v *versionItem) error {
+		v.Name = "lost"
+		return db.Model(new(versionItem)).Where("id = ?", item.ID).Updates(map[string]any{"name": "c", "version": 3}).Error
+	}, Where("id = ?", item.ID))
+	if !errors.Is(err, ErrVersionConflict) || out.Version != 2 {
+		t.Fatalf("expect ErrVersionConflict, got %v %d", err, out.Version)
+	}
+	if err := store.Get(ctx, &out, Where("id = ?", item.ID)); err != nil || out.Name != "c" || out.Version != 3 {
+		t.Fatalf("unexpected %+v %v", out, err)
+	}
+
+	if err := NewType[scrollItem](db).UpdateOptimistic(ctx, new(scrollItem), 0, func(*scrollItem) error { return nil }, Where("id = 1")); err == nil {
+		t.Fatal("expect no version column error")
+	}
+}
@@ -0,0 +1,313 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/metrics"
+	"gorm.io/gorm"
+)
+
+const usePrimaryKey = "orm:use_primary"
+
+// ReplicaConfig 读写分离配置
+type ReplicaConfig struct {
+	// HealthInterval 副本健康检查间隔，默认 5s，不健康的副本不参与路由
+	HealthInterval time.Duration
+	// ReadYourWrites 同一请求写入后，此时长内的查询使用主库，默认 5s
+	// 需要 ctx 经过 WithReadYourWrites，否则无法感知之前的写入
+	ReadYourWrites time.Duration
+}
+
+type replicaPool struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+var _ gorm.Plugin = (*Replicas)(nil)
+
+// Replicas 读写分离，通过 db.Use 注册
+// 不在事务中的查询(First/Find/Count/Pluck)路由到健康的副本，副本全部不可用时使用主库
+// 写入、事务内的语句、FOR UPDATE 等加锁的查询与 Raw/Row/Scan 始终使用主库，表迁移的检查不会读到副本
+type Replicas struct {
+	cfg     ReplicaConfig
+	primary gorm.ConnPool
+	pools   []*replicaPool
+	next    atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewReplicas replicas 为 New 打开的只读副本，只使用其连接池
+func NewReplicas(cfg ReplicaConfig, replicas ...*gorm.DB) (*Replicas, error) {
+	if cfg.HealthInterval <= 0 {
+		cfg.HealthInterval = 5 * time.Second
+	}
+	if cfg.ReadYourWrites <= 0 {
+		cfg.ReadYourWrites = 5 * time.Second
+	}
+	r := Replicas{
+		cfg:   cfg,
+		pools: make([]*replicaPool, 0, len(replicas)),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	for i, v := range replicas {
+		db, err := v.DB()
+		if err != nil {
+			return nil, err
+		}
+		pool := replicaPool{name: fmt.Sprintf("replica%d", i), db: db}
+		pool.healthy.Store(true)
+		r.pools = append(r.pools, &pool)
+	}
+	return &r, nil
+}
+
+// Name implements gorm.Plugin.
+func (r *Replicas) Name() string {
+	return "orm:replicas"
+}
+
+// Initialize implements gorm.Plugin.
+func (r *Replicas) Initialize(db *gorm.DB) error {
+	r.primary = db.ConnPool
+	if sqlDB, ok := db.ConnPool.(*sql.DB); ok {
+		PublishDBStats("primary", sqlDB)
+	}
+	for _, pool := range r.pools {
+		publishPool(pool.name, pool.db, &pool.healthy)
+	}
+
+	cb := db.Callback()
+	if err := cb.Query().Before("gorm:query").Register("orm:replica_query", r.route); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("orm:replica_create", r.markWrite); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("orm:replica_update", r.markWrite); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("orm:replica_delete", r.markWrite); err != nil {
+		return err
+	}
+	if err := cb.Raw().After("gorm:raw").Register("orm:replica_raw", r.markWrite); err != nil {
+		return err
+	}
+
+	go r.healthCheck()
+	return nil
+}
+
+// Close 停止健康检查并关闭副本的连接池
+func (r *Replicas) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	<-r.done
+	var err error
+	for _, pool := range r.pools {
+		if e := pool.db.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// route 查询语句选择连接
+func (r *Replicas) route(db *gorm.DB) {
+	stmt := db.Statement
+	// 事务中的连接与主库不同，保持不变
+	if db.Error != nil || stmt.ConnPool != r.primary {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return
+	}
+	if v, ok := stmt.Settings.Load(usePrimaryKey); ok && v.(bool) {
+		return
+	}
+	if w, ok := stmt.Context.Value(writesKey{}).(*recentWrite); ok && w.within(r.cfg.ReadYourWrites) {
+		return
+	}
+	if pool := r.pick(); pool != nil {
+		stmt.ConnPool = pool.db
+	}
+}
+
+// pick 轮询健康的副本
+func (r *Replicas) pick() *replicaPool {
+	n := len(r.pools)
+	if n == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := range n {
+		if pool := r.pools[(start+uint64(i))%uint64(n)]; pool.healthy.Load() {
+			return pool
+		}
+	}
+	return nil
+}
+
+func (r *Replicas) markWrite(db *gorm.DB) {
+	if w, ok := db.Statement.Context.Value(writesKey{}).(*recentWrite); ok {
+		w.mark()
+	}
+}
+
+func (r *Replicas) healthCheck() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.cfg.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+		for _, pool := range r.pools {
+			ctx, cancel := context.WithTimeout(context.Background(), r.cfg.HealthInterval)
+			err := pool.db.PingContext(ctx)
+			cancel()
+			if healthy := err == nil; pool.healthy.Swap(healthy) != healthy {
+				if healthy {
+					slog.Info("replica recovered", "name", pool.name)
+				} else {
+					slog.Error("replica unhealthy", "name", pool.name, "err", err)
+				}
+			}
+		}
+	}
+}
+
+// Primary 本次查询使用主库，用于对延迟敏感的读取
+func Primary() QueryOption {
+	return func(d *gorm.DB) *gorm.DB {
+		return d.Set(usePrimaryKey, true)
+	}
+}
+
+type writesKey struct{}
+
+// recentWrite 请求内最后一次写入的时间
+type recentWrite struct {
+	at atomic.Int64
+}
+
+func (w *recentWrite) mark() {
+	w.at.Store(time.Now().UnixNano())
+}
+
+func (w *recentWrite) within(d time.Duration) bool {
+	at := w.at.Load()
+	return at > 0 && time.Since(time.Unix(0, at)) < d
+}
+
+// WithReadYourWrites 记录 ctx 内的写入，写入后一段时间内的查询使用主库，避免读到副本上的旧数据
+// 通常由 web.ReadYourWrites 中间件在每个请求开始时调用
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(writesKey{}).(*recentWrite); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, writesKey{}, new(recentWrite))
+}
+
+type publishedPool struct {
+	db *sql.DB
+	// healthy 副本的健康状态，主库为 nil
+	healthy *atomic.Bool
+}
+
+var (
+	poolStats     = make(map[string]publishedPool)
+	poolStatsMu   sync.Mutex
+	poolStatsOnce sync.Once
+)
+
+// eachPool 按名称排序遍历已发布的连接池
+func eachPool(fn func(name string, pool publishedPool)) {
+	poolStatsMu.Lock()
+	pools := maps.Clone(poolStats)
+	poolStatsMu.Unlock()
+	for _, name := range slices.Sorted(maps.Keys(pools)) {
+		fn(name, pools[name])
+	}
+}
+
+// publishPool 连接池状态发布到 expvar 的 db_pools 与 metrics.Default，同名覆盖
+func publishPool(name string, db *sql.DB, healthy *atomic.Bool) {
+	poolStatsMu.Lock()
+	poolStats[name] = publishedPool{db: db, healthy: healthy}
+	poolStatsMu.Unlock()
+	poolStatsOnce.Do(func() {
+		expvar.Publish("db_pools", expvar.Func(func() any {
+			out := make(map[string]any)
+			eachPool(func(name string, pool publishedPool) {
+				if pool.healthy == nil {
+					out[name] = pool.db.Stats()
+					return
+				}
+				out[name] = struct {
+					sql.DBStats
+					Healthy bool
+				}{pool.db.Stats(), pool.healthy.Load()}
+			})
+			return out
+		}))
+		registerPoolMetrics()
+	})
+}
+
+// registerPoolMetrics sql.DBStats 以 pool 为标签输出
+func registerPoolMetrics() {
+	gauge := func(name, help string, fn func(s sql.DBStats) float64) {
+		metrics.Default.NewGaugeFunc(name, help, []string{"pool"}, func(observe metrics.Observe) {
+			eachPool(func(name string, pool publishedPool) {
+				observe(fn(pool.db.Stats()), name)
+			})
+		})
+	}
+	counter := func(name, help string, fn func(s sql.DBStats) float64) {
+		metrics.Default.NewCounterFunc(name, help, []string{"pool"}, func(observe metrics.Observe) {
+			eachPool(func(name string, pool publishedPool) {
+				observe(fn(pool.db.Stats()), name)
+			})
+		})
+	}
+	gauge("db_pool_max_open_connections", "Maximum number of open connections to the database.", func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("db_pool_open_connections", "The number of established connections both in use and idle.", func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("db_pool_in_use_connections", "The number of connections currently in use.", func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("db_pool_idle_connections", "The number of idle connections.", func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("db_pool_wait_count_total", "The total number of connections waited for.", func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("db_pool_wait_duration_seconds_total", "The total time blocked waiting for a new connection.", func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("db_pool_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.", func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("db_pool_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.", func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+	metrics.Default.NewGaugeFunc("db_replica_healthy", "Whether the replica passed the last health check.", []string{"pool"}, func(observe metrics.Observe) {
+		eachPool(func(name string, pool publishedPool) {
+			if pool.healthy == nil {
+				return
+			}
+			v := 0.0
+			if pool.healthy.Load() {
+				v = 1
+			}
+			observe(v, name)
+		})
+	})
+}
+
+// PublishDBStats 连接池状态发布到 expvar 的 db_pools 与 metrics.Default，通过 /debug/vars 与 /debug/metrics 查看
+func PublishDBStats(name string, db *sql.DB) {
+	publishPool(name, db, nil)
+}
@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"context"
+	"expvar"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func TestReplicas(t *testing.T) {
+	open := func(name string) *gorm.DB {
+		db := newTestDB(t, new(scrollItem))
+		// 主库与副本的数据不同，用于区分查询使用的连接
+		if err := db.Create(&scrollItem{Name: name}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+	db, replica := open("primary"), open("replica")
+	r, err := NewReplicas(ReplicaConfig{HealthInterval: time.Hour}, replica)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Use(r); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	name := func(ctx context.Context, opts ...QueryOption) string {
+		t.Helper()
+		var out scrollItem
+		if err := FirstWithContext(ctx, db, &out, append(opts, Where("id = 1"))...); err != nil {
+			t.Fatal(err)
+		}
+		return out.Name
+	}
+	ctx := context.Background()
+	if v := name(ctx); v != "replica" {
+		t.Fatalf("expect replica, got %s", v)
+	}
+	if v := name(ctx, Primary()); v != "primary" {
+		t.Fatalf("expect primary, got %s", v)
+	}
+	if v := name(ctx, func(d *gorm.DB) *gorm.DB { return d.Clauses(clause.Locking{Strength: "UPDATE"}) }); v != "primary" {
+		t.Fatalf("expect primary when locking, got %s", v)
+	}
+	if total, err := CountWithContext[scrollItem](ctx, db); err != nil || total != 1 {
+		t.Fatalf("expect 1, got %d %v", total, err)
+	}
+	_ = Transaction(ctx, db, func(ctx context.Context) error {
+		if v := name(ctx); v != "primary" {
+			t.Fatalf("expect primary in transaction, got %s", v)
+		}
+		return nil
+	})
+
+	// 同一请求写入后读主库
+	ctx = WithReadYourWrites(ctx)
+	if v := name(ctx); v != "replica" {
+		t.Fatalf("expect replica before write, got %s", v)
+	}
+	if err := Conn(ctx, db).Create(&scrollItem{Name: "new"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if v := name(ctx); v != "primary" {
+		t.Fatalf("expect primary after write, got %s", v)
+	}
+
+	// 副本不可用时使用主库
+	r.pools[0].healthy.Store(false)
+	if v := name(context.Background()); v != "primary" {
+		t.Fatalf("expect primary when replica unhealthy, got %s", v)
+	}
+
+	stats := expvar.Get("db_pools").String()
+	if !strings.Contains(stats, `"primary"`) || !strings.Contains(stats, `"replica0"`) || !strings.Contains(stats, `"Healthy":false`) {
+		t.Fatalf("unexpected stats %s", stats)
+	}
+}
@@ -0,0 +1,151 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TenantColumn 租户字段，模型包含此字段时按 ctx 中的租户自动过滤
+const TenantColumn = "tenant_id"
+
+// ErrTenantMismatch 写入的数据属于其它租户
+var ErrTenantMismatch = errors.New("tenant mismatch")
+
+const (
+	skipTenantKey    = "orm:skip_tenant"
+	appliedTenantKey = "orm:tenant_applied"
+)
+
+type tenantKey struct{}
+
+// WithTenant 将租户放入 ctx，之后通过 Conn(ctx, db) 执行的语句自动按租户过滤
+// 鉴权中间件根据 jwt 中的租户调用，tenantID 为空时不过滤
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFrom 获取 ctx 中的租户
+func TenantFrom(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	v, _ := ctx.Value(tenantKey{}).(string)
+	return v, v != ""
+}
+
+// AllTenants 跳过租户过滤，用于平台管理等需要跨租户的操作，调用方负责鉴权
+func AllTenants() QueryOption {
+	return func(d *gorm.DB) *gorm.DB {
+		return d.Set(skipTenantKey, true)
+	}
+}
+
+// TenantKey 缓存 key 加上 ctx 中的租户前缀，防止不同租户读到对方的缓存
+// 没有租户时原样返回
+func TenantKey(ctx context.Context, key string) string {
+	tenantID, _ := TenantFrom(ctx)
+	return TenantKeyOf(tenantID, key)
+}
+
+// TenantKeyOf 指定租户的缓存 key，用于清除其它租户的缓存
+func TenantKeyOf(tenantID, key string) string {
+	if tenantID == "" {
+		return key
+	}
+	return "TENANT:" + tenantID + ":" + key
+}
+
+var _ gorm.Plugin = TenantPlugin{}
+
+// TenantPlugin 包含 tenant_id 字段的模型，查询、更新与删除自动追加 tenant_id = ? 条件
+// 新增时填充 ctx 中的租户；原生 sql(Raw/Exec) 不经过此处，需要自行处理
+// New 已注册，直接使用 gorm.Open 时需要 db.Use(TenantPlugin{})
+type TenantPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (TenantPlugin) Name() string {
+	return "orm:tenant"
+}
+
+// Initialize implements gorm.Plugin.
+func (TenantPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("orm:tenant_create", tenantCreate); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("orm:tenant_query", tenantScope); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("orm:tenant_row", tenantScope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("orm:tenant_update", tenantScope); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("orm:tenant_delete", tenantScope)
+}
+
+// tenantOf 语句需要限定的租户
+func tenantOf(db *gorm.DB) (string, bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.Schema.LookUpField(TenantColumn) == nil {
+		return "", false
+	}
+	if skip, ok := stmt.Settings.Load(skipTenantKey); ok && skip.(bool) {
+		return "", false
+	}
+	return TenantFrom(stmt.Context)
+}
+
+func tenantScope(db *gorm.DB) {
+	tenantID, ok := tenantOf(db)
+	if !ok {
+		return
+	}
+	// Count 之后再 Find 时复用同一语句，条件只追加一次
+	if _, applied := db.Statement.Settings.LoadOrStore(appliedTenantKey, true); applied {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: TenantColumn}, Value: tenantID},
+	}})
+}
+
+// tenantCreate 填充租户，已指定其它租户时拒绝写入
+func tenantCreate(db *gorm.DB) {
+	tenantID, ok := tenantOf(db)
+	if !ok {
+		return
+	}
+	stmt := db.Statement
+	field := stmt.Schema.LookUpField(TenantColumn)
+	set := func(rv reflect.Value) {
+		rv = reflect.Indirect(rv)
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		v, zero := field.ValueOf(stmt.Context, rv)
+		if zero {
+			if err := field.Set(stmt.Context, rv, tenantID); err != nil {
+				_ = db.AddError(err)
+			}
+			return
+		}
+		if fmt.Sprint(v) != tenantID {
+			_ = db.AddError(ErrTenantMismatch)
+		}
+	}
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			set(rv.Index(i))
+		}
+	default:
+		set(rv)
+	}
+}
@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type tenantItem struct {
+	ID       int    `gorm:"primaryKey"`
+	TenantID string `gorm:"notNull;default:''"`
+	Name     string `gorm:"notNull;default:''"`
+}
+
+func TestTenant(t *testing.T) {
+	db := newTestDB(t, new(tenantItem), new(scrollItem))
+	if err := db.Use(TenantPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	store := NewType[tenantItem](db)
+	a := WithTenant(context.Background(), "a")
+	b := WithTenant(context.Background(), "b")
+
+	// 新增时填充租户
+	for _, ctx := range []context.Context{a, a, b} {
+		if err := store.Create(ctx, &tenantItem{Name: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Create(a, &tenantItem{TenantID: "b"}); !errors.Is(err, ErrTenantMismatch) {
+		t.Fatalf("expect ErrTenantMismatch, got %v", err)
+	}
+
+	var items []*tenantItem
+	if total, err := store.List(a, &items, nil); err != nil || total != 2 || len(items) != 2 || items[0].TenantID != "a" {
+		t.Fatalf("expect 2 items of tenant a, got %d %v", total, err)
+	}
+	items = items[:0]
+	if total, err := store.List(a, &items, nil, AllTenants()); err != nil || total != 3 {
+		t.Fatalf("expect 3 items of all tenants, got %d %v", total, err)
+	}
+	items = items[:0]
+	// 没有租户时不过滤，例如后台任务
+	if total, err := store.List(context.Background(), &items, nil); err != nil || total != 3 {
+		t.Fatalf("expect 3 items without tenant, got %d %v", total, err)
+	}
+
+	// 其它租户的数据不可见，也无法修改与删除
+	var item tenantItem
+	if err := store.Get(b, &item, Where("id = ?", 1)); !IsErrRecordNotFound(err) {
+		t.Fatalf("expect not found, got %v", err)
+	}
+	if err := store.Update(b, &item, func(v *tenantItem) error {
+		v.Name = "y"
+		return nil
+	}, Where("id = ?", 1)); !IsErrRecordNotFound(err) {
+		t.Fatalf("expect not found, got %v", err)
+	}
+	if n := Conn(b, db).Model(new(tenantItem)).Where("id > 0").Update("name", "y").RowsAffected; n != 1 {
+		t.Fatalf("expect 1 updated, got %d", n)
+	}
+	if err := store.Delete(b, new(tenantItem), Where("id IN ?", []int{1, 2, 3})); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Model(new(tenantItem)).Count(&count).Error; err != nil || count != 2 {
+		t.Fatalf("expect 2 rows, got %d %v", count, err)
+	}
+
+	// 没有租户字段的模型不受影响
+	if err := NewType[scrollItem](db).Create(a, &scrollItem{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if key := TenantKey(a, "K"); key != "TENANT:a:K" {
+		t.Fatalf("unexpected key %s", key)
+	}
+	if key := TenantKey(context.Background(), "K"); key != "K" {
+		t.Fatalf("unexpected key %s", key)
+	}
+}
@@ -0,0 +1,101 @@
+package orm
+
+import (
+	"errors"
+
+	"github.com/ixugo/goddd/pkg/trace"
+	"gorm.io/gorm"
+)
+
+const traceSpanKey = "orm:trace_span"
+
+type tracedStmt struct {
+	op   string
+	span *trace.Span
+}
+
+var _ gorm.Plugin = TracePlugin{}
+
+// TracePlugin 为 gorm 语句创建 span，orm.New 已注册
+// 只在 ctx 中存在记录中的 span 时创建，例如经过 web.Logger 的请求，避免后台轮询产生大量独立的链路
+type TracePlugin struct{}
+
+// Name implements gorm.Plugin.
+func (TracePlugin) Name() string {
+	return "orm:trace"
+}
+
+// Initialize implements gorm.Plugin.
+func (p TracePlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("orm:trace_before_create", p.before("create")); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("orm:trace_before_query", p.before("query")); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("orm:trace_before_update", p.before("update")); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("orm:trace_before_delete", p.before("delete")); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register("orm:trace_before_row", p.before("row")); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("*").Register("orm:trace_before_raw", p.before("raw")); err != nil {
+		return err
+	}
+
+	if err := cb.Create().After("*").Register("orm:trace_after_create", p.after); err != nil {
+		return err
+	}
+	if err := cb.Query().After("*").Register("orm:trace_after_query", p.after); err != nil {
+		return err
+	}
+	if err := cb.Update().After("*").Register("orm:trace_after_update", p.after); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("*").Register("orm:trace_after_delete", p.after); err != nil {
+		return err
+	}
+	if err := cb.Row().After("*").Register("orm:trace_after_row", p.after); err != nil {
+		return err
+	}
+	return cb.Raw().After("*").Register("orm:trace_after_raw", p.after)
+}
+
+func (TracePlugin) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		if !trace.SpanFromContext(stmt.Context).IsRecording() {
+			return
+		}
+		_, span := trace.Start(stmt.Context, op, trace.KindClient,
+			trace.Attr{Key: "db.system.name", Value: db.Dialector.Name()},
+			trace.Attr{Key: "db.operation.name", Value: op},
+		)
+		stmt.Settings.Store(traceSpanKey, tracedStmt{op: op, span: span})
+	}
+}
+
+func (TracePlugin) after(db *gorm.DB) {
+	v, ok := db.Statement.Settings.LoadAndDelete(traceSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(tracedStmt).span
+	stmt := db.Statement
+	// 表名在语句解析后才能确定
+	if stmt.Table != "" {
+		span.SetName(v.(tracedStmt).op + " " + stmt.Table)
+		span.SetAttr("db.collection.name", stmt.Table)
+	}
+	// 只记录带占位符的语句，不记录参数
+	span.SetAttr("db.query.text", stmt.SQL.String())
+	span.SetAttr("db.response.rows_affected", db.RowsAffected)
+	if err := db.Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.SetError(err)
+	}
+	span.End()
+}
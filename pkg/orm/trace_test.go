@@ -0,0 +1,63 @@
+package orm
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ixugo/goddd/pkg/trace"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []trace.SpanData
+}
+
+func (r *spanRecorder) Export(_ context.Context, _ []trace.Attr, spans []trace.SpanData) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, spans...)
+	return nil
+}
+
+func TestTracePlugin(t *testing.T) {
+	db := newTestDB(t, new(scrollItem))
+	if err := db.Use(TracePlugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var recorder spanRecorder
+	tracer := trace.New(trace.Config{SampleRatio: 1}, &recorder)
+	prev := trace.Default()
+	trace.SetDefault(tracer)
+	defer trace.SetDefault(prev)
+
+	// 没有 span 的 ctx 不记录
+	if err := Conn(context.Background(), db).Create(&scrollItem{Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	ctx, root := trace.Start(context.Background(), "request", trace.KindServer)
+	if err := Conn(ctx, db).Create(&scrollItem{Name: "b"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var item scrollItem
+	if err := FirstWithContext(ctx, db, &item, Where("id = ?", 100)); !IsErrRecordNotFound(err) {
+		t.Fatalf("expect not found, got %v", err)
+	}
+	root.End()
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.spans) != 3 {
+		t.Fatalf("expect 3 spans, got %d", len(recorder.spans))
+	}
+	create, query := recorder.spans[0], recorder.spans[1]
+	if create.Name != "create scroll_items" || create.Parent != root.SpanContext().SpanID {
+		t.Fatalf("unexpected create span %+v", create)
+	}
+	// 未找到记录不视为失败
+	if query.Name != "query scroll_items" || query.Status != trace.StatusUnset {
+		t.Fatalf("unexpected query span %+v", query)
+	}
+}
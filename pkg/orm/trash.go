@@ -0,0 +1,96 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotSoftDelete 模型没有 DeletedAt 字段，不支持软删除
+var ErrNotSoftDelete = errors.New("model does not support soft delete")
+
+// SoftDeleter 软删除的 store，模型包含 DeletedAt 字段时 Delete 仅标记删除
+// 普通查询自动过滤已删除的数据，回收站中的数据通过以下方法管理
+type SoftDeleter[T any] interface {
+	// Restore 恢复已删除的数据，返回恢复的行数
+	Restore(context.Context, ...QueryOption) (int64, error)
+	// ListTrashed 分页查询已删除的数据
+	ListTrashed(context.Context, *[]*T, Pager, ...QueryOption) (int64, error)
+	// Purge 彻底删除 before 之前删除的数据，返回删除的行数
+	Purge(context.Context, time.Time, ...QueryOption) (int64, error)
+}
+
+var deletedAtType = reflect.TypeFor[DeletedAt]()
+
+// deletedAtColumn 返回模型的软删除字段名，以及用于条件的带引号字段名
+func deletedAtColumn[T any](db *gorm.DB) (name, quoted string, err error) {
+	stmt := gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return "", "", err
+	}
+	for _, f := range stmt.Schema.Fields {
+		if f.FieldType == deletedAtType && f.DBName != "" {
+			return f.DBName, stmt.Quote(f.DBName), nil
+		}
+	}
+	return "", "", fmt.Errorf("%w: %s", ErrNotSoftDelete, stmt.Schema.Name)
+}
+
+// Restore 恢复已删除的数据
+func (t Type[T]) Restore(ctx context.Context, opts ...QueryOption) (int64, error) {
+	return RestoreWithContext[T](ctx, t.db, opts...)
+}
+
+// ListTrashed 分页查询已删除的数据
+func (t Type[T]) ListTrashed(ctx context.Context, out *[]*T, p Pager, opts ...QueryOption) (int64, error) {
+	return ListTrashedWithContext(ctx, t.db, out, p, opts...)
+}
+
+// Purge 彻底删除 before 之前删除的数据
+func (t Type[T]) Purge(ctx context.Context, before time.Time, opts ...QueryOption) (int64, error) {
+	return PurgeWithContext[T](ctx, t.db, before, opts...)
+}
+
+// RestoreWithContext 恢复已删除的数据，必须指定条件，防止误恢复整张表
+func RestoreWithContext[T any](ctx context.Context, db *gorm.DB, opts ...QueryOption) (int64, error) {
+	if len(opts) == 0 {
+		return 0, fmt.Errorf("where is empty")
+	}
+	name, col, err := deletedAtColumn[T](db)
+	if err != nil {
+		return 0, err
+	}
+	tx := Conn(ctx, db).Unscoped().Model(new(T)).Where(col + " IS NOT NULL")
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	result := tx.Update(name, nil)
+	return result.RowsAffected, result.Error
+}
+
+// ListTrashedWithContext 分页查询已删除的数据
+func ListTrashedWithContext[T any](ctx context.Context, db *gorm.DB, out *[]*T, p Pager, opts ...QueryOption) (int64, error) {
+	_, col, err := deletedAtColumn[T](db)
+	if err != nil {
+		return 0, err
+	}
+	return ListWithContext(ctx, db, out, p, append([]QueryOption{Unscoped(), Where(col + " IS NOT NULL")}, opts...)...)
+}
+
+// PurgeWithContext 彻底删除 before 之前删除的数据
+func PurgeWithContext[T any](ctx context.Context, db *gorm.DB, before time.Time, opts ...QueryOption) (int64, error) {
+	_, col, err := deletedAtColumn[T](db)
+	if err != nil {
+		return 0, err
+	}
+	tx := Conn(ctx, db).Unscoped().Where(col+" < ?", before)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	result := tx.Delete(new(T))
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type trashItem struct {
+	ID        int       `gorm:"primaryKey"`
+	Name      string    `gorm:"notNull;default:''"`
+	DeletedAt DeletedAt `gorm:"index"`
+}
+
+func TestSoftDelete(t *testing.T) {
+	db := newTestDB(t, new(trashItem), new(scrollItem))
+	store := NewType[trashItem](db)
+	ctx := context.Background()
+	for _, name := range []string{"a", "b", "c"} {
+		if err := store.Create(ctx, &trashItem{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, id := range []int{1, 2} {
+		if err := store.Delete(ctx, new(trashItem), Where("id = ?", id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var items []*trashItem
+	if total, err := store.List(ctx, &items, nil); err != nil || total != 1 {
+		t.Fatalf("expect 1 item, got %d %v", total, err)
+	}
+	items = items[:0]
+	total, err := store.ListTrashed(ctx, &items, nil)
+	if err != nil || total != 2 || !items[0].DeletedAt.Valid {
+		t.Fatalf("expect 2 trashed, got %d %v", total, err)
+	}
+
+	if _, err := store.Restore(ctx); err == nil {
+		t.Fatal("expect where is empty")
+	}
+	// 未删除的数据不受影响
+	if n, err := store.Restore(ctx, Where("id IN ?", []int{1, 3})); err != nil || n != 1 {
+		t.Fatalf("expect 1 restored, got %d %v", n, err)
+	}
+	var item trashItem
+	if err := store.Get(ctx, &item, Where("id = ?", 1)); err != nil || item.DeletedAt.Valid {
+		t.Fatalf("expect restored, got %+v %v", item, err)
+	}
+
+	// 只清理 before 之前删除的数据
+	if n, err := store.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("expect nothing purged, got %d %v", n, err)
+	}
+	if n, err := store.Purge(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("expect 1 purged, got %d %v", n, err)
+	}
+	var count int64
+	if err := db.Unscoped().Model(new(trashItem)).Count(&count).Error; err != nil || count != 2 {
+		t.Fatalf("expect 2 rows, got %d %v", count, err)
+	}
+
+	if _, err := NewType[scrollItem](db).Purge(ctx, time.Now()); !errors.Is(err, ErrNotSoftDelete) {
+		t.Fatalf("expect ErrNotSoftDelete, got %v", err)
+	}
+}
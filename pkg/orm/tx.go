@@ -0,0 +1,88 @@
+package orm
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// unitOfWork 一次事务或保存点，记录提交后才能执行的函数
+type unitOfWork struct {
+	tx    *gorm.DB
+	mu    sync.Mutex
+	hooks []func(context.Context)
+}
+
+func (u *unitOfWork) add(fns ...func(context.Context)) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.hooks = append(u.hooks, fns...)
+}
+
+func (u *unitOfWork) take() []func(context.Context) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	hooks := u.hooks
+	u.hooks = nil
+	return hooks
+}
+
+// Transaction 工作单元，事务通过 ctx 传递
+// fn 内使用该 ctx 调用的 store 方法自动加入此事务，可以跨多个领域的 store 保证原子性
+// 嵌套调用时使用保存点，内层返回 error 仅回滚到保存点，外层可以选择继续
+// fn 返回 error 或 panic 时回滚，AfterCommit 注册的函数在最外层事务提交后执行，回滚时丢弃
+func Transaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	parent, _ := ctx.Value(txKey{}).(*unitOfWork)
+	if parent != nil {
+		db = parent.tx
+	}
+	var hooks []func(context.Context)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		u := unitOfWork{tx: tx}
+		if err := fn(context.WithValue(ctx, txKey{}, &u)); err != nil {
+			return err
+		}
+		hooks = u.take()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// 保存点成功仅代表暂时有效，需要等待外层事务提交
+	if parent != nil {
+		parent.add(hooks...)
+		return nil
+	}
+	for _, fn := range hooks {
+		fn(ctx)
+	}
+	return nil
+}
+
+// Conn 返回 ctx 中的事务，不在事务中时返回 db
+// store 应当通过此函数获取连接，而不是直接使用 db.WithContext(ctx)
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if u, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		return u.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTransaction ctx 是否处于 Transaction 中
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*unitOfWork)
+	return ok
+}
+
+// AfterCommit 事务提交后执行 fn，回滚时不执行，不在事务中时立即执行
+// 用于缓存失效等不能回滚的副作用，避免其它请求读到未提交或已回滚的数据
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if u, ok := ctx.Value(txKey{}).(*unitOfWork); ok {
+		u.add(fn)
+		return
+	}
+	fn(ctx)
+}
@@ -0,0 +1,101 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTransaction(t *testing.T) {
+	// 单连接，未加入事务的查询会阻塞
+	db := newTestDB(t, new(scrollItem))
+	store := NewType[scrollItem](db)
+	names := func() []string {
+		var out []string
+		if err := db.Model(new(scrollItem)).Order("id").Pluck("name", &out).Error; err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	errRollback := errors.New("rollback")
+	ctx := context.Background()
+
+	var hooks []string
+	err := Transaction(ctx, db, func(ctx context.Context) error {
+		if !InTransaction(ctx) {
+			t.Fatal("expect in transaction")
+		}
+		if err := store.Create(ctx, &scrollItem{Name: "a"}); err != nil {
+			return err
+		}
+		AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "a") })
+
+		// 内层失败仅回滚到保存点，注册的函数被丢弃
+		err := Transaction(ctx, db, func(ctx context.Context) error {
+			if err := store.Create(ctx, &scrollItem{Name: "b"}); err != nil {
+				return err
+			}
+			AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "b") })
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("expect rollback, got %v", err)
+		}
+
+		err = Transaction(ctx, db, func(ctx context.Context) error {
+			AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "c") })
+			return store.Update(ctx, &scrollItem{}, func(v *scrollItem) error {
+				v.Name = "c"
+				return nil
+			}, Where("name=?", "a"))
+		})
+		if err != nil {
+			return err
+		}
+		if len(hooks) != 0 {
+			t.Fatalf("hooks run before commit: %v", hooks)
+		}
+		var total int64
+		if total, err = CountWithContext[scrollItem](ctx, db); err != nil || total != 1 {
+			t.Fatalf("expect 1 row in transaction, got %d %v", total, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := []string{"a", "c"}; !reflect.DeepEqual(hooks, expect) {
+		t.Fatalf("expect hooks %v, got %v", expect, hooks)
+	}
+	if expect := []string{"c"}; !reflect.DeepEqual(names(), expect) {
+		t.Fatalf("expect %v, got %v", expect, names())
+	}
+
+	// 外层回滚时，内层已成功的保存点与注册的函数一并丢弃
+	hooks = nil
+	err = Transaction(ctx, db, func(ctx context.Context) error {
+		if err := Transaction(ctx, db, func(ctx context.Context) error {
+			AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "d") })
+			return store.Create(ctx, &scrollItem{Name: "d"})
+		}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("expect rollback, got %v", err)
+	}
+	if len(hooks) != 0 {
+		t.Fatalf("expect no hooks, got %v", hooks)
+	}
+	if expect := []string{"c"}; !reflect.DeepEqual(names(), expect) {
+		t.Fatalf("expect %v, got %v", expect, names())
+	}
+
+	// 不在事务中时立即执行
+	AfterCommit(ctx, func(context.Context) { hooks = append(hooks, "e") })
+	if len(hooks) != 1 {
+		t.Fatal("expect hook run immediately")
+	}
+}
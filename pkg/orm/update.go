@@ -100,10 +100,11 @@ func FirstWithContext(ctx context.Context, db *gorm.DB, out any, opts ...QueryOp
 	if len(opts) == 0 {
 		panic("where is empty")
 	}
+	db = Conn(ctx, db)
 	for _, opt := range opts {
 		db = opt(db)
 	}
-	return db.WithContext(ctx).First(out).Error
+	return db.First(out).Error
 }
 
 // Update 通用更新
@@ -112,7 +113,7 @@ func (t Type[T]) Update(ctx context.Context, model *T, changeFn func(*T) error,
 }
 
 func (t Type[T]) Create(ctx context.Context, model *T) error {
-	return t.db.WithContext(ctx).Create(model).Error
+	return Conn(ctx, t.db).Create(model).Error
 }
 
 func Update[T any](db *gorm.DB, model *T, changeFn func(*T), opts ...QueryOption) error {
@@ -121,7 +122,7 @@ func Update[T any](db *gorm.DB, model *T, changeFn func(*T), opts ...QueryOption
 
 func CountWithContext[T any](ctx context.Context, db *gorm.DB, opts ...QueryOption) (int64, error) {
 	var count int64
-	tx := db.WithContext(ctx).Model(new(T))
+	tx := Conn(ctx, db).Model(new(T))
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -132,7 +133,7 @@ func UpdateWithContext[T any](ctx context.Context, db *gorm.DB, model *T, change
 	if len(opts) == 0 {
 		panic("where is empty")
 	}
-	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return Conn(ctx, db).Transaction(func(tx *gorm.DB) error {
 		{
 			tx := tx.Clauses(clause.Locking{Strength: "UPDATE"})
 			for _, opt := range opts {
@@ -151,7 +152,7 @@ func UpdateWithContext2[T any](ctx context.Context, db *gorm.DB, model *T, chang
 	if len(opts) == 0 {
 		panic("where is empty")
 	}
-	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return Conn(ctx, db).Transaction(func(tx *gorm.DB) error {
 		{
 			tx := tx.Clauses(clause.Locking{Strength: "UPDATE"})
 			for _, opt := range opts {
@@ -200,11 +201,11 @@ func DeleteWithContext(ctx context.Context, db *gorm.DB, model any, opts ...Quer
 	if len(opts) == 0 {
 		return fmt.Errorf("where is empty")
 	}
-	db = db.Clauses(clause.Returning{})
+	db = Conn(ctx, db).Clauses(clause.Returning{})
 	for _, opt := range opts {
 		db = opt(db)
 	}
-	return db.WithContext(ctx).Delete(model).Error
+	return db.Delete(model).Error
 }
 
 type Pager interface {
@@ -227,7 +228,7 @@ func ListWithContext[T any](ctx context.Context, db *gorm.DB, out *[]*T, p Pager
 		limit = p.Limit()
 		offset = p.Offset()
 	}
-	db = db.Model(new(T)).WithContext(ctx)
+	db = Conn(ctx, db).Model(new(T))
 	for _, opt := range opts {
 		db = opt(db)
 	}
@@ -0,0 +1,78 @@
+package reason
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestRegistry(t *testing.T) {
+	entries := Registered()
+	for i := 1; i < len(entries); i++ {
+		if entries[i-1].Reason >= entries[i].Reason {
+			t.Fatalf("expect sorted and unique, got %s %s", entries[i-1].Reason, entries[i].Reason)
+		}
+	}
+	if e, ok := Lookup("ErrUnauthorizedToken"); !ok || e.HTTPStatus != 401 || e.Msg != "用户已过期或错误" {
+		t.Fatalf("unexpected %+v", e)
+	}
+	if ErrVersionConflict.GetHTTPCode() != 412 {
+		t.Fatalf("expect 412, got %d", ErrVersionConflict.GetHTTPCode())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic on duplicate reason")
+		}
+	}()
+	NewError("ErrNotFound", "重复")
+}
+
+func TestCatalog(t *testing.T) {
+	// 内置的错误均已翻译
+	for _, e := range Registered() {
+		if _, ok := Message("en", e.Reason); !ok {
+			t.Fatalf("missing en message for %s", e.Reason)
+		}
+	}
+
+	if msg := Localize(ErrNotFound, "en"); msg != "Resource not found" {
+		t.Fatalf("unexpected %s", msg)
+	}
+	// 默认语言保留 SetMsg 设置的消息
+	if msg := Localize(ErrNotFound.SetMsg("用户不存在"), DefaultLanguage); msg != "用户不存在" {
+		t.Fatalf("unexpected %s", msg)
+	}
+
+	for accept, expect := range map[string]string{
+		"":                           DefaultLanguage,
+		"en-US,en;q=0.9":             "en",
+		"fr;q=0.9, en;q=0.8":         "en",
+		"en;q=0.5, zh-CN;q=0.8":      "zh",
+		"ja, fr":                     DefaultLanguage,
+		"en;q=0, zh;q=0.1":           "zh",
+		"EN_us":                      "en",
+		"de-DE;q=1.0, ja-JP;q=0.9,*": DefaultLanguage,
+	} {
+		if v := MatchLanguage(accept); v != expect {
+			t.Fatalf("accept %q expect %s, got %s", accept, expect, v)
+		}
+	}
+
+	// 业务加载自己的目录
+	fsys := fstest.MapFS{
+		"i18n/ja.json": {Data: []byte(`{"ErrNotFound":"見つかりません"}`)},
+		"i18n/en.toml": {Data: []byte(`ErrCustom = "Custom"`)},
+	}
+	if err := LoadCatalogs(fsys, "i18n"); err != nil {
+		t.Fatal(err)
+	}
+	if v := MatchLanguage("ja-JP"); v != "ja" {
+		t.Fatalf("expect ja, got %s", v)
+	}
+	if msg, _ := Message("ja", "ErrNotFound"); msg != "見つかりません" {
+		t.Fatalf("unexpected %s", msg)
+	}
+	if msg, _ := Message("en", "ErrNotFound"); msg != "Resource not found" {
+		t.Fatalf("expect merged catalog, got %s", msg)
+	}
+}
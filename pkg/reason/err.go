@@ -5,7 +5,7 @@ var (
 	ErrBadRequest           = NewError("ErrBadRequest", "请求参数有误")
 	ErrDB                   = NewError("ErrStore", "数据发生错误")
 	ErrServer               = NewError("ErrServer", "服务器发生错误")
-	ErrUnauthorizedToken    = NewError("ErrUnauthorizedToken", "用户已过期或错误").SetHTTPStatus(401)
+	ErrUnauthorizedToken    = NewErrorWithStatus("ErrUnauthorizedToken", "用户已过期或错误", 401)
 	ErrJSON                 = NewError("ErrJSON", "JSON 编解码出错")
 	ErrNotFound             = NewError("ErrNotFound", "资源未找到")
 	ErrUsedLogic            = NewError("ErrUsedLogic", "使用逻辑错误")
@@ -20,7 +20,11 @@ var (
 	ErrUnsupportedMediaType = NewError("ErrUnsupportedMediaType", "不支持的媒体类型")
 	ErrContentTooLarge      = NewError("ErrContentTooLarge", "请求体过大")
 
-	ErrRateLimit = NewError("ErrRateLimit", "请求频率过高").SetHTTPStatus(429)
+	ErrRateLimit = NewErrorWithStatus("ErrRateLimit", "请求频率过高", 429)
+
+	// ErrVersionConflict 乐观锁版本号不一致，客户端应当重新获取后再修改
+	ErrVersionConflict      = NewErrorWithStatus("ErrVersionConflict", "数据已被修改，请刷新后重试", 412)
+	ErrPreconditionRequired = NewErrorWithStatus("ErrPreconditionRequired", "缺少 If-Match 请求头", 428)
 )
 
 // 业务错误
@@ -30,4 +34,4 @@ var (
 	ErrAccountDisabled = NewError("ErrAccountDisabled", "登录限制")
 )
 
-var _ error = NewError("test_new_error", "")
+var _ error = (*Error)(nil)
@@ -0,0 +1,172 @@
+package reason
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// DefaultLanguage NewError 中的消息使用的语言
+const DefaultLanguage = "zh"
+
+//go:embed locales
+var embedded embed.FS
+
+var (
+	catalogs   = make(map[string]map[string]string)
+	catalogsMu sync.RWMutex
+)
+
+func init() {
+	if err := LoadCatalogs(embedded, "locales"); err != nil {
+		panic(err)
+	}
+}
+
+// LoadCatalogs 加载目录下的 <语言>.toml 或 <语言>.json，内容为 reason 到消息的映射
+// 同一语言多次加载时合并，后加载的覆盖，业务可以加载自己的目录翻译新增的 reason
+func LoadCatalogs(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		if e.IsDir() || (ext != ".toml" && ext != ".json") {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		messages := make(map[string]string)
+		if ext == ".toml" {
+			err = toml.Unmarshal(b, &messages)
+		} else {
+			err = json.Unmarshal(b, &messages)
+		}
+		if err != nil {
+			return fmt.Errorf("catalog %s: %w", e.Name(), err)
+		}
+		AddMessages(strings.TrimSuffix(e.Name(), ext), messages)
+	}
+	return nil
+}
+
+// AddMessages 添加指定语言的消息，语言标签不区分大小写，例如 en、en-US
+func AddMessages(lang string, messages map[string]string) {
+	lang = normalizeLanguage(lang)
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	c, ok := catalogs[lang]
+	if !ok {
+		c = make(map[string]string, len(messages))
+		catalogs[lang] = c
+	}
+	for k, v := range messages {
+		c[k] = v
+	}
+}
+
+// Message 指定语言的消息，DefaultLanguage 返回注册时的消息，未翻译时返回 false
+func Message(lang, reason string) (string, bool) {
+	lang = normalizeLanguage(lang)
+	if lang == DefaultLanguage {
+		e, ok := Lookup(reason)
+		return e.Msg, ok
+	}
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	msg, ok := catalogs[lang][reason]
+	return msg, ok
+}
+
+// Localize 错误在指定语言下的消息
+// 默认语言返回错误当前的消息，保留 SetMsg 设置的内容；其它语言按 reason 翻译，未翻译时返回当前的消息
+func Localize(e ErrorInfoer, lang string) string {
+	if lang = normalizeLanguage(lang); lang == DefaultLanguage {
+		return e.GetMessage()
+	}
+	if msg, ok := Message(lang, e.GetReason()); ok {
+		return msg
+	}
+	return e.GetMessage()
+}
+
+// Languages 支持的语言，包含 DefaultLanguage
+func Languages() []string {
+	catalogsMu.RLock()
+	out := make([]string, 0, len(catalogs)+1)
+	for k := range catalogs {
+		out = append(out, k)
+	}
+	catalogsMu.RUnlock()
+	if !slices.Contains(out, DefaultLanguage) {
+		out = append(out, DefaultLanguage)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// MatchLanguage 按 Accept-Language 的权重选择支持的语言，例如 "en-US,en;q=0.9,zh;q=0.8"
+// en-US 不存在时匹配 en，无法匹配时返回 DefaultLanguage
+func MatchLanguage(accept string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for part := range strings.SplitSeq(accept, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{lang: normalizeLanguage(tag), q: q})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	supported := func(lang string) bool {
+		_, ok := catalogs[lang]
+		return ok || lang == DefaultLanguage
+	}
+	for _, c := range candidates {
+		if supported(c.lang) {
+			return c.lang
+		}
+		if base, _, ok := strings.Cut(c.lang, "-"); ok && supported(base) {
+			return base
+		}
+	}
+	return DefaultLanguage
+}
+
+// normalizeLanguage 小写并使用 - 分隔，例如 zh_CN 转为 zh-cn
+func normalizeLanguage(lang string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "_", "-")
+}
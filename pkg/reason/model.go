@@ -2,10 +2,22 @@ package reason
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 )
 
-var codes = make(map[string]string, 8)
+// Entry 已注册的错误，reason 在服务间保持稳定，可用于生成客户端的错误枚举
+type Entry struct {
+	Reason     string `json:"reason"`
+	HTTPStatus int    `json:"http_status"`
+	Msg        string `json:"msg"` // 默认语言的消息
+}
+
+var (
+	registry   = make(map[string]Entry, 32)
+	registryMu sync.RWMutex
+)
 
 type CustomError interface {
 	error
@@ -97,13 +109,44 @@ func (e *Error) GetReason() string {
 	return e.Reason
 }
 
-// NewError ..
+// NewError 注册错误，http 状态码为 400，reason 重复时 panic
+// msg 使用 DefaultLanguage，其它语言的消息在 locales 目录中按 reason 翻译
 func NewError(reason, msg string) CustomError {
-	if _, ok := codes[reason]; ok {
+	return NewErrorWithStatus(reason, msg, 400)
+}
+
+// NewErrorWithStatus 注册指定 http 状态码的错误，状态码记录在 Registered 中
+// 定义时使用 NewError(...).SetHTTPStatus 不会修改已注册的状态码
+func NewErrorWithStatus(reason, msg string, status int) CustomError {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[reason]; ok {
 		panic(fmt.Sprintf("err reason %s exists", reason))
 	}
-	codes[reason] = msg
-	return &Error{Reason: reason, Msg: msg, HTTPStatus: 400}
+	registry[reason] = Entry{Reason: reason, HTTPStatus: status, Msg: msg}
+	return &Error{Reason: reason, Msg: msg, HTTPStatus: status}
+}
+
+// Registered 已注册的全部错误，按 reason 排序
+func Registered() []Entry {
+	registryMu.RLock()
+	out := make([]Entry, 0, len(registry))
+	for _, v := range registry {
+		out = append(out, v)
+	}
+	registryMu.RUnlock()
+	slices.SortFunc(out, func(a, b Entry) int {
+		return strings.Compare(a.Reason, b.Reason)
+	})
+	return out
+}
+
+// Lookup 查询已注册的错误
+func Lookup(reason string) (Entry, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	v, ok := registry[reason]
+	return v, ok
 }
 
 func (e *Error) As(target any) bool {
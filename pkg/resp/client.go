@@ -0,0 +1,331 @@
+// resp
+// 实现 RESP 协议的精简客户端，可连接 redis/valkey/dragonfly 等兼容服务。
+// 仅覆盖缓存常用命令，复杂场景可通过 Do 发送任意命令。
+package resp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNil 键不存在
+var ErrNil = errors.New("resp: nil reply")
+
+// ErrClosed 客户端已关闭
+var ErrClosed = errors.New("resp: client closed")
+
+// Error 服务端返回的错误，例如 "ERR unknown command"
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// Config 连接配置
+type Config struct {
+	Addr         string        // 地址，例如 127.0.0.1:6379
+	Password     string        // 密码，为空时不执行 AUTH
+	DB           int           // 数据库编号
+	PoolSize     int           // 最大空闲连接数，默认 10
+	DialTimeout  time.Duration // 建连超时，默认 3 秒
+	ReadTimeout  time.Duration // 读写超时，ctx 没有 deadline 时生效，默认 3 秒
+	WriteTimeout time.Duration
+}
+
+// Client 并发安全的连接池客户端
+type Client struct {
+	cfg    Config
+	idle   chan *conn
+	mu     sync.Mutex
+	closed bool
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// NewClient 创建客户端，并通过 PING 检查连接
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 3 * time.Second
+	}
+	if cfg.ReadTimeout <= 0 {
+		cfg.ReadTimeout = 3 * time.Second
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = cfg.ReadTimeout
+	}
+	c := Client{
+		cfg:  cfg,
+		idle: make(chan *conn, cfg.PoolSize),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DialTimeout)
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Close 关闭所有空闲连接，正在使用的连接归还时关闭
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.idle)
+	for cn := range c.idle {
+		_ = cn.Close()
+	}
+	return nil
+}
+
+// Do 执行命令，返回值类型为 string/int64/[]any/nil
+// 键不存在时返回 ErrNil，服务端错误返回 Error
+func (c *Client) Do(ctx context.Context, args ...any) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v, err := cn.do(ctx, c.cfg, args)
+	// 服务端错误不影响连接复用，网络错误则丢弃连接
+	var e Error
+	if err != nil && !errors.As(err, &e) && !errors.Is(err, ErrNil) {
+		_ = cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return v, err
+}
+
+// Ping 检查连接
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Get 获取值，键不存在时返回 ErrNil
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	v, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	s, _ := v.(string)
+	return []byte(s), nil
+}
+
+// Set 设置值，ttl<=0 表示不过期
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []any{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// SetNX 仅当键不存在时设置值，返回是否设置成功
+func (c *Client) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	args := []any{"SET", key, value, "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	_, err := c.Do(ctx, args...)
+	if errors.Is(err, ErrNil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Del 删除键，返回删除的数量
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	v, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := v.(int64)
+	return n, nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	select {
+	case cn, ok := <-c.idle:
+		if ok {
+			return cn, nil
+		}
+		return nil, ErrClosed
+	default:
+	}
+	return c.dial(ctx)
+}
+
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		_ = cn.Close()
+		return
+	}
+	select {
+	case c.idle <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: c.cfg.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if c.cfg.Password != "" {
+		if _, err := cn.do(ctx, c.cfg, []any{"AUTH", c.cfg.Password}); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+	if c.cfg.DB > 0 {
+		if _, err := cn.do(ctx, c.cfg, []any{"SELECT", c.cfg.DB}); err != nil {
+			_ = nc.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (cn *conn) do(ctx context.Context, cfg Config, args []any) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(cfg.WriteTimeout)
+	}
+	_ = cn.SetWriteDeadline(deadline)
+	if err := writeCommand(cn.w, args); err != nil {
+		return nil, err
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		deadline = time.Now().Add(cfg.ReadTimeout)
+	}
+	_ = cn.SetReadDeadline(deadline)
+	return readReply(cn.r)
+}
+
+// writeCommand 以 RESP 数组格式写入命令
+func writeCommand(w *bufio.Writer, args []any) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		var b []byte
+		switch v := arg.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		default:
+			b = fmt.Append(nil, v)
+		}
+		if _, err := fmt.Fprintf(w, "$%d\r\n", len(b)); err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readReply 读取一个完整的 RESP 应答
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("resp: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return string(b[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, ErrNil
+		}
+		out := make([]any, n)
+		for i := range n {
+			v, err := readReply(r)
+			if err != nil && !errors.Is(err, ErrNil) {
+				return nil, err
+			}
+			out[i] = v
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("resp: unknown reply type %q", line[0])
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("resp: invalid line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,108 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/internal/resptest"
+)
+
+func newTestClient(t *testing.T, password string) *Client {
+	t.Helper()
+	srv, err := resptest.NewServer("", password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	cli, err := NewClient(Config{Addr: srv.Addr(), Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cli.Close() })
+	return cli
+}
+
+func TestClient(t *testing.T) {
+	cli := newTestClient(t, "")
+	ctx := context.Background()
+
+	if _, err := cli.Get(ctx, "a"); !errors.Is(err, ErrNil) {
+		t.Fatalf("expect ErrNil, got %v", err)
+	}
+	if err := cli.Set(ctx, "a", []byte("1\r\n2"), 0); err != nil {
+		t.Fatal(err)
+	}
+	v, err := cli.Get(ctx, "a")
+	if err != nil || string(v) != "1\r\n2" {
+		t.Fatalf("expect 1\\r\\n2, got %q %v", v, err)
+	}
+
+	ok, err := cli.SetNX(ctx, "a", []byte("3"), 0)
+	if err != nil || ok {
+		t.Fatalf("expect setnx fail, got %v %v", ok, err)
+	}
+	n, err := cli.Del(ctx, "a", "b")
+	if err != nil || n != 1 {
+		t.Fatalf("expect del 1, got %d %v", n, err)
+	}
+
+	var e Error
+	if _, err := cli.Do(ctx, "NOPE"); !errors.As(err, &e) {
+		t.Fatalf("expect server error, got %v", err)
+	}
+	// 服务端错误后，连接仍可复用
+	if err := cli.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientTTL(t *testing.T) {
+	cli := newTestClient(t, "")
+	ctx := context.Background()
+
+	if err := cli.Set(ctx, "a", []byte("1"), 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if _, err := cli.Get(ctx, "a"); !errors.Is(err, ErrNil) {
+		t.Fatalf("expect expired, got %v", err)
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	srv, err := resptest.NewServer("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	if _, err := NewClient(Config{Addr: srv.Addr(), Password: "wrong"}); err == nil {
+		t.Fatal("expect auth fail")
+	}
+	cli := newTestClient(t, "secret")
+	if err := cli.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientConcurrent(t *testing.T) {
+	cli := newTestClient(t, "")
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			key := string(rune('a' + i%26))
+			if err := cli.Set(ctx, key, []byte(key), 0); err != nil {
+				t.Error(err)
+			}
+			if _, err := cli.Get(ctx, key); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+}
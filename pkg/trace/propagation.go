@@ -0,0 +1,135 @@
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+
+	maxTracestate = 512
+)
+
+// ParseTraceparent 解析 W3C traceparent，格式为 version-trace_id-parent_id-flags
+// 兼容更高版本追加的字段，version 为 ff 或 id 全为 0 时无效
+func ParseTraceparent(traceparent, tracestate string) (SpanContext, bool) {
+	var sc SpanContext
+	s := strings.TrimSpace(traceparent)
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, false
+	}
+	version, err := hex.DecodeString(s[:2])
+	if err != nil || version[0] == 0xff || !isLowerHex(s[:2]) {
+		return sc, false
+	}
+	// 版本 00 的长度固定，更高版本追加的字段以 - 分隔
+	if (version[0] == 0 && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, false
+	}
+	if !isLowerHex(s[3:35]) || !isLowerHex(s[36:52]) || !isLowerHex(s[53:55]) {
+		return sc, false
+	}
+	_, _ = hex.Decode(sc.TraceID[:], []byte(s[3:35]))
+	_, _ = hex.Decode(sc.SpanID[:], []byte(s[36:52]))
+	flags, _ := strconv.ParseUint(s[53:55], 16, 8)
+	sc.Sampled = flags&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	if ts := strings.TrimSpace(tracestate); len(ts) <= maxTracestate {
+		sc.TraceState = ts
+	}
+	sc.Remote = true
+	return sc, true
+}
+
+func isLowerHex(s string) bool {
+	for i := range len(s) {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Traceparent 生成版本 00 的 traceparent
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// Extract 解析请求头中的 traceparent，有效时作为之后 span 的父级
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, ok := ParseTraceparent(h.Get(HeaderTraceparent), h.Get(HeaderTracestate))
+	if !ok {
+		return ctx
+	}
+	return ContextWithRemote(ctx, sc)
+}
+
+// Inject ctx 中存在 span 时，写入 traceparent 与 tracestate
+func Inject(ctx context.Context, h http.Header) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(HeaderTraceparent, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(HeaderTracestate, sc.TraceState)
+	}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport 为 http.Client 的请求创建 span，并向下游传递 traceparent
+// base 为 nil 时使用 http.DefaultTransport
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), "HTTP "+req.Method, KindClient,
+		Attr{Key: "http.request.method", Value: req.Method},
+		Attr{Key: "url.full", Value: redactURL(req)},
+		Attr{Key: "server.address", Value: req.URL.Hostname()},
+	)
+	defer span.End()
+
+	// RoundTripper 不能修改传入的请求
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	span.SetAttr("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 400 {
+		span.SetStatus(StatusError, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// redactURL 去掉查询参数与用户信息，避免记录令牌等敏感数据
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
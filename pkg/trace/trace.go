@@ -0,0 +1,392 @@
+// trace
+// 兼容 OpenTelemetry 的链路追踪，通过 W3C traceparent/tracestate 在服务间传递
+// span 以 OTLP/HTTP JSON 上报，或写入文件用于离线排查
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	mrand "math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ixugo/goddd/pkg/metrics"
+)
+
+// TraceID 16 字节的链路 id
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (t TraceID) IsValid() bool  { return t != TraceID{} }
+
+// SpanID 8 字节的 span id
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+func (s SpanID) IsValid() bool  { return s != SpanID{} }
+
+// SpanContext 跨进程传递的 span 标识
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+	// Remote 是否来自上游的 traceparent
+	Remote bool
+}
+
+// IsValid trace id 与 span id 均不为零
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// SpanKind span 类型，取值与 OTLP 一致
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// StatusCode span 状态，取值与 OTLP 一致
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attr span 属性，值支持 string/bool/整数/浮点数，其它类型按 fmt.Sprint 输出
+type Attr struct {
+	Key   string
+	Value any
+}
+
+// SpanData 结束后交给 Exporter 的 span
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanID
+	Start         time.Time
+	End           time.Time
+	Attrs         []Attr
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Span 一次操作，方法对 nil 安全
+type Span struct {
+	tracer    *Tracer
+	recording bool
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext span 标识
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// IsRecording 是否记录并上报，未采样或没有配置 Exporter 时为 false
+func (s *Span) IsRecording() bool {
+	return s != nil && s.recording
+}
+
+// SetName 修改名称，例如路由匹配后使用路由模板
+func (s *Span) SetName(name string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Name = name
+	s.mu.Unlock()
+}
+
+// SetAttr 设置属性，同名覆盖
+func (s *Span) SetAttr(key string, value any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.Attrs {
+		if s.data.Attrs[i].Key == key {
+			s.data.Attrs[i].Value = value
+			return
+		}
+	}
+	s.data.Attrs = append(s.data.Attrs, Attr{Key: key, Value: value})
+}
+
+// SetError 标记失败，err 为 nil 时忽略
+func (s *Span) SetError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+	s.mu.Unlock()
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status = code
+	s.data.StatusMessage = msg
+	s.mu.Unlock()
+}
+
+// End 结束并提交上报，重复调用忽略
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	s.tracer.enqueue(data)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan ctx 中保存 span，之后创建的 span 以此为父级
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext ctx 中的 span，不存在时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemote 上游传入的 span 作为父级，不会上报
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return ContextWithSpan(ctx, &Span{data: SpanData{SpanContext: sc}})
+}
+
+// Exporter 批量上报结束的 span，实现 io.Closer 时在 Tracer.Close 中关闭
+// resource 为服务名称等描述服务的属性
+type Exporter interface {
+	Export(ctx context.Context, resource []Attr, spans []SpanData) error
+}
+
+// Config 链路追踪配置
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	// SampleRatio 没有上游时的采样率 0~1，上游传入时跟随上游的采样标记
+	SampleRatio float64
+	// BatchSize 单次上报的数量，默认 512
+	BatchSize int
+	// FlushInterval 上报间隔，默认 5s
+	FlushInterval time.Duration
+	// QueueSize 等待上报的数量，队列满时丢弃，默认 2048
+	QueueSize int
+}
+
+func (c *Config) setDefaults() {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 512
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 5 * time.Second
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 2048
+	}
+	c.SampleRatio = min(max(c.SampleRatio, 0), 1)
+}
+
+var dropped = metrics.Default.NewCounter("trace_spans_dropped_total", "Spans dropped because the export queue was full.").With()
+
+// Tracer 创建 span 并批量上报
+type Tracer struct {
+	cfg       Config
+	exporters []Exporter
+	resource  []Attr
+
+	queue chan SpanData
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// New 没有 exporters 时仍然生成 trace id 并传递给下游，但不记录 span
+func New(cfg Config, exporters ...Exporter) *Tracer {
+	cfg.setDefaults()
+	t := Tracer{
+		cfg:       cfg,
+		exporters: exporters,
+		resource: []Attr{
+			{Key: "service.name", Value: cfg.ServiceName},
+			{Key: "service.version", Value: cfg.ServiceVersion},
+		},
+		queue: make(chan SpanData, cfg.QueueSize),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if len(exporters) == 0 {
+		close(t.done)
+		return &t
+	}
+	go t.run()
+	return &t
+}
+
+// Start 创建 span，ctx 中存在 span 时作为父级
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sc := SpanContext{SpanID: newSpanID()}
+	data := SpanData{Name: name, Kind: kind, Start: time.Now(), Attrs: attrs}
+	if parent := SpanFromContext(ctx).SpanContext(); parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+		sc.TraceState = parent.TraceState
+		data.Parent = parent.SpanID
+	} else {
+		sc.TraceID = newTraceID()
+		sc.Sampled = len(t.exporters) > 0 && sample(t.cfg.SampleRatio)
+	}
+	data.SpanContext = sc
+	span := Span{tracer: t, recording: sc.Sampled && len(t.exporters) > 0, data: data}
+	return ContextWithSpan(ctx, &span), &span
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	select {
+	case t.queue <- data:
+	default:
+		dropped.Inc()
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, t.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		t.export(batch)
+		batch = make([]SpanData, 0, t.cfg.BatchSize)
+	}
+	for {
+		select {
+		case data := <-t.queue:
+			if batch = append(batch, data); len(batch) >= t.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stop:
+			// 退出前上报队列中剩余的 span
+			for {
+				select {
+				case data := <-t.queue:
+					if batch = append(batch, data); len(batch) >= t.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) export(batch []SpanData) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, e := range t.exporters {
+		if err := e.Export(ctx, t.resource, batch); err != nil {
+			slog.Warn("trace export", "spans", len(batch), "err", err)
+		}
+	}
+}
+
+// Close 上报剩余的 span 并关闭 Exporter
+func (t *Tracer) Close() error {
+	t.once.Do(func() { close(t.stop) })
+	<-t.done
+	var errs []error
+	for _, e := range t.exporters {
+		if c, ok := e.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+func init() {
+	defaultTracer.Store(New(Config{}))
+}
+
+// SetDefault 设置全局 Tracer，web.Logger、orm 与 Transport 使用
+func SetDefault(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// Default 全局 Tracer，未设置时只生成 id 不记录
+func Default() *Tracer {
+	return defaultTracer.Load()
+}
+
+// Start 使用全局 Tracer 创建 span
+func Start(ctx context.Context, name string, kind SpanKind, attrs ...Attr) (context.Context, *Span) {
+	return Default().Start(ctx, name, kind, attrs...)
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func sample(ratio float64) bool {
+	return ratio >= 1 || mrand.Float64() < ratio
+}
@@ -0,0 +1,164 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(valid, "congo=t61rcWkgMzE")
+	if !ok || !sc.Sampled || !sc.Remote || sc.TraceState != "congo=t61rcWkgMzE" {
+		t.Fatalf("unexpected %+v %v", sc, ok)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected ids %s %s", sc.TraceID, sc.SpanID)
+	}
+	if v := sc.Traceparent(); v != valid {
+		t.Fatalf("expect %s, got %s", valid, v)
+	}
+
+	for _, v := range []string{
+		"",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceparent(v, ""); ok {
+			t.Fatalf("expect invalid %q", v)
+		}
+	}
+	// 更高版本追加的字段忽略
+	if _, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra", ""); !ok {
+		t.Fatal("expect valid future version")
+	}
+}
+
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (e *memoryExporter) Export(_ context.Context, _ []Attr, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	var exporter memoryExporter
+	tracer := New(Config{ServiceName: "test", SampleRatio: 1}, &exporter)
+	prev := Default()
+	SetDefault(tracer)
+	defer SetDefault(prev)
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	// 沿用上游的 trace id
+	h := http.Header{}
+	h.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := Extract(context.Background(), h)
+	ctx, root := Start(ctx, "GET /", KindServer)
+	if root.SpanContext().TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !root.IsRecording() {
+		t.Fatalf("unexpected root %+v", root.SpanContext())
+	}
+
+	client := http.Client{Transport: Transport(nil)}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/x?token=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if req.Header.Get(HeaderTraceparent) != "" {
+		t.Fatal("expect request not modified")
+	}
+	sc, ok := ParseTraceparent(got.Get(HeaderTraceparent), "")
+	if !ok || sc.TraceID != root.SpanContext().TraceID || sc.SpanID == root.SpanContext().SpanID {
+		t.Fatalf("unexpected traceparent %q", got.Get(HeaderTraceparent))
+	}
+	root.End()
+	root.End()
+
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(exporter.spans) != 2 {
+		t.Fatalf("expect 2 spans, got %d", len(exporter.spans))
+	}
+	client0 := exporter.spans[0]
+	if client0.Kind != KindClient || client0.Parent != root.SpanContext().SpanID || client0.Status != StatusError {
+		t.Fatalf("unexpected client span %+v", client0)
+	}
+	for _, a := range client0.Attrs {
+		if a.Key == "url.full" && strings.Contains(a.Value.(string), "secret") {
+			t.Fatalf("expect query redacted, got %s", a.Value)
+		}
+	}
+	if exporter.spans[1].Parent.String() != "00f067aa0ba902b7" {
+		t.Fatalf("expect remote parent, got %s", exporter.spans[1].Parent)
+	}
+
+	// 没有 Exporter 时不记录，但仍然生成 trace id
+	_, span := New(Config{SampleRatio: 1}).Start(context.Background(), "x", KindInternal)
+	if span.IsRecording() || !span.SpanContext().IsValid() {
+		t.Fatal("expect valid non-recording span")
+	}
+}
+
+func TestExporter(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "Basic x" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "trace", "spans.json")
+	fe, err := NewFileExporter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := New(Config{ServiceName: "svc", SampleRatio: 1}, NewOTLPExporter(srv.URL, map[string]string{"Authorization": "Basic x"}), fe)
+	_, span := tracer.Start(context.Background(), "op", KindInternal, Attr{Key: "n", Value: 1}, Attr{Key: "ok", Value: true})
+	span.End()
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var req otlpRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatal(err, string(body))
+	}
+	rs := req.ResourceSpans[0]
+	if rs.Resource.Attributes[0].Value["stringValue"] != "svc" || rs.ScopeSpans[0].Spans[0].Name != "op" {
+		t.Fatalf("unexpected %s", body)
+	}
+	if v := rs.ScopeSpans[0].Spans[0].Attributes[0].Value["intValue"]; v != "1" {
+		t.Fatalf("expect intValue as string, got %v", v)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil || strings.Count(string(b), "\n") != 1 || !strings.Contains(string(b), span.SpanContext().TraceID.String()) {
+		t.Fatalf("unexpected file %s %v", b, err)
+	}
+}
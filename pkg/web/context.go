@@ -3,6 +3,9 @@ package web
 import (
 	"context"
 	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/orm"
 )
 
 // Context 扩展 context.Context，携带 HTTP 请求的元信息。
@@ -33,3 +36,11 @@ func (c *httpRequestContext) GetBaseURL() string                 { return GetBas
 func (c *httpRequestContext) GetScheme() string                  { return GetScheme(c.req) }
 func (c *httpRequestContext) GetHost() string                    { return GetHost(c.req) }
 func (c *httpRequestContext) BaseURLJoin(paths ...string) string { return BaseURLJoin(c.req, paths...) }
+
+// ReadYourWrites 记录请求内的写入，写入后的查询使用主库，配合 orm.Replicas 读写分离
+func ReadYourWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(orm.WithReadYourWrites(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// Versioned 带乐观锁版本号的出参，WrapH 据此输出 ETag，orm.Model 已实现
+type Versioned interface {
+	GetVersion() int
+}
+
+// ETag 由版本号生成强校验的 ETag
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// IfMatch 嵌入 PUT/PATCH 的入参后，WrapH 要求请求携带 If-Match，取值为 GET 响应的 ETag，可以是逗号分隔的多个 ETag
+// 缺少时返回 428，格式不正确时返回 400，没有可以匹配的 ETag 时返回 412；"*" 表示不校验版本号
+type IfMatch struct {
+	versions []int
+}
+
+// IfMatchVersion 客户端持有的版本号，0 表示不校验，配合 orm.UpdateOptimistic 使用
+// 携带多个 ETag 时返回第一个，此时应当在 changeFn 中使用 MatchVersion 校验
+func (m IfMatch) IfMatchVersion() int {
+	if len(m.versions) == 0 {
+		return 0
+	}
+	return m.versions[0]
+}
+
+// MatchVersion 版本号是否与 If-Match 中的任意一个 ETag 匹配，"*" 时总是匹配
+func (m IfMatch) MatchVersion(version int) bool {
+	return len(m.versions) == 0 || slices.Contains(m.versions, version)
+}
+
+// setIfMatch 由 WrapH 调用
+func (m *IfMatch) setIfMatch(header string) error {
+	header = strings.TrimSpace(header)
+	switch header {
+	case "":
+		return reason.ErrPreconditionRequired
+	case "*":
+		m.versions = nil
+		return nil
+	}
+	versions := make([]int, 0, 1)
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		opaque, weak := strings.CutPrefix(tag, "W/")
+		if len(opaque) < 2 || opaque[0] != '"' || opaque[len(opaque)-1] != '"' || strings.Contains(opaque[1:len(opaque)-1], `"`) {
+			return reason.ErrBadRequest.SetMsg("If-Match 格式有误").Withf("If-Match[%s]", header)
+		}
+		// If-Match 使用强比较，弱 ETag 与非版本号的 ETag 永远不匹配
+		if v, err := strconv.Atoi(opaque[1 : len(opaque)-1]); err == nil && v > 0 && !weak {
+			versions = append(versions, v)
+		}
+	}
+	if len(versions) == 0 {
+		return reason.ErrVersionConflict.Withf("If-Match[%s]", header)
+	}
+	m.versions = versions
+	return nil
+}
+
+// bindIfMatch 入参嵌入 IfMatch 时，解析 PUT/PATCH 请求的 If-Match
+func bindIfMatch(c *gin.Context, in any) error {
+	v, ok := in.(interface{ setIfMatch(string) error })
+	if !ok {
+		return nil
+	}
+	switch c.Request.Method {
+	case http.MethodPut, http.MethodPatch:
+		return v.setIfMatch(c.GetHeader("If-Match"))
+	}
+	return nil
+}
+
+// setETag 出参实现 Versioned 时输出 ETag，删除后的资源不再有版本
+func setETag(c *gin.Context, out any) {
+	v, ok := out.(Versioned)
+	if !ok || c.Request.Method == http.MethodDelete {
+		return
+	}
+	if rv := reflect.ValueOf(out); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return
+	}
+	c.Header("ETag", ETag(v.GetVersion()))
+}
@@ -0,0 +1,300 @@
+package web
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Key jwt 签名密钥
+// 非对称密钥只有公钥时，仅能用于验证，常见于轮换前的旧密钥
+type Key struct {
+	ID     string // 写入 token 头部的 kid
+	Method jwt.SigningMethod
+	sign   any
+	verify any
+}
+
+// CanSign 是否可以签发 token
+func (k *Key) CanSign() bool {
+	return k.sign != nil
+}
+
+// NewHMACKey HS256 对称密钥，id 为空时签发的 token 不带 kid，与 NewToken 兼容
+func NewHMACKey(id, secret string) (*Key, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("secret is required")
+	}
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)}, nil
+}
+
+// LoadKeyFile 从 PEM 文件加载密钥，参考 ParseKeyPEM
+func LoadKeyFile(id, alg, path string) (*Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKeyPEM(id, alg, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// ParseKeyPEM 解析 PEM 格式的 RSA/ECDSA/Ed25519 私钥、公钥或证书
+// alg 为空时根据密钥类型推断，RSA 为 RS256，ECDSA 按曲线为 ES256/ES384/ES512，Ed25519 为 EdDSA
+func ParseKeyPEM(id, alg string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid pem")
+	}
+	key := Key{ID: id}
+	if priv, err := parsePrivateKey(block.Bytes); err == nil {
+		key.sign = priv
+		key.verify = priv.(crypto.Signer).Public()
+	} else if pub, err := parsePublicKey(block.Bytes); err == nil {
+		key.verify = pub
+	} else {
+		return nil, fmt.Errorf("unsupported pem type %q", block.Type)
+	}
+
+	if alg == "" {
+		alg = defaultAlg(key.verify)
+	}
+	key.Method = jwt.GetSigningMethod(alg)
+	if key.Method == nil || !algMatchKey(alg, key.verify) {
+		return nil, fmt.Errorf("alg %q does not match key type %T", alg, key.verify)
+	}
+	return &key, nil
+}
+
+func parsePrivateKey(der []byte) (any, error) {
+	if k, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return k, nil
+	}
+	return x509.ParseECPrivateKey(der)
+}
+
+func parsePublicKey(der []byte) (any, error) {
+	if k, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return k, nil
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return cert.PublicKey, nil
+}
+
+func defaultAlg(pub any) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return "RS256"
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P384():
+			return "ES384"
+		case elliptic.P521():
+			return "ES512"
+		}
+		return "ES256"
+	case ed25519.PublicKey:
+		return "EdDSA"
+	}
+	return ""
+}
+
+func algMatchKey(alg string, pub any) bool {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+			return true
+		}
+	case *ecdsa.PublicKey:
+		return alg == defaultAlg(k)
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// KeySet 签名密钥集合，使用 current 签发 token，使用全部密钥验证
+// 轮换密钥后，旧密钥签发的 token 在过期前仍然有效
+type KeySet struct {
+	mu      sync.RWMutex
+	current *Key
+	keys    map[string]*Key
+}
+
+// NewKeySet current 用于签发，previous 仅用于验证
+func NewKeySet(current *Key, previous ...*Key) (*KeySet, error) {
+	var s KeySet
+	if err := s.Replace(current, previous...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// NewHMACKeySet 使用 HS256 单一秘钥，与 NewToken/ParseToken 行为一致
+func NewHMACKeySet(secret string) (*KeySet, error) {
+	key, err := NewHMACKey("", secret)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(key)
+}
+
+// Replace 整体替换密钥集合，用于配置重载
+func (s *KeySet) Replace(current *Key, previous ...*Key) error {
+	if current == nil || !current.CanSign() {
+		return fmt.Errorf("current key must be able to sign")
+	}
+	keys := make(map[string]*Key, len(previous)+1)
+	for _, k := range previous {
+		if _, ok := keys[k.ID]; ok {
+			return fmt.Errorf("duplicate kid %q", k.ID)
+		}
+		keys[k.ID] = k
+	}
+	if _, ok := keys[current.ID]; ok {
+		return fmt.Errorf("duplicate kid %q", current.ID)
+	}
+	keys[current.ID] = current
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = current
+	s.keys = keys
+	return nil
+}
+
+// Rotate 使用 next 签发新 token，原密钥保留用于验证
+func (s *KeySet) Rotate(next *Key) error {
+	if next == nil || !next.CanSign() {
+		return fmt.Errorf("next key must be able to sign")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[next.ID] = next
+	s.current = next
+	return nil
+}
+
+// Remove 移除旧密钥，其签发的 token 立即失效，不能移除当前密钥
+func (s *KeySet) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current.ID != id {
+		delete(s.keys, id)
+	}
+}
+
+// NewToken 使用当前密钥签发 token，参数同 NewToken
+func (s *KeySet) NewToken(data map[string]any, opts ...TokenOptions) (string, error) {
+	s.mu.RLock()
+	key := s.current
+	s.mu.RUnlock()
+
+	tc := jwt.NewWithClaims(key.Method, newClaims(data, opts...))
+	if key.ID != "" {
+		tc.Header["kid"] = key.ID
+	}
+	return tc.SignedString(key.sign)
+}
+
+// ParseToken 根据头部的 kid 选择密钥验证签名，不校验有效期，参数同 ParseToken
+func (s *KeySet) ParseToken(tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		s.mu.RLock()
+		key, ok := s.keys[kid]
+		s.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		// 防止算法混淆，例如使用 RSA 公钥作为 HMAC 秘钥伪造 token
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected alg %q", t.Method.Alg())
+		}
+		return key.verify, nil
+	}, jwt.WithoutClaimsValidation())
+	return &claims, err
+}
+
+// JWK RFC 7517 公钥
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS 公钥集合，对称密钥不会公开
+func (s *KeySet) JWKS() []JWK {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]JWK, 0, len(s.keys))
+	for _, k := range s.keys {
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+		switch pub := k.verify.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64(pub.N.Bytes())
+			jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = b64(pub.X.FillBytes(make([]byte, size)))
+			jwk.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64(pub)
+		default:
+			continue
+		}
+		out = append(out, jwk)
+	}
+	slices.SortFunc(out, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return out
+}
+
+// JWKSHandler 通常挂载到 /.well-known/jwks.json，供其它服务验证 token
+func (s *KeySet) JWKSHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, gin.H{"keys": s.JWKS()})
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
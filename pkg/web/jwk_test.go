@@ -0,0 +1,154 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func pemKey(t *testing.T, priv any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func pemPublicKey(t *testing.T, pub any) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		kid  string
+		priv any
+		alg  string
+	}{
+		{kid: "rsa", priv: rsaKey, alg: "RS256"},
+		{kid: "ec", priv: ecKey, alg: "ES256"},
+		{kid: "ed", priv: edKey, alg: "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			key, err := ParseKeyPEM(tt.kid, "", pemKey(t, tt.priv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Method.Alg() != tt.alg {
+				t.Fatalf("expect %s, got %s", tt.alg, key.Method.Alg())
+			}
+			ks, err := NewKeySet(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := ks.NewToken(NewClaimsData().SetUserID(7))
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := ks.ParseToken(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims.Data[KeyUserID].(float64) != 7 {
+				t.Fatal("uid not equal")
+			}
+			if jwks := ks.JWKS(); len(jwks) != 1 || jwks[0].Kid != tt.kid {
+				t.Fatalf("unexpected jwks %+v", jwks)
+			}
+		})
+	}
+}
+
+func TestKeySetDuplicateKid(t *testing.T) {
+	legacy, _ := NewHMACKey("", "legacy_secret")
+	edKey, _ := ParseKeyPEM("", "", pemKey(t, ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))))
+	if _, err := NewKeySet(edKey, legacy); err == nil {
+		t.Fatal("expect duplicate kid error")
+	}
+	edKey.ID = "ed"
+	if _, err := NewKeySet(edKey, legacy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeySetRotate(t *testing.T) {
+	legacy, _ := NewHMACKey("", "legacy_secret")
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	k1, _ := ParseKeyPEM("k1", "", pemKey(t, ecKey))
+	ks, err := NewKeySet(k1, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 轮换前使用 HS256 签发的 token 仍然有效
+	old, _ := NewToken(NewClaimsData(), "legacy_secret")
+	if _, err := ks.ParseToken(old); err != nil {
+		t.Fatal(err)
+	}
+	// 对称密钥不公开
+	if jwks := ks.JWKS(); len(jwks) != 1 {
+		t.Fatalf("expect 1 key, got %d", len(jwks))
+	}
+
+	t1, _ := ks.NewToken(NewClaimsData())
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	k2, _ := ParseKeyPEM("k2", "", pemKey(t, edKey))
+	if err := ks.Rotate(k2); err != nil {
+		t.Fatal(err)
+	}
+	t2, _ := ks.NewToken(NewClaimsData())
+	for _, token := range []string{t1, t2} {
+		if _, err := ks.ParseToken(token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ks.Remove("k1")
+	if _, err := ks.ParseToken(t1); err == nil {
+		t.Fatal("expect removed key to be rejected")
+	}
+
+	// 仅有公钥的密钥不能用于签发
+	pub, err := ParseKeyPEM("pub", "", pemPublicKey(t, edKey.Public()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Rotate(pub); err == nil {
+		t.Fatal("expect public key rotate failed")
+	}
+}
+
+func TestKeySetAlgConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key, _ := ParseKeyPEM("rsa", "", pemKey(t, rsaKey))
+	ks, _ := NewKeySet(key)
+
+	// 使用公开的 RSA 公钥作为 HMAC 秘钥伪造 token
+	tc := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(NewClaimsData().SetLevel(1)))
+	tc.Header["kid"] = "rsa"
+	forged, _ := tc.SignedString(pemPublicKey(t, &rsaKey.PublicKey))
+	if _, err := ks.ParseToken(forged); err == nil {
+		t.Fatal("expect forged token rejected")
+	}
+
+	if _, err := ParseKeyPEM("rsa", "ES256", pemKey(t, rsaKey)); err == nil {
+		t.Fatal("expect alg mismatch")
+	}
+}
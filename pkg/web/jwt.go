@@ -1,12 +1,14 @@
 package web
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/ixugo/goddd/pkg/orm"
 	"github.com/ixugo/goddd/pkg/reason"
 )
 
@@ -17,6 +19,8 @@ const (
 	KeyLevel       = "level"
 	KeyRoleID      = "role_id"
 	KeyUsername    = "username"
+	KeyTenantID    = "tenant_id"
+	KeyLanguage    = "lang"
 	KeyTokenString = "token"
 )
 
@@ -69,14 +73,81 @@ func (c ClaimsData) SetUsername(username string) ClaimsData {
 	return c
 }
 
+// SetTenantID 多租户部署时签发，鉴权后该请求的数据库操作只能访问此租户的数据
+func (c ClaimsData) SetTenantID(tenantID string) ClaimsData {
+	c[KeyTenantID] = tenantID
+	return c
+}
+
+// SetLanguage 用户选择的语言，例如 en，错误消息优先使用此语言，其次为请求头 Accept-Language
+func (c ClaimsData) SetLanguage(lang string) ClaimsData {
+	c[KeyLanguage] = lang
+	return c
+}
+
 func (c ClaimsData) Set(key string, value any) ClaimsData {
 	c[key] = value
 	return c
 }
 
+// Get 实现 Geter，可以使用 GetUID、GetUsername 等函数读取
+func (c ClaimsData) Get(key any) (any, bool) {
+	k, _ := key.(string)
+	v, ok := c[k]
+	return v, ok
+}
+
+// GetString 实现 Geter
+func (c ClaimsData) GetString(key any) string {
+	v, _ := c.Get(key)
+	s, _ := v.(string)
+	return s
+}
+
+type claimsDataKey struct{}
+
+// ClaimsDataFrom 获取鉴权中间件放入 ctx 的载荷，未登录时返回 nil，读取时返回零值
+// 用于拿不到 *gin.Context 的场景，例如 orm 回调
+func ClaimsDataFrom(ctx context.Context) ClaimsData {
+	data, _ := ctx.Value(claimsDataKey{}).(ClaimsData)
+	return data
+}
+
+// SetClaimsData 将登录用户的载荷写入 gin.Context 并放入 c.Request 的 ctx，携带租户时同时设置租户
+// 自定义鉴权的 HandlerOption 可以调用此函数
+func SetClaimsData(c *gin.Context, data ClaimsData) {
+	for k, v := range data {
+		c.Set(k, v)
+	}
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), claimsDataKey{}, data))
+	if tenantID, _ := data[KeyTenantID].(string); tenantID != "" {
+		SetTenantID(c, tenantID)
+	}
+}
+
+// requireTenant 多租户部署时拒绝不携带租户的令牌
+var requireTenant bool
+
+// SetRequireTenant 开启后，鉴权中间件拒绝 Claims 中没有 tenant_id 的令牌
+// 没有租户的请求不按租户过滤，多租户部署时必须开启，防止其读到全部租户的数据
+func SetRequireTenant(ok bool) {
+	requireTenant = ok
+}
+
 // AuthMiddleware 鉴权
 // handler 可以拦截请求，返回 true 则跳过默认鉴权行为，可以通过此参数自定义鉴权方案
 func AuthMiddleware(secret string, handler ...HandlerOption) gin.HandlerFunc {
+	return authMiddleware(func(s string) (*Claims, error) {
+		return ParseToken(s, secret)
+	}, handler...)
+}
+
+// AuthMiddlewareWithKeySet 鉴权，支持非对称签名与密钥轮换
+func AuthMiddlewareWithKeySet(keys *KeySet, handler ...HandlerOption) gin.HandlerFunc {
+	return authMiddleware(keys.ParseToken, handler...)
+}
+
+func authMiddleware(parse func(string) (*Claims, error), handler ...HandlerOption) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		for _, h := range handler {
 			if h(c) {
@@ -95,7 +166,7 @@ func AuthMiddleware(secret string, handler ...HandlerOption) gin.HandlerFunc {
 			AbortWithStatusJSON(c, reason.ErrUnauthorizedToken.SetMsg("身份验证失败"))
 			return
 		}
-		claims, err := ParseToken(auth[len(prefix):], secret)
+		claims, err := parse(auth[len(prefix):])
 		if err != nil {
 			AbortWithStatusJSON(c, reason.ErrUnauthorizedToken.SetMsg("身份验证失败"))
 			return
@@ -104,11 +175,13 @@ func AuthMiddleware(secret string, handler ...HandlerOption) gin.HandlerFunc {
 			AbortWithStatusJSON(c, reason.ErrUnauthorizedToken.SetMsg("请重新登录"))
 			return
 		}
+		if tenantID, _ := claims.Data[KeyTenantID].(string); requireTenant && tenantID == "" {
+			AbortWithStatusJSON(c, reason.ErrUnauthorizedToken.SetMsg("身份验证失败"))
+			return
+		}
 
 		c.Set(KeyTokenString, auth)
-		for k, v := range claims.Data {
-			c.Set(k, v)
-		}
+		SetClaimsData(c, claims.Data)
 		c.Next()
 	}
 }
@@ -123,6 +196,18 @@ func GetUsername(c Geter) string {
 	return c.GetString(KeyUsername)
 }
 
+// GetTenantID 获取租户 ID，单租户部署时为空
+func GetTenantID(c Geter) string {
+	return c.GetString(KeyTenantID)
+}
+
+// SetTenantID 设置请求的租户，并放入 c.Request 的 ctx，之后的 orm 操作按租户过滤
+// 自定义鉴权的 HandlerOption 可以调用此函数
+func SetTenantID(c *gin.Context, tenantID string) {
+	c.Set(KeyTenantID, tenantID)
+	c.Request = c.Request.WithContext(orm.WithTenant(c.Request.Context(), tenantID))
+}
+
 // GetRole 获取用户角色
 func GetRoleID(c Geter) int {
 	return GetInt(c, KeyRoleID)
@@ -225,6 +310,11 @@ func NewToken(data map[string]any, secret string, opts ...TokenOptions) (string,
 	if secret == "" {
 		return "", fmt.Errorf("secret is required")
 	}
+	tc := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims(data, opts...))
+	return tc.SignedString([]byte(secret))
+}
+
+func newClaims(data map[string]any, opts ...TokenOptions) Claims {
 	now := time.Now()
 	claims := Claims{
 		Data: data,
@@ -237,6 +327,5 @@ func NewToken(data map[string]any, secret string, opts ...TokenOptions) (string,
 	for _, opt := range opts {
 		opt(&claims)
 	}
-	tc := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return tc.SignedString([]byte(secret))
+	return claims
 }
@@ -51,6 +51,64 @@ func TestClaimsData(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_ClaimsDataFrom(t *testing.T) {
+	const secret = "test_secret_key"
+	token, err := NewToken(NewClaimsData().SetUserID(7).SetUsername("admin"), secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uid int
+	var username string
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(secret), func(c *gin.Context) {
+		// 不依赖 *gin.Context，从 ctx 中读取登录用户
+		data := ClaimsDataFrom(c.Request.Context())
+		uid, username = GetUID(data), GetUsername(data)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if uid != 7 || username != "admin" {
+		t.Fatalf("expect 7 admin, got %d %q", uid, username)
+	}
+
+	if data := ClaimsDataFrom(req.Context()); GetUID(data) != 0 || GetUsername(data) != "" {
+		t.Fatalf("expect empty claims without auth, got %v", data)
+	}
+}
+
+func TestAuthMiddleware_RequireTenant(t *testing.T) {
+	const secret = "test_secret_key"
+	SetRequireTenant(true)
+	defer SetRequireTenant(false)
+
+	r := gin.New()
+	r.GET("/me", AuthMiddleware(secret), func(c *gin.Context) {
+		c.String(http.StatusOK, GetTenantID(c))
+	})
+	for _, tt := range []struct {
+		data   ClaimsData
+		expect int
+	}{
+		{data: NewClaimsData().SetUserID(1).SetTenantID("t1"), expect: http.StatusOK},
+		{data: NewClaimsData().SetUserID(1), expect: http.StatusUnauthorized},
+		{data: NewClaimsData().SetUserID(1).SetTenantID(""), expect: http.StatusUnauthorized},
+	} {
+		token, err := NewToken(tt.data, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.expect {
+			t.Fatalf("%v expect %d, got %d", tt.data, tt.expect, w.Code)
+		}
+	}
+}
+
 func TestEtag(t *testing.T) {
 	gin.SetMode(gin.ReleaseMode)
 	g := gin.New()
@@ -2,7 +2,6 @@ package web
 
 import (
 	"bytes"
-	"encoding/hex"
 	"io"
 	"log/slog"
 	"net/http"
@@ -12,7 +11,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/ixugo/goddd/pkg/logger"
 )
 
@@ -92,9 +90,11 @@ func IgoreContains(substrs ...string) IngoreOption {
 // 入参是忽略函数，返回 true 则忽略，比如网页请求可以忽略
 func Logger(ignoreFn ...IngoreOption) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		guid := uuid.New()
-		traceID := hex.EncodeToString(guid[:])
-		c.Request = c.Request.WithContext(logger.WithAttr(c.Request.Context(), slog.String("trace_id", traceID)))
+		// 请求头存在 traceparent 时沿用上游的 trace id，日志与 span 使用相同的 trace id
+		ctx, span := startServerSpan(c)
+		defer endServerSpan(c, span)
+		traceID := span.SpanContext().TraceID.String()
+		c.Request = c.Request.WithContext(logger.WithAttr(ctx, slog.String("trace_id", traceID)))
 		SetTraceID(c, traceID)
 
 		for _, fn := range ignoreFn {
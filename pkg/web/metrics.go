@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/metrics"
 	"github.com/ixugo/goddd/pkg/queue"
 )
 
@@ -17,7 +18,8 @@ import (
 // 4. HTTP 响应成功和错误的比率是多少?
 // 深入了解以上内容有助于把控程序，并得到预警。
 
-// Metrics ...
+// Metrics 请求计数发布到 expvar，耗时、响应大小等分布发布到 metrics.Default
+// 通过 SetupMetrics 注册的路由以 Prometheus 格式输出
 func Metrics() gin.HandlerFunc {
 	request := expvar.NewInt("request")
 	totalRequests := expvar.NewInt("requests")
@@ -25,9 +27,18 @@ func Metrics() gin.HandlerFunc {
 	urls := expvar.NewMap("requestURLs")
 	statusCodes := expvar.NewMap("statusCodes")
 
+	inFlight := metrics.Default.NewGauge("http_requests_in_flight", "Number of HTTP requests being served.").With()
+	duration := metrics.Default.NewHistogram("http_request_duration_seconds", "HTTP request latency in seconds.", metrics.DefBuckets, "method", "route")
+	size := metrics.Default.NewHistogram("http_response_size_bytes", "HTTP response size in bytes.", metrics.ExponentialBuckets(100, 10, 6), "method", "route")
+	responses := metrics.Default.NewCounter("http_responses_total", "Total HTTP responses by status class.", "method", "route", "class")
+
 	return func(c *gin.Context) {
 		totalRequests.Add(1)
 		request.Add(1)
+		inFlight.Inc()
+		// handler panic 时仍然需要减少
+		defer inFlight.Dec()
+		start := time.Now()
 		c.Next()
 		request.Add(-1)
 		totalResponses.Add(1)
@@ -37,6 +48,16 @@ func Metrics() gin.HandlerFunc {
 			urls.Add(c.Request.Method+" "+c.FullPath(), 1)
 		}
 		statusCodes.Add(strconv.Itoa(status), 1)
+
+		// 未匹配的路由统一记录，避免扫描请求产生大量标签
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		duration.With(method, route).Observe(time.Since(start).Seconds())
+		size.With(method, route).Observe(float64(max(c.Writer.Size(), 0)))
+		responses.With(method, route, strconv.Itoa(status/100)+"xx").Inc()
 	}
 }
 
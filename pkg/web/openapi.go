@@ -0,0 +1,560 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeDoc 注册路由时记录的入参与出参类型
+type routeDoc struct {
+	in, out reflect.Type
+	name    string // 业务函数名，作为 operationId
+}
+
+var (
+	// routeDocs key 为 "方法 完整路径"，value 为 *routeDoc，重复注册同一路由时覆盖
+	routeDocs sync.Map
+	// customDocs key 为 CustomMethods 注册的 "POST /jobs:method"，value 为 map[string]*routeDoc
+	customDocs sync.Map
+)
+
+func newRouteDoc[I, O any](fn func(*gin.Context, *I) (O, error)) *routeDoc {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+	// 匿名函数没有可读的名称
+	if strings.HasPrefix(name, "func") {
+		name = ""
+	}
+	return &routeDoc{in: reflect.TypeFor[I](), out: reflect.TypeFor[O](), name: name}
+}
+
+// Endpoint WrapH 包装后的处理函数，同时携带出入参类型，由 NewEndpoint 创建
+type Endpoint struct {
+	handler gin.HandlerFunc
+	doc     *routeDoc
+}
+
+// NewEndpoint 使用 WrapH 包装业务函数，配合 CustomEndpoints 注册时生成文档
+func NewEndpoint[I, O any](fn func(*gin.Context, *I) (O, error)) Endpoint {
+	return Endpoint{handler: WrapH(fn), doc: newRouteDoc(fn)}
+}
+
+// Handle 使用 WrapH 包装业务函数并注册路由，同时记录出入参类型用于生成 OpenAPI 文档
+// handlers 为业务函数之前执行的中间件
+//
+// 示例：
+// web.Handle(group, http.MethodGet, "/:id", api.getUser)
+func Handle[I, O any](g gin.IRoutes, method, relativePath string, fn func(*gin.Context, *I) (O, error), handlers ...gin.HandlerFunc) {
+	routeDocs.Store(method+" "+fullPath(g, relativePath), newRouteDoc(fn))
+	g.Handle(method, relativePath, append(handlers, WrapH(fn))...)
+}
+
+// CustomEndpoints 与 CustomMethods 相同，同时记录每个自定义方法的出入参类型用于生成 OpenAPI 文档
+//
+// 示例：
+// web.CustomEndpoints(group, "/tokens", map[string]web.Endpoint{
+// "refresh": web.NewEndpoint(api.refreshToken),
+// })
+func CustomEndpoints(g gin.IRouter, relativePath string, data map[string]Endpoint) {
+	handlers := make(map[string]func(*gin.Context), len(data))
+	docs := make(map[string]*routeDoc, len(data))
+	for k, v := range data {
+		k, _ := strings.CutPrefix(k, ":")
+		handlers[k] = v.handler
+		docs[k] = v.doc
+	}
+	customDocs.Store(http.MethodPost+" "+fullPath(g, relativePath+":method"), docs)
+	CustomMethods(g, relativePath, handlers)
+}
+
+// fullPath 与 gin 拼接路由的规则一致
+func fullPath(g gin.IRoutes, relativePath string) string {
+	base := "/"
+	if v, ok := g.(interface{ BasePath() string }); ok {
+		base = v.BasePath()
+	}
+	if relativePath == "" {
+		return base
+	}
+	out := path.Join(base, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(out, "/") {
+		out += "/"
+	}
+	return out
+}
+
+// OpenAPIInfo 文档描述
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema JSON Schema，OpenAPI 3.1 与 JSON Schema 2020-12 兼容
+type Schema = map[string]any
+
+// OpenAPIHandler 提供 OpenAPI 3.1 文档，应在所有路由注册完成后调用
+// 仅包含通过 Handle 与 CustomEndpoints 注册的路由，文档在首次请求时生成
+func OpenAPIHandler(r *gin.Engine, info OpenAPIInfo) gin.HandlerFunc {
+	var once sync.Once
+	var body []byte
+	return func(c *gin.Context) {
+		once.Do(func() {
+			body, _ = json.Marshal(NewOpenAPI(r.Routes(), info))
+		})
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
+
+// NewOpenAPI 根据路由生成 OpenAPI 3.1 文档
+func NewOpenAPI(routes gin.RoutesInfo, info OpenAPIInfo) map[string]any {
+	b := schemaBuilder{components: openAPIComponents(), names: make(map[string]reflect.Type)}
+	paths := make(map[string]map[string]any)
+	add := func(method, path string, doc *routeDoc) {
+		path, params := openAPIPath(path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]any)
+		}
+		paths[path][strings.ToLower(method)] = b.operation(method, path, params, doc)
+	}
+
+	for _, route := range routes {
+		// OpenAPI 不支持描述 CONNECT，常见于 gin.Any 注册的路由
+		if route.Method == http.MethodConnect {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		if v, ok := routeDocs.Load(key); ok {
+			add(route.Method, route.Path, v.(*routeDoc))
+			continue
+		}
+		if v, ok := customDocs.Load(key); ok {
+			base := strings.TrimSuffix(route.Path, ":method")
+			for action, doc := range v.(map[string]*routeDoc) {
+				add(route.Method, base+":"+action, doc)
+			}
+		}
+	}
+
+	return map[string]any{
+		"openapi":    "3.1.0",
+		"info":       info,
+		"paths":      paths,
+		"components": map[string]any{"schemas": b.components, "responses": openAPIResponses(), "securitySchemes": openAPISecuritySchemes()},
+	}
+}
+
+var pathParamRe = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// openAPIPath 将 gin 的 /users/:id 转换为 /users/{id}
+// CustomMethods 的 /tokens:refresh 中冒号后为固定文本，不是参数
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		m := pathParamRe.FindStringSubmatch(seg)
+		params = append(params, m[1])
+		segments[i] = "{" + m[1] + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func openAPIComponents() map[string]Schema {
+	return map[string]Schema{
+		"Error": {
+			"type":        "object",
+			"description": "统一错误响应，reason 为错误码，msg 可直接展示给用户，details 仅在调试模式下返回",
+			"properties": Schema{
+				"reason":   Schema{"type": "string"},
+				"msg":      Schema{"type": "string"},
+				"details":  Schema{"type": "array", "items": Schema{"type": "string"}},
+				"trace_id": Schema{"type": "string"},
+			},
+			"required": []string{"reason", "msg"},
+		},
+		"Problem": {
+			"type":        "object",
+			"description": "RFC 9457 错误响应，请求头 Accept 为 application/problem+json 或全局启用时返回，errors 为字段级的校验错误",
+			"properties": Schema{
+				"type":     Schema{"type": "string"},
+				"title":    Schema{"type": "string"},
+				"status":   Schema{"type": "integer"},
+				"detail":   Schema{"type": "string"},
+				"instance": Schema{"type": "string"},
+				"reason":   Schema{"type": "string"},
+				"details":  Schema{"type": "array", "items": Schema{"type": "string"}},
+				"trace_id": Schema{"type": "string"},
+				"errors": Schema{"type": "array", "items": Schema{
+					"type": "object",
+					"properties": Schema{
+						"field": Schema{"type": "string"},
+						"in":    Schema{"type": "string", "enum": []string{"uri", "query", "body"}},
+						"rule":  Schema{"type": "string"},
+						"param": Schema{"type": "string"},
+					},
+					"required": []string{"field", "in", "rule"},
+				}},
+			},
+			"required": []string{"type", "title", "status"},
+		},
+	}
+}
+
+func openAPIResponses() map[string]any {
+	return map[string]any{
+		"Error": map[string]any{
+			"description": "请求失败",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": Schema{"$ref": "#/components/schemas/Error"}},
+				ContentTypeProblem: map[string]any{"schema": Schema{"$ref": "#/components/schemas/Problem"}},
+			},
+		},
+	}
+}
+
+func openAPISecuritySchemes() map[string]any {
+	return map[string]any{
+		"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+	}
+}
+
+type schemaBuilder struct {
+	components map[string]Schema
+	names      map[string]reflect.Type
+}
+
+var (
+	pagerFilterType = reflect.TypeFor[PagerFilter]()
+	timeType        = reflect.TypeFor[time.Time]()
+	marshalerType   = reflect.TypeFor[json.Marshaler]()
+)
+
+func (b *schemaBuilder) operation(method, path string, params []string, doc *routeDoc) map[string]any {
+	op := map[string]any{
+		"responses": map[string]any{
+			"200":     map[string]any{"description": "OK", "content": map[string]any{"application/json": map[string]any{"schema": b.output(doc)}}},
+			"400":     map[string]any{"$ref": "#/components/responses/Error"},
+			"default": map[string]any{"$ref": "#/components/responses/Error"},
+		},
+	}
+	if doc.name != "" {
+		op["operationId"] = doc.name
+	}
+	if tag := strings.Split(strings.TrimPrefix(path, "/"), "/")[0]; tag != "" {
+		tag, _, _ = strings.Cut(tag, ":")
+		op["tags"] = []string{tag}
+	}
+
+	in := doc.in
+	for in.Kind() == reflect.Pointer {
+		in = in.Elem()
+	}
+	fields := flattenFields(in)
+
+	parameters := make([]map[string]any, 0, len(params)+len(fields))
+	for _, name := range params {
+		schema := Schema{"type": "string"}
+		for _, f := range fields {
+			if tagName(f, "uri") == name {
+				schema, _ = b.field(f)
+			}
+		}
+		parameters = append(parameters, map[string]any{"name": name, "in": "path", "required": true, "schema": schema})
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodDelete:
+		for _, f := range fields {
+			name := tagName(f, "form")
+			if name == "" {
+				continue
+			}
+			schema, required := b.field(f)
+			for _, opt := range strings.Split(f.Tag.Get("form"), ",")[1:] {
+				if v, ok := strings.CutPrefix(opt, "default="); ok {
+					schema["default"] = v
+				}
+			}
+			parameters = append(parameters, map[string]any{"name": name, "in": "query", "required": required, "schema": schema})
+		}
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		if in.Kind() == reflect.Struct && in.Size() > 0 {
+			op["requestBody"] = map[string]any{
+				"content": map[string]any{"application/json": map[string]any{"schema": b.schema(in)}},
+			}
+		}
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+// output 出参为 any 或 gin.H 时，若入参包含 PagerFilter，按分页约定描述为 {items,total}
+func (b *schemaBuilder) output(doc *routeDoc) Schema {
+	out := doc.out
+	if out.Kind() != reflect.Interface && out.Kind() != reflect.Map {
+		return b.schema(out)
+	}
+	in := doc.in
+	for in.Kind() == reflect.Pointer {
+		in = in.Elem()
+	}
+	if hasEmbedded(in, pagerFilterType) {
+		return Schema{
+			"type": "object",
+			"properties": Schema{
+				"items": Schema{"type": "array", "items": Schema{}},
+				"total": Schema{"type": "integer", "format": "int64"},
+			},
+		}
+	}
+	return b.schema(out)
+}
+
+func hasEmbedded(t reflect.Type, target reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && (f.Type == target || hasEmbedded(f.Type, target)) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenFields 展开匿名嵌套的结构体字段，与 gin 绑定的行为一致
+func flattenFields(t reflect.Type) []reflect.StructField {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	out := make([]reflect.StructField, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			out = append(out, flattenFields(ft)...)
+			continue
+		}
+		if f.IsExported() {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// tagName 标签中的名称，"-" 与未设置返回空串
+func tagName(f reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(key), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// field 字段的 schema 与是否必填
+func (b *schemaBuilder) field(f reflect.StructField) (Schema, bool) {
+	schema := b.schema(f.Type)
+	rules := f.Tag.Get("binding")
+	if rules == "" {
+		return schema, false
+	}
+	// 引用类型的 schema 是共享的，约束需要包装一层
+	if _, ok := schema["$ref"]; ok {
+		schema = Schema{"allOf": []Schema{schema}}
+	}
+	return schema, applyBinding(schema, f.Type, rules)
+}
+
+// applyBinding 将 validator 的规则转换为 schema 约束，返回是否必填
+func applyBinding(s Schema, t reflect.Type, rules string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var required bool
+	for rule := range strings.SplitSeq(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			// dive 之后的规则作用于元素
+			return required
+		case "required":
+			required = true
+		case "min", "gte":
+			s[minKey(t)] = number(value)
+		case "max", "lte":
+			s[maxKey(t)] = number(value)
+		case "gt":
+			s["exclusiveMinimum"] = number(value)
+		case "lt":
+			s["exclusiveMaximum"] = number(value)
+		case "len":
+			s[minKey(t)], s[maxKey(t)] = number(value), number(value)
+		case "oneof":
+			enum := make([]any, 0, 4)
+			for v := range strings.FieldsSeq(value) {
+				if isNumberKind(t.Kind()) {
+					enum = append(enum, number(v))
+				} else {
+					enum = append(enum, v)
+				}
+			}
+			s["enum"] = enum
+		case "email":
+			s["format"] = "email"
+		case "url", "uri":
+			s["format"] = "uri"
+		case "uuid":
+			s["format"] = "uuid"
+		case "ipv4", "ipv6":
+			s["format"] = key
+		}
+	}
+	return required
+}
+
+func minKey(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "minLength"
+	case reflect.Slice, reflect.Array:
+		return "minItems"
+	case reflect.Map:
+		return "minProperties"
+	}
+	return "minimum"
+}
+
+func maxKey(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "maxLength"
+	case reflect.Slice, reflect.Array:
+		return "maxItems"
+	case reflect.Map:
+		return "maxProperties"
+	}
+	return "maximum"
+}
+
+func number(s string) any {
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v
+	}
+	return s
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+var componentNameRe = regexp.MustCompile(`[\w.-]*/`)
+
+// componentName 包名.类型名，泛型参数去掉导入路径
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	name := componentNameRe.ReplaceAllString(t.Name(), "")
+	return strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "").Replace(pkg + "." + name)
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && (t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)):
+		// 自定义序列化的结构体，例如 orm.Time，通常输出为字符串
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return b.ref(t)
+	}
+	// interface 等无法确定的类型
+	return Schema{}
+}
+
+func (b *schemaBuilder) ref(t reflect.Type) Schema {
+	name := componentName(t)
+	for i := 2; b.names[name] != nil && b.names[name] != t; i++ {
+		name = componentName(t) + strconv.Itoa(i)
+	}
+	ref := Schema{"$ref": "#/components/schemas/" + name}
+	if b.names[name] == t {
+		return ref
+	}
+	// 先占位，防止递归类型无限展开
+	b.names[name] = t
+	b.components[name] = Schema{}
+	b.components[name] = b.object(t)
+	return ref
+}
+
+func (b *schemaBuilder) object(t reflect.Type) Schema {
+	properties := make(Schema)
+	required := make([]string, 0, 2)
+	for _, f := range flattenFields(t) {
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema, ok := b.field(f)
+		properties[name] = schema
+		if ok {
+			required = append(required, name)
+		}
+	}
+	out := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
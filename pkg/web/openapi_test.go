@@ -0,0 +1,119 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type openAPIUser struct {
+	ID     int           `json:"id"`
+	Name   string        `json:"name"`
+	Friend *openAPIUser  `json:"friend,omitempty"`
+	Tags   []string      `json:"tags"`
+	Secret string        `json:"-"`
+	Items  []openAPIUser `json:"items"`
+}
+
+type findOpenAPIUserInput struct {
+	PagerFilter
+	Name   string `form:"name" binding:"max=20"`
+	Status int    `form:"status,default=1" binding:"oneof=1 2"`
+}
+
+type addOpenAPIUserInput struct {
+	Name  string `json:"name" binding:"required,min=2"`
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	g := gin.New()
+	Handle(g, http.MethodGet, "/users", func(_ *gin.Context, _ *findOpenAPIUserInput) (any, error) { return nil, nil })
+	Handle(g.Group("/users"), http.MethodGet, "/:id", func(_ *gin.Context, _ *struct{}) (*openAPIUser, error) { return nil, nil })
+	Handle(g, http.MethodPost, "/users", func(_ *gin.Context, _ *addOpenAPIUserInput) (*openAPIUser, error) { return nil, nil })
+	CustomEndpoints(g.Group("/v1"), "/users", map[string]Endpoint{
+		"export": NewEndpoint(func(_ *gin.Context, _ *struct{}) (PageOutput[openAPIUser], error) {
+			return PageOutput[openAPIUser]{}, nil
+		}),
+	})
+	g.GET("/raw", func(*gin.Context) {})
+	g.GET("/wrapped", WrapH(func(_ *gin.Context, _ *struct{}) (*openAPIUser, error) { return nil, nil }))
+	g.GET("/openapi.json", OpenAPIHandler(g, OpenAPIInfo{Title: "test", Version: "1.0.0"}))
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name     string         `json:"name"`
+				In       string         `json:"in"`
+				Required bool           `json:"required"`
+				Schema   map[string]any `json:"schema"`
+			} `json:"parameters"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema map[string]any `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]map[string]any `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Fatalf("expect 3.1.0, got %s", doc.OpenAPI)
+	}
+	for _, path := range []string{"/users", "/users/{id}", "/v1/users:export"} {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Fatalf("expect path %s, got %v", path, doc.Paths)
+		}
+	}
+	for _, path := range []string{"/raw", "/wrapped"} {
+		if _, ok := doc.Paths[path]; ok {
+			t.Fatalf("expect route %s not registered by Handle ignored", path)
+		}
+	}
+
+	params := make(map[string]map[string]any)
+	for _, p := range doc.Paths["/users"]["get"].Parameters {
+		params[p.Name] = p.Schema
+	}
+	for _, name := range []string{"page", "size", "sort", "name", "status"} {
+		if _, ok := params[name]; !ok {
+			t.Fatalf("expect query %s, got %v", name, params)
+		}
+	}
+	if params["name"]["maxLength"] != float64(20) || params["status"]["default"] != "1" {
+		t.Fatalf("unexpected binding rules %v", params)
+	}
+	if id := doc.Paths["/users/{id}"]["get"].Parameters; len(id) != 1 || id[0].In != "path" || !id[0].Required {
+		t.Fatalf("unexpected path param %+v", id)
+	}
+
+	body := doc.Paths["/users"]["post"].RequestBody.Content["application/json"].Schema
+	ref, _ := body["$ref"].(string)
+	add := doc.Components.Schemas[ref[len("#/components/schemas/"):]]
+	if req, _ := add["required"].([]any); len(req) != 1 || req[0] != "name" {
+		t.Fatalf("unexpected required %v", add)
+	}
+
+	user := doc.Components.Schemas["web.openAPIUser"]
+	props, _ := user["properties"].(map[string]any)
+	if _, ok := props["Secret"]; ok || len(props) != 5 {
+		t.Fatalf("unexpected properties %v", props)
+	}
+	if _, ok := doc.Components.Schemas["Error"]; !ok {
+		t.Fatal("expect Error schema")
+	}
+	if _, ok := doc.Components.Schemas["web.PageOutput_web.openAPIUser"]; !ok {
+		t.Fatalf("expect PageOutput schema, got %v", doc.Components.Schemas)
+	}
+}
@@ -0,0 +1,53 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// PermissionChecker 判断角色是否拥有权限，通常由 rbac 领域实现
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, roleID int, permission string) (bool, error)
+}
+
+var permissionChecker PermissionChecker
+
+// SetPermissionChecker 设置 RequirePermission 使用的权限来源，应在服务启动前调用
+func SetPermissionChecker(checker PermissionChecker) {
+	permissionChecker = checker
+}
+
+// RequirePermission 要求当前角色拥有 permission，格式为 resource:action
+// 需要放在 AuthMiddleware 之后，通过 GetRoleID 获取角色
+// 与 AuthLevel 一样支持 IgnorePrefix 等忽略规则
+func RequirePermission(permission string, ignoreFn ...IngoreOption) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, fn := range ignoreFn {
+			if fn(c) {
+				c.Next()
+				return
+			}
+		}
+
+		if permissionChecker == nil {
+			slog.ErrorContext(c.Request.Context(), "RequirePermission", "err", "permission checker is not set")
+			AbortWithStatusJSON(c, reason.ErrServer)
+			return
+		}
+		ok, err := permissionChecker.HasPermission(c.Request.Context(), GetRoleID(c), permission)
+		if err != nil {
+			slog.ErrorContext(c.Request.Context(), "RequirePermission", "permission", permission, "err", err)
+			AbortWithStatusJSON(c, reason.ErrServer)
+			return
+		}
+		if !ok {
+			AbortWithStatusJSON(c, reason.ErrPermissionDenied.SetHTTPStatus(http.StatusForbidden))
+			return
+		}
+		c.Next()
+	}
+}
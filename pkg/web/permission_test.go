@@ -0,0 +1,52 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type staticChecker map[int][]string
+
+func (s staticChecker) HasPermission(_ context.Context, roleID int, permission string) (bool, error) {
+	for _, v := range s[roleID] {
+		if v == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	SetPermissionChecker(staticChecker{1: {"user:read"}})
+	defer SetPermissionChecker(nil)
+
+	r := gin.New()
+	r.GET("/users", func(c *gin.Context) {
+		// 模拟 AuthMiddleware，json 反序列化后数字为 float64
+		roleID, _ := strconv.Atoi(c.Query("role"))
+		c.Set(KeyRoleID, float64(roleID))
+	}, RequirePermission("user:read"), func(c *gin.Context) {
+		c.String(200, "OK")
+	})
+
+	tests := []struct {
+		role   string
+		expect int
+	}{
+		{role: "1", expect: http.StatusOK},
+		{role: "2", expect: http.StatusForbidden},
+		{role: "", expect: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?role="+tt.role, nil))
+		if w.Code != tt.expect {
+			t.Fatalf("role %q expect %d, got %d", tt.role, tt.expect, w.Code)
+		}
+	}
+}
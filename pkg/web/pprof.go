@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/metrics"
 )
 
 // debugAccess 授权指定 ip 访问
@@ -45,6 +46,12 @@ func SetupPProf(r gin.IRouter, ips *[]string) {
 	debug.GET("/vars", gin.WrapH(expvar.Handler()))
 }
 
+// SetupMetrics 以 Prometheus 文本格式输出 metrics.Default，请求头 Accept 包含 OpenMetrics 时使用 OpenMetrics 格式
+// 与 SetupPProf 使用相同的访问白名单
+func SetupMetrics(r gin.IRouter, ips *[]string) {
+	r.GET("/debug/metrics", debugAccess(ips), gin.WrapH(metrics.Default.Handler()))
+}
+
 // SetupMutexProfile 启用互斥锁采样，rate=1 开启采样, rate<=0 关闭采样
 func SetupMutexProfile(rate int) {
 	runtime.SetBlockProfileRate(rate)
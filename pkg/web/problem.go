@@ -0,0 +1,233 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/ixugo/goddd/pkg/reason"
+)
+
+// ContentTypeProblem RFC 9457 定义的错误响应格式
+const ContentTypeProblem = "application/problem+json"
+
+// ErrorFormat 错误响应的格式
+type ErrorFormat string
+
+const (
+	// ErrorFormatAuto 请求头 Accept 包含 application/problem+json 时使用 problem，否则使用 envelope
+	ErrorFormatAuto ErrorFormat = "auto"
+	// ErrorFormatEnvelope {reason,msg,details,trace_id}
+	ErrorFormatEnvelope ErrorFormat = "envelope"
+	// ErrorFormatProblem RFC 9457 problem+json
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+var (
+	errorFormat     = ErrorFormatAuto
+	problemTypeBase string
+)
+
+// SetErrorFormat 设置 Fail 与 AbortWithStatusJSON 的错误格式，无法识别的格式按 ErrorFormatAuto 处理
+// typeBase 不为空时 problem 的 type 为 typeBase+reason，例如 https://example.com/errors/ErrNotFound，否则为 about:blank
+func SetErrorFormat(format ErrorFormat, typeBase string) {
+	errorFormat = format
+	problemTypeBase = typeBase
+}
+
+// FieldError 字段级的校验错误
+type FieldError struct {
+	Field string `json:"field"`           // 参数名，嵌套时以 . 分隔，例如 items[0].name
+	In    string `json:"in"`              // 参数位置 uri/query/body
+	Rule  string `json:"rule"`            // 未通过的校验规则，例如 required、max，类型错误时为 type
+	Param string `json:"param,omitempty"` // 规则的参数，例如 max=10 中的 10
+}
+
+// ValidationError 携带字段错误的 reason 错误，problem 格式输出到 errors 扩展字段
+// 仍然实现 reason.ErrorInfoer，errors.Is(err, reason.ErrBadRequest) 成立
+type ValidationError struct {
+	reason.CustomError
+	Fields []FieldError
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.CustomError
+}
+
+// bindError 绑定参数失败，details 保留原有的错误描述，校验错误同时记录字段
+func bindError(err error, in any, source string) error {
+	e := reason.ErrBadRequest.With(HanddleJSONErr(err).Error())
+	fields := fieldErrors(err, reflect.TypeOf(in), source)
+	if len(fields) == 0 {
+		return e
+	}
+	return &ValidationError{CustomError: e, Fields: fields}
+}
+
+// fieldErrors 将 gin binding 的错误转为字段错误，字段名使用对应位置的标签
+func fieldErrors(err error, t reflect.Type, source string) []FieldError {
+	var unmarshalTypeError *json.UnmarshalTypeError
+	if errors.As(err, &unmarshalTypeError) && unmarshalTypeError.Field != "" {
+		return []FieldError{{Field: unmarshalTypeError.Field, In: source, Rule: "type", Param: unmarshalTypeError.Type.String()}}
+	}
+
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	tags := []string{"json", "form", "uri"}
+	switch source {
+	case "uri":
+		tags = []string{"uri"}
+	case "query":
+		tags = []string{"form", "json"}
+	}
+	out := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		out = append(out, FieldError{
+			Field: fieldPath(t, fe.StructNamespace(), tags),
+			In:    source,
+			Rule:  fe.Tag(),
+			Param: fe.Param(),
+		})
+	}
+	return out
+}
+
+// fieldPath 将 Input.Items[0].Name 转为 items[0].name，匿名嵌入的结构体不出现在路径中
+func fieldPath(t reflect.Type, namespace string, tags []string) string {
+	parts := strings.Split(namespace, ".")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts[1:] {
+		name, index, _ := strings.Cut(p, "[")
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				t = f.Type
+				name = fieldName(f, tags)
+				if name == "" {
+					continue
+				}
+			} else {
+				t = nil
+			}
+		}
+		if index != "" {
+			name += "[" + index
+		}
+		out = append(out, name)
+	}
+	return strings.Join(out, ".")
+}
+
+// fieldName 按顺序取标签中的名称，匿名嵌入且没有标签时返回空串
+func fieldName(f reflect.StructField, tags []string) string {
+	for _, tag := range tags {
+		if name := tagName(f, tag); name != "" {
+			return name
+		}
+	}
+	if f.Anonymous {
+		return ""
+	}
+	return f.Name
+}
+
+// useProblem 按全局设置或请求头 Accept 选择 problem 格式
+func useProblem(c ResponseWriter) bool {
+	switch errorFormat {
+	case ErrorFormatProblem:
+		return true
+	case ErrorFormatEnvelope:
+		return false
+	}
+	gc, ok := c.(*gin.Context)
+	if !ok || gc.Request == nil {
+		return false
+	}
+	for part := range strings.SplitSeq(gc.GetHeader("Accept"), ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), ContentTypeProblem) {
+			return acceptQuality(params) > 0
+		}
+	}
+	return false
+}
+
+// acceptQuality 解析媒体类型参数中的 q，缺省或格式有误时为 1
+func acceptQuality(params string) float64 {
+	for param := range strings.SplitSeq(params, ";") {
+		k, v, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(k), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 1
+		}
+		return q
+	}
+	return 1
+}
+
+// newProblem 将错误转为 problem 的成员，reason、details、trace_id 与字段错误作为扩展成员
+func newProblem(c ResponseWriter, code int, err error) map[string]any {
+	out := map[string]any{
+		"type":   "about:blank",
+		"title":  http.StatusText(code),
+		"status": code,
+	}
+	if gc, ok := c.(*gin.Context); ok && gc.Request != nil {
+		out["instance"] = gc.Request.URL.Path
+	}
+	if traceID, ok := TraceID(c); ok {
+		out["trace_id"] = traceID
+	}
+
+	e, ok := err.(reason.ErrorInfoer)
+	if !ok {
+		return out
+	}
+	lang := Language(c)
+	out["reason"] = e.GetReason()
+	out["detail"] = reason.Localize(e, lang)
+	// type 为 about:blank 时 title 应当为状态描述，否则为该类错误的概述
+	if problemTypeBase != "" {
+		out["type"] = problemTypeBase + e.GetReason()
+		if title, ok := reason.Message(lang, e.GetReason()); ok {
+			out["title"] = title
+		}
+	}
+	if d := e.GetDetails(); defaultDebug && len(d) > 0 {
+		out["details"] = d
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		out["errors"] = ve.Fields
+	}
+	return out
+}
+
+// renderProblem 以 application/problem+json 输出错误
+func renderProblem(c ResponseWriter, code int, err error, fn []WithData, abort bool) {
+	out := newProblem(c, code, err)
+	for i := range fn {
+		fn[i](out)
+	}
+	if h, ok := c.(interface{ Header(key, value string) }); ok {
+		h.Header("Content-Type", ContentTypeProblem)
+	}
+	if abort {
+		c.AbortWithStatusJSON(code, out)
+	} else {
+		c.JSON(code, out)
+	}
+	c.Set(ResponseErr, err.Error())
+}
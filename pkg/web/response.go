@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"slices"
 	"strings"
 	"unsafe"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"github.com/ixugo/goddd/pkg/httpclient"
 	"github.com/ixugo/goddd/pkg/reason"
 )
 
@@ -60,18 +62,26 @@ func Success(c HTTPContext, bean any) {
 type WithData func(map[string]any)
 
 // Fail 通用错误返回
+// 格式由 SetErrorFormat 决定，problem 格式参考 newProblem
 func Fail(c ResponseWriter, err error, fn ...WithData) {
+	code := 400
+	e1, ok := err.(reason.ErrorInfoer)
+	if ok {
+		code = e1.GetHTTPCode()
+	}
+	if useProblem(c) {
+		renderProblem(c, code, err, fn, false)
+		return
+	}
+
 	out := make(map[string]any)
 	if traceID, ok := TraceID(c); ok {
 		out["trace_id"] = traceID
 	}
 
-	code := 400
-
-	if e1, ok := err.(reason.ErrorInfoer); ok {
-		code = e1.GetHTTPCode()
+	if ok {
 		out["reason"] = e1.GetReason()
-		out["msg"] = e1.GetMessage()
+		out["msg"] = reason.Localize(e1, Language(c))
 
 		// 是否需要添加 details
 		if defaultDebug {
@@ -93,15 +103,22 @@ func Fail(c ResponseWriter, err error, fn ...WithData) {
 }
 
 func AbortWithStatusJSON(c ResponseWriter, err error, fn ...WithData) {
-	out := make(map[string]any)
-
 	err1, ok := err.(reason.ErrorInfoer)
 
-	var code int
+	// 与 Fail 一致，非 reason 错误按 400 处理
+	code := 400
 	if ok {
 		code = err1.GetHTTPCode()
+	}
+	if useProblem(c) {
+		renderProblem(c, code, err, fn, true)
+		return
+	}
+
+	out := make(map[string]any)
+	if ok {
 		out["reason"] = err1.GetReason()
-		out["msg"] = err1.GetMessage()
+		out["msg"] = reason.Localize(err1, Language(c))
 
 		d := err1.GetDetails()
 		if defaultDebug && len(d) > 0 {
@@ -118,6 +135,18 @@ func AbortWithStatusJSON(c ResponseWriter, err error, fn ...WithData) {
 	c.Set(ResponseErr, err.Error())
 }
 
+// Language 错误消息使用的语言，令牌中的 lang 优先，其次为请求头 Accept-Language
+// 只返回 reason 中有翻译的语言，无法匹配时为 reason.DefaultLanguage
+func Language(c context.Context) string {
+	if lang, _ := c.Value(KeyLanguage).(string); lang != "" {
+		return reason.MatchLanguage(lang)
+	}
+	if gc, ok := c.(*gin.Context); ok && gc.Request != nil {
+		return reason.MatchLanguage(gc.GetHeader("Accept-Language"))
+	}
+	return reason.DefaultLanguage
+}
+
 // WrapHs 包装业务处理函数的同时，支持多个中间件
 func WrapHs[I any, O any](fn func(*gin.Context, *I) (O, error), mid ...gin.HandlerFunc) []gin.HandlerFunc {
 	return slices.Concat(mid, []gin.HandlerFunc{WrapH(fn)})
@@ -135,14 +164,14 @@ func WrapH[I any, O any](fn func(*gin.Context, *I) (O, error)) gin.HandlerFunc {
 					m[v.Key] = []string{v.Value}
 				}
 				if err := binding.MapFormWithTag(&in, m, "uri"); err != nil {
-					Fail(c, reason.ErrBadRequest.With(HanddleJSONErr(err).Error()))
+					Fail(c, bindError(err, &in, "uri"))
 					return
 				}
 			}
 			switch c.Request.Method {
 			case http.MethodGet:
 				if err := c.ShouldBindQuery(&in); err != nil {
-					Fail(c, reason.ErrBadRequest.With(HanddleJSONErr(err).Error()))
+					Fail(c, bindError(err, &in, "query"))
 					return
 				}
 			case http.MethodDelete:
@@ -155,12 +184,12 @@ func WrapH[I any, O any](fn func(*gin.Context, *I) (O, error)) gin.HandlerFunc {
 						return
 					}
 					if err := c.ShouldBind(&in); err != nil {
-						Fail(c, reason.ErrBadRequest.With(HanddleJSONErr(err).Error()))
+						Fail(c, bindError(err, &in, "body"))
 						return
 					}
 				} else {
 					if err := c.ShouldBindQuery(&in); err != nil {
-						Fail(c, reason.ErrBadRequest.With(HanddleJSONErr(err).Error()))
+						Fail(c, bindError(err, &in, "query"))
 						return
 					}
 				}
@@ -172,17 +201,27 @@ func WrapH[I any, O any](fn func(*gin.Context, *I) (O, error)) gin.HandlerFunc {
 						return
 					}
 					if err := c.ShouldBind(&in); err != nil {
-						Fail(c, reason.ErrBadRequest.With(HanddleJSONErr(err).Error()))
+						Fail(c, bindError(err, &in, "body"))
 						return
 					}
 				}
 			}
 		}
+		// 嵌套 PagerFilter 的入参，需要原始查询参数解析过滤条件
+		if v, ok := any(&in).(interface{ setRawQuery(url.Values) }); ok {
+			v.setRawQuery(c.Request.URL.Query())
+		}
+		// 嵌套 IfMatch 的入参，修改前校验版本号
+		if err := bindIfMatch(c, &in); err != nil {
+			Fail(c, err)
+			return
+		}
 		out, err := fn(c, &in)
 		if err != nil {
 			Fail(c, err)
 			return
 		}
+		setETag(c, out)
 		Success(c, out)
 	}
 }
@@ -191,16 +230,13 @@ type ResponseMsg struct {
 	Msg string `json:"msg"`
 }
 
-// HandlerResponseMsg 获取响应的结果
+// HandlerResponseMsg 获取响应的结果，非 200 时按 httpclient.DecodeError 还原错误
+// Deprecated: 调用其它服务使用 httpclient.Client，提供重试、熔断与链路传递
 func HandlerResponseMsg(resp http.Response) error {
 	if resp.StatusCode == 200 {
 		return nil
 	}
-	var out ResponseMsg
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return reason.ErrServer.SetMsg(out.Msg)
-	}
-	return reason.ErrServer.SetMsg(resp.Status)
+	return httpclient.DecodeError(&resp)
 }
 
 func HanddleJSONErr(err error) error {
@@ -3,11 +3,15 @@ package web
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
 )
 
 // reqBind 同时带 form 和 uri tag 的请求结构体
@@ -366,3 +370,252 @@ func TestBind_GetWithURIRequired_MissingQuery(t *testing.T) {
 		t.Fatal("缺少 required 字段 name 应返回 400")
 	}
 }
+
+// reqFilter 嵌套 PagerFilter，验证过滤条件
+type reqFilter struct {
+	PagerFilter
+}
+
+func TestBind_PagerFilterOptions(t *testing.T) {
+	r := gin.New()
+	r.GET("/items", WrapH(func(c *gin.Context, in *reqFilter) (any, error) {
+		in.FilterSafelist = orm.FilterSafelist{
+			"scope":      {},
+			"expired_at": {Parse: orm.ParseFilterTime},
+		}
+		opts, err := in.FilterOptions()
+		return gin.H{"count": len(opts)}, err
+	}))
+	// 未设置 FilterSafelist 的接口
+	r.GET("/plain", WrapH(func(c *gin.Context, in *reqFilter) (any, error) {
+		opts, err := in.FilterOptions()
+		return gin.H{"count": len(opts)}, err
+	}))
+
+	cases := []struct {
+		path  string
+		query string
+		code  int
+		count float64
+	}{
+		{query: "", code: http.StatusOK, count: 0},
+		{query: "filter=" + url.QueryEscape(`scope eq "web"`) + "&expired_at[gte]=1700000000000", code: http.StatusOK, count: 2},
+		{query: "expired_at[lt]=abc", code: http.StatusBadRequest},
+		{query: "filter=" + url.QueryEscape(`user_id eq "1"`), code: http.StatusBadRequest},
+		{path: "/plain", query: "page=1", code: http.StatusOK, count: 0},
+		{path: "/plain", query: "filter=" + url.QueryEscape(`scope eq "web"`), code: http.StatusBadRequest},
+		{path: "/plain", query: "expired_at[gte]=1700000000000", code: http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		if c.path == "" {
+			c.path = "/items"
+		}
+		req := httptest.NewRequest(http.MethodGet, c.path+"?"+c.query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code {
+			t.Fatalf("%s: 期望 %d，实际 %d, body: %s", c.query, c.code, w.Code, w.Body.String())
+		}
+		if c.code != http.StatusOK {
+			continue
+		}
+		var resp map[string]any
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp["count"].(float64) != c.count {
+			t.Errorf("%s: count = %v, 期望 %v", c.query, resp["count"], c.count)
+		}
+	}
+}
+
+func TestBind_PagerFilterUseCursor(t *testing.T) {
+	r := gin.New()
+	r.GET("/items", WrapH(func(c *gin.Context, in *reqFilter) (any, error) {
+		return gin.H{"cursor": in.UseCursor()}, nil
+	}))
+	cases := []struct {
+		query  string
+		cursor bool
+	}{
+		{query: "page=1", cursor: false},
+		{query: "cursor=", cursor: true},
+		{query: "cursor=abc&size=10", cursor: true},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+c.query, nil))
+		var resp map[string]bool
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp["cursor"] != c.cursor {
+			t.Errorf("%s: cursor = %v, 期望 %v", c.query, resp["cursor"], c.cursor)
+		}
+	}
+}
+
+type reqEdit struct {
+	IfMatch
+	Name string `json:"name"`
+}
+
+func TestBind_IfMatchETag(t *testing.T) {
+	r := gin.New()
+	r.GET("/items/:id", WrapH(func(c *gin.Context, _ *struct{}) (*orm.Model, error) {
+		return &orm.Model{ID: 1, Version: 3}, nil
+	}))
+	r.PUT("/items/:id", WrapH(func(c *gin.Context, in *reqEdit) (*orm.Model, error) {
+		if !in.MatchVersion(3) {
+			return nil, reason.ErrVersionConflict
+		}
+		return &orm.Model{ID: 1, Version: 4}, nil
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+	if etag := w.Header().Get("ETag"); etag != `"3"` {
+		t.Fatalf("ETag = %q, 期望 \"3\"", etag)
+	}
+
+	cases := []struct {
+		ifMatch string
+		code    int
+		etag    string
+	}{
+		{ifMatch: "", code: http.StatusPreconditionRequired},
+		{ifMatch: `"2"`, code: http.StatusPreconditionFailed},
+		{ifMatch: `W/"3"`, code: http.StatusPreconditionFailed},
+		{ifMatch: `"abc"`, code: http.StatusPreconditionFailed},
+		{ifMatch: `3`, code: http.StatusBadRequest},
+		{ifMatch: `"3`, code: http.StatusBadRequest},
+		{ifMatch: `"3", 4`, code: http.StatusBadRequest},
+		{ifMatch: `"1", "2"`, code: http.StatusPreconditionFailed},
+		{ifMatch: `"2", "3"`, code: http.StatusOK, etag: `"4"`},
+		{ifMatch: `"3"`, code: http.StatusOK, etag: `"4"`},
+		{ifMatch: "*", code: http.StatusOK, etag: `"4"`},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBufferString(`{"name":"a"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if c.ifMatch != "" {
+			req.Header.Set("If-Match", c.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != c.code || w.Header().Get("ETag") != c.etag {
+			t.Errorf("If-Match %q: 期望 %d %q，实际 %d %q, body: %s", c.ifMatch, c.code, c.etag, w.Code, w.Header().Get("ETag"), w.Body.String())
+		}
+	}
+}
+
+func TestFail_Language(t *testing.T) {
+	r := gin.New()
+	r.GET("/items", func(c *gin.Context) {
+		if lang := c.Query("lang"); lang != "" {
+			c.Set(KeyLanguage, lang)
+		}
+		Fail(c, reason.ErrNotFound.SetMsg("用户不存在"))
+	})
+
+	msg := func(accept, query string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/items?lang="+query, nil)
+		req.Header.Set("Accept-Language", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var out map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out["msg"].(string)
+	}
+	if v := msg("", ""); v != "用户不存在" {
+		t.Fatalf("expect default message, got %s", v)
+	}
+	if v := msg("en-US,en;q=0.9", ""); v != "Resource not found" {
+		t.Fatalf("expect english message, got %s", v)
+	}
+	// 令牌中的语言优先
+	if v := msg("en-US", "zh"); v != "用户不存在" {
+		t.Fatalf("expect claim language, got %s", v)
+	}
+}
+
+func TestFail_Problem(t *testing.T) {
+	type item struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type input struct {
+		PagerFilter
+		Title string `json:"title" binding:"max=3"`
+		Items []item `json:"items" binding:"dive"`
+	}
+	r := gin.New()
+	r.POST("/items", WrapH(func(_ *gin.Context, _ *input) (any, error) {
+		return nil, nil
+	}))
+	r.GET("/items/:id", func(c *gin.Context) {
+		Fail(c, reason.ErrNotFound.SetHTTPStatus(404).SetMsg("用户不存在"))
+	})
+	r.GET("/abort", func(c *gin.Context) {
+		AbortWithStatusJSON(c, errors.New("boom"))
+	})
+
+	do := func(method, target, accept, body string) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var out map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return w, out
+	}
+
+	// 默认按 Accept 协商
+	if w, out := do(http.MethodGet, "/items/1", "application/json", ""); w.Header().Get("Content-Type") == ContentTypeProblem || out["msg"] != "用户不存在" {
+		t.Fatalf("expect envelope, got %s %v", w.Header().Get("Content-Type"), out)
+	}
+	w, out := do(http.MethodGet, "/items/1", "application/problem+json, application/json;q=0.9", "")
+	if w.Code != 404 || w.Header().Get("Content-Type") != ContentTypeProblem {
+		t.Fatalf("expect problem, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if out["type"] != "about:blank" || out["title"] != "Not Found" || out["status"] != float64(404) ||
+		out["detail"] != "用户不存在" || out["instance"] != "/items/1" || out["reason"] != "ErrNotFound" {
+		t.Fatalf("unexpected %v", out)
+	}
+
+	for _, accept := range []string{"application/problem+json;q=0", "application/problem+json; q=0.0", "application/problem+json;q=0.000"} {
+		if w, _ := do(http.MethodGet, "/items/1", accept, ""); w.Header().Get("Content-Type") == ContentTypeProblem {
+			t.Fatalf("expect %q refused problem", accept)
+		}
+	}
+	if w, _ := do(http.MethodGet, "/items/1", "application/problem+json;q=0.5", ""); w.Header().Get("Content-Type") != ContentTypeProblem {
+		t.Fatal("expect problem")
+	}
+
+	// 非 reason 错误默认 400
+	for _, accept := range []string{"application/json", ContentTypeProblem} {
+		if w, _ := do(http.MethodGet, "/abort", accept, ""); w.Code != http.StatusBadRequest {
+			t.Fatalf("expect 400, got %d", w.Code)
+		}
+	}
+
+	SetErrorFormat(ErrorFormatProblem, "https://example.com/errors/")
+	defer SetErrorFormat(ErrorFormatAuto, "")
+	if _, out := do(http.MethodGet, "/items/1", "", ""); out["type"] != "https://example.com/errors/ErrNotFound" || out["title"] != "资源未找到" {
+		t.Fatalf("unexpected %v", out)
+	}
+
+	// 字段级的校验错误
+	_, out = do(http.MethodPost, "/items", "", `{"title":"abcd","items":[{"name":"a"},{}]}`)
+	b, _ := json.Marshal(out["errors"])
+	expect := `[{"field":"title","in":"body","param":"3","rule":"max"},{"field":"items[1].name","in":"body","rule":"required"}]`
+	if string(b) != expect {
+		t.Fatalf("expect %s, got %s", expect, b)
+	}
+	_, out = do(http.MethodPost, "/items", "", `{"title":1}`)
+	if b, _ := json.Marshal(out["errors"]); string(b) != `[{"field":"title","in":"body","param":"string","rule":"type"}]` {
+		t.Fatalf("unexpected %s", b)
+	}
+}
@@ -2,8 +2,10 @@ package web
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/trace"
 )
 
 const traceIDKey = "TRACE_ID_KEY"
@@ -24,3 +26,29 @@ func TraceID(ctx context.Context) (string, bool) {
 func SetTraceID(ctx *gin.Context, id string) {
 	ctx.Set(traceIDKey, id)
 }
+
+// startServerSpan 解析请求头中的 traceparent，为请求创建 span
+func startServerSpan(c *gin.Context) (context.Context, *trace.Span) {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	ctx := trace.Extract(c.Request.Context(), c.Request.Header)
+	return trace.Start(ctx, c.Request.Method+" "+route, trace.KindServer,
+		trace.Attr{Key: "http.request.method", Value: c.Request.Method},
+		trace.Attr{Key: "http.route", Value: c.FullPath()},
+		trace.Attr{Key: "url.path", Value: c.Request.URL.Path},
+		trace.Attr{Key: "client.address", Value: c.ClientIP()},
+		trace.Attr{Key: "user_agent.original", Value: c.Request.UserAgent()},
+	)
+}
+
+func endServerSpan(c *gin.Context, span *trace.Span) {
+	code := c.Writer.Status()
+	span.SetAttr("http.response.status_code", code)
+	if code >= 500 {
+		errStr, _ := c.Get(ResponseErr)
+		span.SetStatus(trace.StatusError, fmt.Sprint(errStr))
+	}
+	span.End()
+}
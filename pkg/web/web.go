@@ -3,20 +3,33 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"slices"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ixugo/goddd/pkg/orm"
+	"github.com/ixugo/goddd/pkg/reason"
 )
 
 // ScrollPageOutput 滚动翻页
+// Next 为下一页的游标，作为 cursor 参数请求下一页，为空表示没有更多数据
 type ScrollPageOutput[T any] struct {
 	Items []T    `json:"items"`
 	Next  string `json:"next"`
 }
 
+// NewScrollPageOutput 配合 orm.ScrollWithContext 使用
+// items 为 nil 时返回空数组，避免前端处理 null
+func NewScrollPageOutput[T any](items []T, next string) *ScrollPageOutput[T] {
+	if items == nil {
+		items = make([]T, 0)
+	}
+	return &ScrollPageOutput[T]{Items: items, Next: next}
+}
+
 // PageOutput 分页数据
 type PageOutput[T any] struct {
 	Items []T   `json:"items"`
@@ -24,11 +37,47 @@ type PageOutput[T any] struct {
 }
 
 // PagerFilter 分页过滤
+// 使用 orm.ScrollWithContext 游标分页时，忽略 Page，通过 Cursor 翻页
 type PagerFilter struct {
-	Page         int      `form:"page"`
-	Size         int      `form:"size"`
-	Sort         string   `form:"sort"`
-	SortSafelist []string `json:"-"`
+	Page           int                `form:"page"`
+	Size           int                `form:"size"`
+	Sort           string             `form:"sort"`
+	Cursor         string             `form:"cursor"` // 上一页响应中的 next，首页为空
+	Filter         string             `form:"filter"` // 过滤表达式，例如 scope eq "web" and expired_at gt 1700000000000
+	SortSafelist   []string           `json:"-"`
+	FilterSafelist orm.FilterSafelist `json:"-"` // 允许过滤的字段，为空时不支持过滤
+
+	rawQuery url.Values
+}
+
+// setRawQuery 由 WrapH 调用，用于解析 expired_at[gte]=... 形式的过滤条件
+func (f *PagerFilter) setRawQuery(query url.Values) {
+	f.rawQuery = query
+}
+
+// FilterOptions 将 Filter 表达式与 field[op]=value 形式的查询参数转换为查询条件
+// 字段必须在 FilterSafelist 中，否则返回 reason.ErrBadRequest，未设置 FilterSafelist 时传入任何过滤条件都会返回错误
+func (f PagerFilter) FilterOptions() ([]orm.QueryOption, error) {
+	filters, err := orm.ParseFilter(f.Filter)
+	if err != nil {
+		return nil, reason.ErrBadRequest.SetMsg("过滤条件有误").With(err.Error())
+	}
+	brackets, err := orm.ParseFilterBrackets(f.rawQuery)
+	if err != nil {
+		return nil, reason.ErrBadRequest.SetMsg("过滤条件有误").With(err.Error())
+	}
+	filters = append(filters, brackets...)
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	if len(f.FilterSafelist) == 0 {
+		return nil, reason.ErrBadRequest.SetMsg("过滤条件有误").With("该接口不支持过滤")
+	}
+	opts, err := orm.CompileFilters(filters, f.FilterSafelist)
+	if err != nil {
+		return nil, reason.ErrBadRequest.SetMsg("过滤条件有误").With(err.Error())
+	}
+	return opts, nil
 }
 
 func NewPagerFilterMaxSize() PagerFilter {
@@ -95,6 +144,16 @@ func (f PagerFilter) SortDirection() string {
 	return "ASC"
 }
 
+// GetCursor 游标分页的游标
+func (f PagerFilter) GetCursor() string {
+	return f.Cursor
+}
+
+// UseCursor 请求携带 cursor 参数时使用游标分页，首页传空值 cursor=
+func (f PagerFilter) UseCursor() bool {
+	return f.Cursor != "" || f.rawQuery.Has("cursor")
+}
+
 // Offset 计算偏离数值
 func (f PagerFilter) Offset() int {
 	if f.Page < 1 {